@@ -3,15 +3,33 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+	"time"
 	apiserver_audit_log "tools/pkg/log_processor/audit_log"
 	"tools/pkg/log_processor/etcd_log"
+	"tools/pkg/log_processor/run_analysis"
 	"tools/pkg/log_processor/trace_log"
 )
 
 func main() {
+	switch flag.Arg(0) {
+	case "compare":
+		compareRuns(flag.Args()[1:])
+		return
+	case "gate":
+		gateRun(flag.Args()[1:])
+		return
+	case "trace":
+		processTrace(flag.Args()[1:])
+		return
+	}
+
 	//pathToFind := "/home/yinghuang/log/processing"
 	//log_processor.ExtractPodSchedulingLog(pathToFind)
 	//log_processor.ExtractScheduledAndNonScheduledPod(pathToFind)
+	//scheduler_log.ExtractArktosPodSchedulingPhases(pathToFind)
+	//scheduler_log.ExtractNodePlacement(pathToFind)
+	//scheduler_log.ExtractSchedulerThroughput(pathToFind, 10)
 	//log_processor.GetTimeToNano(pathToFind, "wcm-7-throttle-rs.txt", "wcm-7-throttle-rs.output")
 
 	//parseTraceFile()
@@ -28,9 +46,82 @@ func main() {
 
 	//pathToFind := "/home/yinghuang/debug/2021-04-09-debug/evaluated1500"
 	//controller_log.ExtractPodSchedulingTime(pathToFind)
+	//controller_log.ExtractPodStartupLatency(pathToFind, controller_log.DefaultPodStartupLogFiles())
+	//controller_log.ExtractControllerSyncLatency(pathToFind, "kube-controller-manager.log")
+	//controller_log.ExtractEventReport(pathToFind, "kube-controller-manager.log", time.Minute)
+	//controller_log.ExtractNodeLifecycle(pathToFind, "kube-controller-manager.log", time.Minute)
+	//leader_election_log.ExtractLeaderElection(pathToFind, []string{"kube-controller-manager-1.log", "kube-controller-manager-2.log"}, "lease-update-audit.log")
+	//kubelet_log.ExtractKubeletLog(pathToFind, "kubelet.log")
 	parseAuditLogGetLeaseUpdate()
 }
 
+// tools [-output_path path] compare <runA> <runB>
+func compareRuns(args []string) {
+	if len(args) != 2 {
+		fmt.Println("Usage: tools [-output_path path] compare <runA> <runB>")
+		os.Exit(2)
+	}
+	run_analysis.CompareRuns(args[0], args[1], output_path)
+}
+
+// tools gate -thresholds <file> <run>
+// Exit code is 1 if any threshold is violated, 2 if gate cannot be evaluated
+func gateRun(args []string) {
+	gateFlags := flag.NewFlagSet("gate", flag.ExitOnError)
+	thresholdFilename := gateFlags.String("thresholds", "", "absolute path to the baseline threshold file")
+	gateFlags.Parse(args)
+	if *thresholdFilename == "" || gateFlags.NArg() != 1 {
+		fmt.Println("Usage: tools gate -thresholds <file> <run>")
+		os.Exit(2)
+	}
+
+	isPassed, err := run_analysis.GateRun(gateFlags.Arg(0), *thresholdFilename)
+	if err != nil {
+		fmt.Printf("Error evaluating gate: %v\n", err)
+		os.Exit(2)
+	}
+	if !isPassed {
+		os.Exit(1)
+	}
+}
+
+// tools trace [-format compacted|stats|chrome|otlp|etcd|partition] [-otlp_endpoint url] [-slow_threshold duration] <path>
+// Reads apiserver.Trace in the path, and etcd.to.execute.range.log for etcd format
+func processTrace(args []string) {
+	traceFlags := flag.NewFlagSet("trace", flag.ExitOnError)
+	otlpEndpoint := traceFlags.String("otlp_endpoint", "", "OTLP HTTP endpoint to post spans to, e.g. http://localhost:4318/v1/traces. Spans are written to file if not set")
+	slowThreshold := traceFlags.Duration("slow_threshold", 100*time.Millisecond, "traces taking longer than this are correlated with etcd requests")
+	format := traceFlags.String("format", "compacted", "compacted: one line per trace\nstats: step statistics by message template\nchrome: Chrome Trace Event JSON for Perfetto or chrome://tracing\notlp: OTLP/JSON spans\netcd: etcd and apiserver side time of slow traces\npartition: Arktos requests and latency by etcd partition and tenant")
+	traceFlags.Parse(args)
+	if traceFlags.NArg() != 1 {
+		fmt.Println("Usage: tools trace [-format compacted|stats|chrome|otlp|etcd|partition] [-otlp_endpoint url] [-slow_threshold duration] <path>")
+		os.Exit(2)
+	}
+
+	pathToFind := traceFlags.Arg(0)
+	switch *format {
+	case "compacted":
+		trace_log.ExtractTraceLog(pathToFind)
+	case "stats":
+		trace_log.ExtractTraceStats(pathToFind)
+	case "chrome":
+		trace_log.ExportChromeTrace(pathToFind)
+	case "otlp":
+		err := trace_log.ExportOtlpTrace(pathToFind, *otlpEndpoint)
+		if err != nil {
+			fmt.Printf("Error exporting otlp spans: %v\n", err)
+			os.Exit(2)
+		}
+	case "etcd":
+		trace_log.CorrelateTraceWithEtcd(pathToFind, *slowThreshold)
+	case "partition":
+		trace_log.ExtractTracePartitions(pathToFind)
+	default:
+		fmt.Printf("Invalid trace format %s\n", *format)
+		os.Exit(2)
+	}
+}
+
 func parseTraceFile() {
 	//pathToFind := "/home/yinghuang/apiserver-perf/gce-500"
 	pathToFind := "/home/yinghuang/apiserver-perf/xiaoning.trace.10.02"
@@ -52,6 +143,11 @@ func parseEtcdLogFile() {
 		etcd_log.ParseRangeLog(pathToFind)
 		fmt.Println()
 		etcd_log.ParNonRangeLog(pathToFind)
+	case 3:
+		etcd_log.ExtractEtcdOperationLog(pathToFind)
+	case 4:
+		// writes with value larger than 100KB, write frequency in 10 seconds window
+		etcd_log.ExtractEtcdKeyChurn(pathToFind, 100*1024, 10*time.Second)
 	}
 }
 
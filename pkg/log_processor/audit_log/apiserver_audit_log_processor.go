@@ -6,7 +6,9 @@ import (
 	"kubernetes/staging/src/k8s.io/apimachinery/pkg/util/json"
 	"os"
 	"path"
+	"sort"
 	"strings"
+	"time"
 	"tools/pkg/log_util"
 )
 
@@ -38,6 +40,9 @@ type APIServerAuditLog struct {
 	//annotations
 }
 
+// Latency sketches by verb are written next to latency file, e.g. latency-kube-apiserver-audit.log.sketch
+const latencySketchFileSuffix = log_util.QuantileSketchFileSuffix
+
 type requestCount struct {
 	// RequestURI string
 	Verb string
@@ -46,17 +51,17 @@ type requestCount struct {
 	Stage string
 }
 
-func readAuditLog(filePath string, errAuditFileHandler *os.File) ([]APIServerAuditLog, error) {
+// Audit entries are passed to process one by one so that the audit log is not loaded into memory
+func scanAuditLog(filePath string, errAuditFileHandler *os.File, process func(log *APIServerAuditLog)) error {
 	inputfileHandler, err := os.Open(filePath)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer inputfileHandler.Close()
 
 	lineReader := bufio.NewReader(inputfileHandler)
 	lineCount := 0
-	apiServerAuditLog := make([]APIServerAuditLog, 0)
 
 	for {
 		line, err := lineReader.ReadString('\n')
@@ -75,24 +80,22 @@ func readAuditLog(filePath string, errAuditFileHandler *os.File) ([]APIServerAud
 			//fmt.Printf("Error unmarshall line [%s]: %v\n", line, err)
 			//return nil, err
 		}
-		apiServerAuditLog = append(apiServerAuditLog, log)
+		process(&log)
 	}
 
 	fmt.Printf("Total processd line %d\n", lineCount)
 
-	return apiServerAuditLog, nil
+	return nil
 }
 
-func processAuditLog(inputFilename string, outputFileHandler1, outputFileHandler2, otherFileHandler, errAuditFileHandler *os.File) {
-	apiServerAuditLog, err := readAuditLog(inputFilename, errAuditFileHandler)
-	if err != nil {
-		fmt.Printf("Error reading audit log: %v\n", err)
-		return
-	}
-
+// Returns latency sketches by verb, nil if the audit log cannot be read
+func processAuditLog(inputFilename string, outputFileHandler1, outputFileHandler2, otherFileHandler, latencyFileHandler, errAuditFileHandler *os.File) map[string]*log_util.QuantileSketch {
 	// map compacted requestURI -> key -> requestCount
 	reqURIMap := make(map[string]map[string]*requestCount, 0)
-	for _, log := range apiServerAuditLog {
+	verbLatencies := make(map[string]*log_util.QuantileSketch)
+	err := scanAuditLog(inputFilename, errAuditFileHandler, func(log *APIServerAuditLog) {
+		addAuditLatency(verbLatencies, log)
+
 		key := fmt.Sprintf("%s:%s:s", log.Verb, log.ResponseStatus.Code, log.Stage)
 		compactedURI := getCompactURI(log.RequestURI)
 
@@ -118,6 +121,10 @@ func processAuditLog(inputFilename string, outputFileHandler1, outputFileHandler
 
 			reqURIMap[compactedURI] = reqCountMap
 		}
+	})
+	if err != nil {
+		fmt.Printf("Error reading audit log: %v\n", err)
+		return nil
 	}
 
 	// print out count
@@ -138,6 +145,53 @@ func processAuditLog(inputFilename string, outputFileHandler1, outputFileHandler
 			}
 		}
 	}
+
+	writeAuditLatency(verbLatencies, latencyFileHandler)
+	return verbLatencies
+}
+
+// Latency from request received to response complete, per verb
+func addAuditLatency(verbLatencies map[string]*log_util.QuantileSketch, log *APIServerAuditLog) {
+	if log.Stage != "ResponseComplete" {
+		return
+	}
+
+	latency, err := getAuditLatency(log)
+	if err != nil {
+		fmt.Printf("Error getting latency of audit [%s]: %v\n", log.AuditID, err)
+		return
+	}
+	if _, isOK := verbLatencies[log.Verb]; !isOK {
+		verbLatencies[log.Verb] = log_util.NewDefaultQuantileSketch()
+	}
+	verbLatencies[log.Verb].Add(latency)
+}
+
+func writeAuditLatency(verbLatencies map[string]*log_util.QuantileSketch, latencyFileHandler *os.File) {
+	verbs := make([]string, 0, len(verbLatencies))
+	for verb := range verbLatencies {
+		verbs = append(verbs, verb)
+	}
+	sort.Strings(verbs)
+
+	header := "verb, count, p50_ms, p90_ms, p99_ms, max_ms\n"
+	latencyFileHandler.WriteString(header)
+	for _, verb := range verbs {
+		line := fmt.Sprintf("%s, %s\n", verb, log_util.GetSketchStatsOutput(verbLatencies[verb]))
+		latencyFileHandler.WriteString(line)
+	}
+}
+
+func getAuditLatency(log *APIServerAuditLog) (time.Duration, error) {
+	receivedTime, err := time.Parse(time.RFC3339Nano, log.RequestReceivedTimeStamp)
+	if err != nil {
+		return 0, err
+	}
+	stageTime, err := time.Parse(time.RFC3339Nano, log.StageTimeStamp)
+	if err != nil {
+		return 0, err
+	}
+	return stageTime.Sub(receivedTime), nil
 }
 
 func getCompactURI(requestURI string) string {
@@ -149,7 +203,7 @@ func getCompactURI(requestURI string) string {
 	return requestURI
 }
 
-func ProcessAuditLog(inputFilename, outputFilename1, outputFilename2, otherFilename, errorAuditLogFilename string) {
+func ProcessAuditLog(inputFilename, outputFilename1, outputFilename2, otherFilename, latencyFilename, errorAuditLogFilename string) {
 	outputFileHandler1, err := os.Create(outputFilename1)
 	if err != nil {
 		fmt.Printf("Error open output file [%s]: %v\n", outputFilename1, err)
@@ -171,6 +225,13 @@ func ProcessAuditLog(inputFilename, outputFilename1, outputFilename2, otherFilen
 	}
 	defer otherFileHandler.Close()
 
+	latencyFileHandler, err := os.Create(latencyFilename)
+	if err != nil {
+		fmt.Printf("Error open audit latency file [%s]: %v\n", latencyFilename, err)
+		return
+	}
+	defer latencyFileHandler.Close()
+
 	errAuditFileHandler, err := os.Create(errorAuditLogFilename)
 	if err != nil {
 		fmt.Printf("Error open unparserable audit log file [%s]: %v\n", errorAuditLogFilename, err)
@@ -178,7 +239,11 @@ func ProcessAuditLog(inputFilename, outputFilename1, outputFilename2, otherFilen
 	}
 	defer errAuditFileHandler.Close()
 
-	processAuditLog(inputFilename, outputFileHandler1, outputFileHandler2, otherFileHandler, errAuditFileHandler)
+	// sketches of multiple audit files are merged by run analysis
+	latencySketches := processAuditLog(inputFilename, outputFileHandler1, outputFileHandler2, otherFileHandler, latencyFileHandler, errAuditFileHandler)
+	if latencySketches != nil {
+		log_util.WriteQuantileSketches(latencyFilename+latencySketchFileSuffix, latencySketches)
+	}
 }
 
 func ExtractAuditLog(outputPath string, inputFilename string) {
@@ -186,6 +251,7 @@ func ExtractAuditLog(outputPath string, inputFilename string) {
 	outputFilename1 := path.Join(outputPath, "compact-start-"+filenameShort)
 	outputFilename2 := path.Join(outputPath, "compact-complete-"+filenameShort)
 	otherFilename := path.Join(outputPath, "compact-Unexpected-"+filenameShort)
+	latencyFilename := path.Join(outputPath, "latency-"+filenameShort)
 	errorAuditLogFilename := path.Join(outputPath, "error-entry-"+filenameShort)
-	ProcessAuditLog(inputFilename, outputFilename1, outputFilename2, otherFilename, errorAuditLogFilename)
+	ProcessAuditLog(inputFilename, outputFilename1, outputFilename2, otherFilename, latencyFilename, errorAuditLogFilename)
 }
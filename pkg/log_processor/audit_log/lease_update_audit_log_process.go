@@ -34,16 +34,10 @@ func ProcessLeaseUpdateAuditLog(inputFilename, outputFilename, errorAuditLogFile
 }
 
 func processLeaseUpdateAuditLog(inputFilename string, outputFileHandler, errAuditFileHandler *os.File) {
-	apiServerAuditLog, err := readAuditLog(inputFilename, errAuditFileHandler)
-	if err != nil {
-		fmt.Printf("Error reading audit log: %v\n", err)
-		return
-	}
-
 	// Get only verb=update, resource=leases
 	// map update leases request to time (previous to second for now) -> count
 	leaseDistributedMap := make(map[string]int, 0)
-	for _, log := range apiServerAuditLog {
+	err := scanAuditLog(inputFilename, errAuditFileHandler, func(log *APIServerAuditLog) {
 		if log.Verb == "update" && strings.HasPrefix(log.RequestURI , "/apis/coordination.k8s.io/v1beta1/tenants/system/namespaces/kube-node-lease/leases/hollow-node-") {
 			dt := log_util.GetDateTime(log.RequestReceivedTimeStamp, 19)
 			if count, isOK := leaseDistributedMap[dt]; isOK {
@@ -52,6 +46,10 @@ func processLeaseUpdateAuditLog(inputFilename string, outputFileHandler, errAudi
 				leaseDistributedMap[dt] = 1
 			}
 		}
+	})
+	if err != nil {
+		fmt.Printf("Error reading audit log: %v\n", err)
+		return
 	}
 
 	// print out count
@@ -0,0 +1,179 @@
+package controller_log
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"tools/pkg/log_util"
+)
+
+type eventReasonStats struct {
+	kind      string
+	eventType string
+	reason    string
+	count     int
+	objects   map[string]bool
+	firstTime string
+	lastTime  string
+	message   string // first message, e.g. why FailedCreate happened
+}
+
+// Events by kind and reason, from the event.go lines of any component log, e.g. kube-controller-manager or scheduler.
+// Outputs are named after the input file:
+// .events.reason: count, distinct objects, first and last time and first message per kind, type and reason
+// .events.timeline: count per interval of each kind.reason, one column per kind.reason so that FailedCreate,
+// FailedScheduling and similar stand out over time. Interval is one minute if not set.
+func ExtractEventReport(pathToFind string, inputFile string, interval time.Duration) {
+	inputFilename := path.Join(pathToFind, inputFile)
+	reasonFilename := inputFilename + ".events.reason"
+	timelineFilename := inputFilename + ".events.timeline"
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	reasons := make(map[string]*eventReasonStats)
+	eventTimes := make(map[string][]time.Time)
+	firstEventTime := time.Time{}
+	lineCount := 0
+	eventCount := 0
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		lineCount++
+		event, isOK := log_util.ParseEventLine(line)
+		if !isOK {
+			continue
+		}
+		eventCount++
+		addEventReason(reasons, event)
+
+		eventTime, err := log_util.ParseLogTime(event.LogTime)
+		if err != nil {
+			fmt.Printf("Error parsing event time [%s]: %v\n", event.LogTime, err)
+			continue
+		}
+		if firstEventTime.IsZero() {
+			firstEventTime = eventTime
+		}
+		column := getEventTimelineColumn(event)
+		eventTimes[column] = append(eventTimes[column], eventTime)
+	}
+
+	reasonFileHandler, err := os.Create(reasonFilename)
+	if err != nil {
+		fmt.Printf("Error create event reason file [%s]: %v\n", reasonFilename, err)
+		panic(err)
+	}
+	defer reasonFileHandler.Close()
+
+	timelineFileHandler, err := os.Create(timelineFilename)
+	if err != nil {
+		fmt.Printf("Error create event timeline file [%s]: %v\n", timelineFilename, err)
+		panic(err)
+	}
+	defer timelineFileHandler.Close()
+
+	reasonFileHandler.WriteString("kind, type, reason, count, objects, first_time, last_time, first_message\n")
+	for _, stats := range getSortedEventReasons(reasons) {
+		reasonFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %d, %d, %s, %s, %s\n", stats.kind, stats.eventType,
+			stats.reason, stats.count, len(stats.objects), stats.firstTime, stats.lastTime, stats.message))
+	}
+
+	columns := make([]string, 0, len(eventTimes))
+	for column := range eventTimes {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	startTime, timeline := getEventTimeline(firstEventTime, columns, eventTimes, interval)
+	timelineFileHandler.WriteString("time, " + strings.Join(columns, ", ") + "\n")
+	for i, counts := range timeline {
+		countStrings := make([]string, len(counts))
+		for j, count := range counts {
+			countStrings[j] = fmt.Sprintf("%d", count)
+		}
+		timeOfInterval := startTime.Add(time.Duration(i) * interval).Format("15:04:05")
+		timelineFileHandler.WriteString(timeOfInterval + ", " + strings.Join(countStrings, ", ") + "\n")
+	}
+	fmt.Printf("Total line %d, events %d, reasons %d\n", lineCount, eventCount, len(reasons))
+}
+
+func addEventReason(reasons map[string]*eventReasonStats, event *log_util.KubeEvent) {
+	key := event.Kind + " " + event.Type + " " + event.Reason
+	stats, isOK := reasons[key]
+	if !isOK {
+		stats = &eventReasonStats{
+			kind:      event.Kind,
+			eventType: event.Type,
+			reason:    event.Reason,
+			objects:   make(map[string]bool),
+			firstTime: event.LogTime,
+			message:   strings.ReplaceAll(event.Message, ",", ";"),
+		}
+		reasons[key] = stats
+	}
+	stats.count++
+	stats.objects[event.GetObjectKey()] = true
+	stats.lastTime = event.LogTime
+}
+
+// ReplicaSet.SuccessfulCreate
+func getEventTimelineColumn(event *log_util.KubeEvent) string {
+	return event.Kind + "." + event.Reason
+}
+
+// Warning events first, then the most frequent
+func getSortedEventReasons(reasons map[string]*eventReasonStats) []*eventReasonStats {
+	result := make([]*eventReasonStats, 0, len(reasons))
+	for _, stats := range reasons {
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].eventType != result[j].eventType {
+			return result[i].eventType == "Warning"
+		}
+		if result[i].count != result[j].count {
+			return result[i].count > result[j].count
+		}
+		if result[i].kind != result[j].kind {
+			return result[i].kind < result[j].kind
+		}
+		return result[i].reason < result[j].reason
+	})
+	return result
+}
+
+// Event count per interval of each column, from the interval of the first event in the log to that of the last event.
+// Log time has no date, event time earlier than the first event is taken as the next day.
+func getEventTimeline(firstEventTime time.Time, columns []string, eventTimes map[string][]time.Time, interval time.Duration) (time.Time, [][]int) {
+	startTime := firstEventTime.Truncate(interval)
+	timeline := make([][]int, 0)
+	for j, column := range columns {
+		for _, eventTime := range eventTimes[column] {
+			if eventTime.Before(startTime) {
+				eventTime = eventTime.Add(24 * time.Hour)
+			}
+			index := int(eventTime.Sub(startTime) / interval)
+			for len(timeline) <= index {
+				timeline = append(timeline, make([]int, len(columns)))
+			}
+			timeline[index][j]++
+		}
+	}
+	return startTime, timeline
+}
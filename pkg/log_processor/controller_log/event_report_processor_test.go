@@ -0,0 +1,41 @@
+package controller_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"tools/pkg/log_util"
+)
+
+func Test_addEventReason(t *testing.T) {
+	reasons := make(map[string]*eventReasonStats)
+	addEventReason(reasons, &log_util.KubeEvent{LogTime: "10:00:00.000000", Kind: "ReplicaSet", Namespace: "ns", Name: "rs", Type: "Normal", Reason: "SuccessfulCreate", Message: "Created pod: p1"})
+	addEventReason(reasons, &log_util.KubeEvent{LogTime: "10:00:01.000000", Kind: "ReplicaSet", Namespace: "ns", Name: "rs", Type: "Normal", Reason: "SuccessfulCreate", Message: "Created pod: p2"})
+	addEventReason(reasons, &log_util.KubeEvent{LogTime: "10:00:02.000000", Kind: "ReplicaSet", Namespace: "ns", Name: "rs", Type: "Warning", Reason: "FailedCreate", Message: "Error creating: pods \"p3\" is forbidden, exceeded quota"})
+
+	sorted := getSortedEventReasons(reasons)
+	assert.Equal(t, 2, len(sorted))
+	assert.Equal(t, "FailedCreate", sorted[0].reason)
+	assert.Equal(t, "Error creating: pods \"p3\" is forbidden; exceeded quota", sorted[0].message)
+	assert.Equal(t, 2, sorted[1].count)
+	assert.Equal(t, 1, len(sorted[1].objects))
+	assert.Equal(t, "10:00:00.000000", sorted[1].firstTime)
+	assert.Equal(t, "10:00:01.000000", sorted[1].lastTime)
+}
+
+func Test_getEventTimeline(t *testing.T) {
+	parseTime := func(logTime string) time.Time {
+		parsed, err := log_util.ParseLogTime(logTime)
+		assert.Nil(t, err)
+		return parsed
+	}
+	columns := []string{"Pod.FailedScheduling", "ReplicaSet.SuccessfulCreate"}
+	eventTimes := map[string][]time.Time{
+		"Pod.FailedScheduling":        {parseTime("23:59:30.000000"), parseTime("00:01:10.000000")},
+		"ReplicaSet.SuccessfulCreate": {parseTime("23:59:10.000000"), parseTime("23:59:50.000000")},
+	}
+
+	startTime, timeline := getEventTimeline(parseTime("23:59:10.000000"), columns, eventTimes, time.Minute)
+	assert.Equal(t, "23:59:00", startTime.Format("15:04:05"))
+	assert.Equal(t, [][]int{{1, 2}, {0, 0}, {1, 0}}, timeline)
+}
@@ -18,37 +18,49 @@ type podSchedulingTime struct {
 	addingToQueueTime string
 	deQueueTime string
 	startSchedulingTime string
+	computePredicatesTime string
+	prioritizingTime string
+	selectingHostTime string
+	assumeTime string
 	startBindingTime string
 	boundedTime string
-	boundedDuration time.Duration	// startBindingTime -> boundedTime
+	boundedDuration time.Duration	// startBindingTime -> boundedTime, bind phase
 	schedulingDuration time.Duration	// startSchedulingTime -> startBindingTime
 	watchedDuration time.Duration	// createdByRSControllerTime -> receivedBySchedulerTime
 	queuedDuration time.Duration // addingToQueueTime -> deQueueTime
+	filterDuration time.Duration // computePredicatesTime -> prioritizingTime
+	scoreDuration time.Duration // prioritizingTime -> selectingHostTime
+	selectHostDuration time.Duration // selectingHostTime -> assumeTime, or startBindingTime if assume is not logged
+	assumeDuration time.Duration // assumeTime -> startBindingTime
 }
 
-type durationBucket struct {
-	D0_32ms int
-	D32_50ms int
-	D50_64ms int
-	D64_128ms int
-	D128_256ms int
-	D256_512ms int
-	D512_1s int
-	D1_2s int
-	D2_inf int
-}
+// Bucket bounds of scheduling phases in .bucket file
+var schedulingDurationBounds = []time.Duration{32 * time.Millisecond, 50 * time.Millisecond, 64 * time.Millisecond,
+	128 * time.Millisecond, 256 * time.Millisecond, 512 * time.Millisecond, time.Second, 2 * time.Second}
+
+// Label of the last bucket in .bucket file, kept for the gate thresholds of existing runs, e.g. scheduler.bound_duration.2-inf
+const schedulingDurationOverflowLabel = "2-inf"
+
+const podCreateEventMessagePrefix = "Created pod: "
+
+const defaultControllerLogFile = "controller.saturation-deployment.log"
+const defaultSchedulerLogFile = "scheduler.saturation-deployment.log"
 
 func ExtractPodSchedulingTime(pathToFind string) {
-	allPodsSchedulingTimes := extractPodCreateEventLog(pathToFind)
-	extractPodSchedulingTime(allPodsSchedulingTimes, pathToFind)
+	ExtractPodSchedulingTimeFromFiles(pathToFind, defaultControllerLogFile, defaultSchedulerLogFile)
+}
+
+// Controller and scheduler log files are in pathToFind. Outputs are named after the scheduler log file.
+func ExtractPodSchedulingTimeFromFiles(pathToFind, controllerLogFile, schedulerLogFile string) {
+	allPodsSchedulingTimes := extractPodCreateEventLog(path.Join(pathToFind, controllerLogFile))
+	extractPodSchedulingTime(allPodsSchedulingTimes, path.Join(pathToFind, schedulerLogFile))
 }
 
 // Get pod creation event from controller log
 /*
 I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:"ReplicaSet", Namespace:"0pd8pj-testns", Name:"saturation-deployment-0-c47675f5", UID:"60f0ef4c-683d-4f4a-9278-c9cd19021e4d", APIVersion:"apps/v1", ResourceVersion:"9989", FieldPath:"", Tenant:"arktos"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: saturation-deployment-0-c47675f5-scn2w
  */
-func extractPodCreateEventLog(pathToFind string) map[string]*podSchedulingTime {
-	inputFilename := path.Join(pathToFind, "controller.saturation-deployment.log")
+func extractPodCreateEventLog(inputFilename string) map[string]*podSchedulingTime {
 	inputFileHandler, err := os.Open(inputFilename)
 	if err != nil {
 		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
@@ -69,19 +81,16 @@ func extractPodCreateEventLog(pathToFind string) map[string]*podSchedulingTime {
 		if len(line) == 0 {
 			break
 		}
-
-		scheduleTime, err := log_util.GetTimeFromLog(line)
-		if err != nil {
-			fmt.Printf("Error getting time from log [%s]. error [%v]", line, err)
-			panic(err)
+		event, isOK := log_util.ParseEventLine(line)
+		if !isOK || !isPodCreateEvent(event) {
+			continue
 		}
 
 		//get pod name
-		fields := strings.Split(line, " ")
-		podname := fields[len(fields)-1]
+		podname := getPodNameFromCreateEvent(event)
 		allPodsSchedulingTime[podname] = &podSchedulingTime{
 			podName: podname,
-			createdByRSControllerTime: scheduleTime,
+			createdByRSControllerTime: event.LogTime,
 		}
 	}
 
@@ -89,15 +98,19 @@ func extractPodCreateEventLog(pathToFind string) map[string]*podSchedulingTime {
 }
 
 var regexToFindArktosScheduling = []string{
-	"Getting pod ",
-	"adding pod ",
+	"Getting pod |Add event for unscheduled pod",
+	"adding pod |Pod moved to an internal scheduling queue",
 	"About to try and schedule pod",	//dequeue
 	"Attempting to schedule pod",	// start scheduling
-	"Attempting to bind pod:",	//start binding
-	"is bound successfully on node",	//bounded
+	"Attempting to bind pod:|Attempting to bind pod to node",	//start binding
+	"is bound successfully on node|Successfully bound pod to node",	//bounded
+	"DEBUG: Compute predicates pod:",	//start filter
+	"DEBUG: Prioritizing pod:",	//start score
+	"DEBUG: Selecting host pod:",	//start select host
+	"AssumePodVolumes",	//start assume
 }
 
-// Get pod scheduling time frame from customized scheduler log
+// Get pod scheduling time frame from customized scheduler log. Structured log of newer scheduler is also accepted.
 /*
 I0409 22:32:35.827427       1 eventhandlers.go:164] Getting pod saturation-deployment-0-c47675f5-xf258 from API server
 I0409 22:32:35.827433       1 scheduling_queue.go:210] adding pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258 to the scheduling queue.
@@ -108,9 +121,18 @@ I0409 22:32:36.228494       1 generic_scheduler.go:231] DEBUG: Prioritizing pod:
 I0409 22:32:36.245901       1 generic_scheduler.go:255] DEBUG: Selecting host pod: arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258
 I0409 22:32:36.246120       1 scheduler.go:417] Attempting to bind pod: arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258
 I0409 22:32:36.248275       1 scheduler.go:596] pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258 is bound successfully on node hollow-node-1-btv5d, 500 nodes evaluated, 500 nodes were found feasible
+
+Structured log of newer scheduler. Dequeue and the DEBUG phases have no structured form.
+I1012 10:00:00.000000       1 eventhandlers.go:118] "Add event for unscheduled pod" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258"
+I1012 10:00:00.000010       1 scheduling_queue.go:465] "Pod moved to an internal scheduling queue" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258" event="PodAdd" queue="Active"
+I1012 10:00:00.100000       1 schedule_one.go:81] "Attempting to schedule pod" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258"
+I1012 10:00:00.110000       1 binder.go:332] "AssumePodVolumes" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258" node="hollow-node-1-btv5d"
+I1012 10:00:00.120000       1 schedule_one.go:792] "Attempting to bind pod to node" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258" node="hollow-node-1-btv5d"
+I1012 10:00:00.130000       1 schedule_one.go:252] "Successfully bound pod to node" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258" node="hollow-node-1-btv5d" evaluatedNodes=500 feasibleNodes=500
+
+Scheduling is broken down into phases: filter (predicates), score (priorities), select host, assume and bind.
  */
-func extractPodSchedulingTime(allPodsSchedulingTimes map[string]*podSchedulingTime, pathToFind string) {
-	inputFilename := path.Join(pathToFind, "scheduler.saturation-deployment.log")
+func extractPodSchedulingTime(allPodsSchedulingTimes map[string]*podSchedulingTime, inputFilename string) {
 	inputFileHandler, err := os.Open(inputFilename)
 	if err != nil {
 		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
@@ -161,16 +183,28 @@ func extractPodSchedulingTime(allPodsSchedulingTimes map[string]*podSchedulingTi
 				entry.startBindingTime = logTime
 			case 5:
 				entry.boundedTime = logTime
+			case 6:
+				entry.computePredicatesTime = logTime
+			case 7:
+				entry.prioritizingTime = logTime
+			case 8:
+				entry.selectingHostTime = logTime
+			case 9:
+				entry.assumeTime = logTime
 			}
 		} else {
 			fmt.Printf("Not found pod entry in all pods map. line [%s]\n", line)
 		}
 	}
 
-	boundedDurationBucket := &durationBucket{}
-	schedulingDurationBucket := &durationBucket{}
-	watchedDurationBucket := &durationBucket{}
-	queuedDurationBucket := &durationBucket{}
+	boundedDurationBucket := log_util.NewHistogram(schedulingDurationBounds, false)
+	schedulingDurationBucket := log_util.NewHistogram(schedulingDurationBounds, false)
+	watchedDurationBucket := log_util.NewHistogram(schedulingDurationBounds, false)
+	queuedDurationBucket := log_util.NewHistogram(schedulingDurationBounds, false)
+	filterDurationBucket := log_util.NewHistogram(schedulingDurationBounds, false)
+	scoreDurationBucket := log_util.NewHistogram(schedulingDurationBounds, false)
+	selectHostDurationBucket := log_util.NewHistogram(schedulingDurationBounds, false)
+	assumeDurationBucket := log_util.NewHistogram(schedulingDurationBounds, false)
 	podNamesToCheck := ""
 
 	// calculate durations
@@ -215,18 +249,41 @@ func extractPodSchedulingTime(allPodsSchedulingTimes map[string]*podSchedulingTi
 			}
 		}
 
+		// scheduling phases, only added into bucket if both phase times are logged
+		var hasPhase bool
+		sTime.filterDuration, hasPhase = getPhaseDuration(podname, "filter", sTime.computePredicatesTime, sTime.prioritizingTime)
+		if hasPhase {
+			filterDurationBucket.Add(sTime.filterDuration)
+		}
+		sTime.scoreDuration, hasPhase = getPhaseDuration(podname, "score", sTime.prioritizingTime, sTime.selectingHostTime)
+		if hasPhase {
+			scoreDurationBucket.Add(sTime.scoreDuration)
+		}
+		selectHostEndTime := sTime.assumeTime
+		if selectHostEndTime == "" {
+			selectHostEndTime = sTime.startBindingTime
+		}
+		sTime.selectHostDuration, hasPhase = getPhaseDuration(podname, "select host", sTime.selectingHostTime, selectHostEndTime)
+		if hasPhase {
+			selectHostDurationBucket.Add(sTime.selectHostDuration)
+		}
+		sTime.assumeDuration, hasPhase = getPhaseDuration(podname, "assume", sTime.assumeTime, sTime.startBindingTime)
+		if hasPhase {
+			assumeDurationBucket.Add(sTime.assumeDuration)
+		}
+
 		// Add into duration bucket
-		isInfPod_Bound := addDurationIntoBucket(boundedDurationBucket, sTime.boundedDuration)
-		isInfPod_Scheduling := addDurationIntoBucket(schedulingDurationBucket, sTime.schedulingDuration)
-		isInfPod_Watch := addDurationIntoBucket(watchedDurationBucket, sTime.watchedDuration)
-		isInfPod_Queued := addDurationIntoBucket(queuedDurationBucket, sTime.queuedDuration)
+		isInfPod_Bound := boundedDurationBucket.Add(sTime.boundedDuration)
+		isInfPod_Scheduling := schedulingDurationBucket.Add(sTime.schedulingDuration)
+		isInfPod_Watch := watchedDurationBucket.Add(sTime.watchedDuration)
+		isInfPod_Queued := queuedDurationBucket.Add(sTime.queuedDuration)
 		if isInfPod_Bound || isInfPod_Scheduling || isInfPod_Watch || isInfPod_Queued {
 			podNamesToCheck = podNamesToCheck + ", " + podname
 		}
 	}
 
 	// output
-	outputFilename := path.Join(pathToFind, "scheduler.saturation-deployment.log.output")
+	outputFilename := inputFilename + ".output"
 	outputFileHandler, err := os.Create(outputFilename)
 	if err != nil {
 		fmt.Printf("Error open output file [%s]: %v\n", outputFilename, err)
@@ -234,33 +291,44 @@ func extractPodSchedulingTime(allPodsSchedulingTimes map[string]*podSchedulingTi
 	}
 	defer outputFileHandler.Close()
 
-	header := "pod_name, creation_time, received_time, adding_to_queue_time, dequeue_time, start_scheduling_time, start_binding_time, bounded_time, bound_duration, scheduling_duration, watch_duration, queued_duration\n"
+	header := "pod_name, creation_time, received_time, adding_to_queue_time, dequeue_time, start_scheduling_time, start_binding_time, bounded_time, bound_duration, scheduling_duration, watch_duration, queued_duration, " +
+		"compute_predicates_time, prioritizing_time, selecting_host_time, assume_time, filter_duration, score_duration, select_host_duration, assume_duration\n"
 	outputFileHandler.WriteString(header)
 	for podname, sTime := range allPodsSchedulingTimes {
-		line := fmt.Sprintf("%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s\n",
+		line := fmt.Sprintf("%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s\n",
 			podname, sTime.createdByRSControllerTime, sTime.receivedBySchedulerTime, sTime.addingToQueueTime,
 			sTime.deQueueTime, sTime.startSchedulingTime, sTime.startBindingTime, sTime.boundedTime,
-			sTime.boundedDuration, sTime.schedulingDuration, sTime.watchedDuration, sTime.queuedDuration)
+			sTime.boundedDuration, sTime.schedulingDuration, sTime.watchedDuration, sTime.queuedDuration,
+			sTime.computePredicatesTime, sTime.prioritizingTime, sTime.selectingHostTime, sTime.assumeTime,
+			sTime.filterDuration, sTime.scoreDuration, sTime.selectHostDuration, sTime.assumeDuration)
 		outputFileHandler.WriteString(line)
 	}
 
 	// output duration bucket
-	outputBucketFilename := path.Join(pathToFind, "scheduler.saturation-deployment.log.bucket")
+	outputBucketFilename := inputFilename + ".bucket"
 	outputBucketFileHandler, err := os.Create(outputBucketFilename)
 	if err != nil {
 		fmt.Printf("Error open output file [%s]: %v\n", outputBucketFilename, err)
 		return
 	}
 	defer outputBucketFileHandler.Close()
-	header = "case, <=32ms, <=50ms, <=64ms, <=128ms, <=256ms, <=512ms, <=1s, <=2s, 2-inf\n"
+	header = "case, " + getBucketOutputHeader(boundedDurationBucket) + "\n"
 	outputBucketFileHandler.WriteString(header)
-	line := "Bound duration, " + getBucketOutputLine(boundedDurationBucket)
+	line := "Bound duration, " + boundedDurationBucket.GetCsvLine() + "\n"
+	outputBucketFileHandler.WriteString(line)
+	line = "Scheduling duration, " + schedulingDurationBucket.GetCsvLine() + "\n"
+	outputBucketFileHandler.WriteString(line)
+	line = "Watched duration, " + watchedDurationBucket.GetCsvLine() + "\n"
+	outputBucketFileHandler.WriteString(line)
+	line = "Queued duration, " + queuedDurationBucket.GetCsvLine() + "\n"
 	outputBucketFileHandler.WriteString(line)
-	line = "Scheduling duration, " + getBucketOutputLine(schedulingDurationBucket)
+	line = "Filter duration, " + filterDurationBucket.GetCsvLine() + "\n"
 	outputBucketFileHandler.WriteString(line)
-	line = "Watched duration, " + getBucketOutputLine(watchedDurationBucket)
+	line = "Score duration, " + scoreDurationBucket.GetCsvLine() + "\n"
 	outputBucketFileHandler.WriteString(line)
-	line = "Queued duration, " + getBucketOutputLine(queuedDurationBucket)
+	line = "Select host duration, " + selectHostDurationBucket.GetCsvLine() + "\n"
+	outputBucketFileHandler.WriteString(line)
+	line = "Assume duration, " + assumeDurationBucket.GetCsvLine() + "\n"
 	outputBucketFileHandler.WriteString(line)
 
 	// output infinity pod names
@@ -282,69 +350,73 @@ func getMatchCase(line string) (isMatch bool, caseId int, podName string) {
 }
 
 func getPodNameFromArktosSchedulerLog(line string, caseId int) string {
-	fields := strings.Split(line, " ")
-
-	if caseId == 0 {
+	fullPodName := getPodFullNameFromArktosSchedulerLog(line, caseId)
+	if fullPodName == "" && caseId == 0 {
+		// Getting pod <name> from API server
+		fields := strings.Split(line, " ")
 		return fields[len(fields) - 4]
 	}
+	fields := strings.Split(fullPodName, "/")
+	return fields[len(fields)-1]
+}
+
+// <tenant>/<namespace>/<name>, or <namespace>/<name> of structured log. Empty if the line has no full pod name.
+func getPodFullNameFromArktosSchedulerLog(line string, caseId int) string {
+	// structured log, pod="ns/name"
+	if klogLine, isOK := log_util.ParseKlogLine(line); isOK && klogLine.Values["pod"] != "" {
+		return klogLine.Values["pod"]
+	}
+
+	fields := strings.Split(line, " ")
+
 	fullPodName := ""
 	switch caseId {
 	case 1:
 		fullPodName = fields[len(fields) - 5]
-	case 2, 3, 4:
+	case 2, 3, 4, 6, 7, 8:
 		fullPodName = fields[len(fields) - 1]
 	case 5:
 		fullPodName = fields[len(fields) - 15]
+	case 9:
+		// AssumePodVolumes for pod "arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258", node "hollow-node-1-btv5d"
+		fullPodName = strings.Trim(fields[len(fields) - 3], "\",")
 	default:
 		return ""
 	}
-	fields = strings.Split(fullPodName, "/")
-	return fields[2]
+	if len(strings.Split(fullPodName, "/")) != 3 {
+		return ""
+	}
+	return fullPodName
 }
 
-func addDurationIntoBucket(durBucket *durationBucket, duration time.Duration) (isInf bool) {
-	isInf = false
-
-	if duration <= time.Millisecond * 32 {
-		durBucket.D0_32ms++
-		return
-	}
-	if duration <= time.Millisecond * 50 {
-		durBucket.D32_50ms++
-		return
-	}
-	if duration <= time.Millisecond * 64 {
-		durBucket.D50_64ms++
-		return
-	}
-	if duration <= time.Millisecond * 128 {
-		durBucket.D64_128ms++
-		return
-	}
-	if duration <= time.Millisecond * 256 {
-		durBucket.D128_256ms++
-		return
-	}
-	if duration <= time.Millisecond * 512 {
-		durBucket.D256_512ms++
-		return
+// Returns false if either phase time is not logged
+func getPhaseDuration(podname, phase, startTime, endTime string) (time.Duration, bool) {
+	if startTime == "" || endTime == "" {
+		return 0, false
 	}
-	if duration <= time.Second {
-		durBucket.D512_1s++
-		return
-	}
-	if duration <= time.Second * 2 {
-		durBucket.D1_2s++
-		return
+
+	duration, err := log_util.GetTimeDiff(startTime, endTime)
+	if err != nil {
+		fmt.Printf("Error getting %s duration. pod name [%s], start time [%s], end time [%s], error [%v]\n",
+			phase, podname, startTime, endTime, err)
+		return 0, false
 	}
-	durBucket.D2_inf++
-	isInf = true
-	return
+	return duration, true
+}
+
+// <=32ms, <=50ms, <=64ms, <=128ms, <=256ms, <=512ms, <=1s, <=2s, 2-inf
+func getBucketOutputHeader(histogram *log_util.Histogram) string {
+	labels := histogram.GetBucketLabels()
+	labels[len(labels)-1] = schedulingDurationOverflowLabel
+	return strings.Join(labels, ", ")
 }
 
-func getBucketOutputLine(durBucket *durationBucket) string {
-	return fmt.Sprintf("%d, %d, %d, %d, %d, %d, %d, %d, %d\n",
-		durBucket.D0_32ms, durBucket.D32_50ms, durBucket.D50_64ms, durBucket.D64_128ms,
-		durBucket.D128_256ms, durBucket.D256_512ms, durBucket.D512_1s, durBucket.D1_2s,
-		durBucket.D2_inf)
-}
\ No newline at end of file
+// Controller log may have other events when it is not filtered by SuccessfulCreate
+func isPodCreateEvent(event *log_util.KubeEvent) bool {
+	return event.Reason == "SuccessfulCreate" && strings.HasPrefix(event.Message, podCreateEventMessagePrefix)
+}
+
+// Created pod: saturation-deployment-0-c47675f5-scn2w
+func getPodNameFromCreateEvent(event *log_util.KubeEvent) string {
+	return strings.TrimSpace(strings.TrimPrefix(event.Message, podCreateEventMessagePrefix))
+}
@@ -2,7 +2,12 @@ package controller_log
 
 import (
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"strings"
 	"testing"
+	"time"
+	"tools/pkg/log_util"
 )
 
 func Test_getMatchCase(t *testing.T) {
@@ -23,4 +28,106 @@ func Test_getMatchCase(t *testing.T) {
 	assert.True(t, isMatch)
 	assert.Equal(t, 0, caseId)
 	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	inputLine = "I0409 22:32:36.212748       1 generic_scheduler.go:211] DEBUG: Compute predicates pod: arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258"
+	isMatch, caseId, podName = getMatchCase(inputLine)
+	assert.True(t, isMatch)
+	assert.Equal(t, 6, caseId)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	inputLine = "I0409 22:32:36.245901       1 generic_scheduler.go:255] DEBUG: Selecting host pod: arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258"
+	isMatch, caseId, podName = getMatchCase(inputLine)
+	assert.True(t, isMatch)
+	assert.Equal(t, 8, caseId)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	inputLine = "I0409 22:32:36.246001       1 scheduler.go:528] AssumePodVolumes for pod \"arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258\", node \"hollow-node-1-btv5d\""
+	isMatch, caseId, podName = getMatchCase(inputLine)
+	assert.True(t, isMatch)
+	assert.Equal(t, 9, caseId)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	inputLine = "I1012 10:00:00.000000       1 schedule_one.go:81] \"Attempting to schedule pod\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\""
+	isMatch, caseId, podName = getMatchCase(inputLine)
+	assert.True(t, isMatch)
+	assert.Equal(t, 3, caseId)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	inputLine = "I1012 10:00:00.100000       1 schedule_one.go:252] \"Successfully bound pod to node\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\" node=\"n1\" evaluatedNodes=500 feasibleNodes=500"
+	isMatch, caseId, podName = getMatchCase(inputLine)
+	assert.True(t, isMatch)
+	assert.Equal(t, 5, caseId)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	inputLine = "I1012 10:00:00.000000       1 eventhandlers.go:118] \"Add event for unscheduled pod\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\""
+	isMatch, caseId, podName = getMatchCase(inputLine)
+	assert.True(t, isMatch)
+	assert.Equal(t, 0, caseId)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	inputLine = "I1012 10:00:00.000010       1 scheduling_queue.go:465] \"Pod moved to an internal scheduling queue\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\" event=\"PodAdd\" queue=\"Active\""
+	isMatch, caseId, podName = getMatchCase(inputLine)
+	assert.True(t, isMatch)
+	assert.Equal(t, 1, caseId)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	inputLine = "I1012 10:00:00.110000       1 binder.go:332] \"AssumePodVolumes\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\" node=\"hollow-node-1-btv5d\""
+	isMatch, caseId, podName = getMatchCase(inputLine)
+	assert.True(t, isMatch)
+	assert.Equal(t, 9, caseId)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	inputLine = "I1012 10:00:00.120000       1 schedule_one.go:792] \"Attempting to bind pod to node\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\" node=\"hollow-node-1-btv5d\""
+	isMatch, caseId, podName = getMatchCase(inputLine)
+	assert.True(t, isMatch)
+	assert.Equal(t, 4, caseId)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+}
+
+func Test_extractPodCreateEventLog(t *testing.T) {
+	dir, err := os.MkdirTemp("", "kcm")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// grep with file names
+	lines := []string{
+		"kube-controller-manager.log:I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:\"ReplicaSet\", Namespace:\"0pd8pj-testns\", Name:\"saturation-deployment-0-c47675f5\", UID:\"60f0ef4c-683d-4f4a-9278-c9cd19021e4d\", APIVersion:\"apps/v1\", ResourceVersion:\"9989\", FieldPath:\"\", Tenant:\"arktos\"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: saturation-deployment-0-c47675f5-scn2w",
+		"I0409 23:36:27.600000       1 event.go:259] Event(v1.ObjectReference{Kind:\"ReplicaSet\", Namespace:\"0pd8pj-testns\", Name:\"saturation-deployment-0-c47675f5\", UID:\"60f0ef4c-683d-4f4a-9278-c9cd19021e4d\", APIVersion:\"apps/v1\", ResourceVersion:\"9990\", FieldPath:\"\", Tenant:\"arktos\"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: saturation-deployment-0-c47675f5-abcde",
+	}
+	inputFilename := path.Join(dir, defaultControllerLogFile)
+	assert.Nil(t, os.WriteFile(inputFilename, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	podTimes := extractPodCreateEventLog(inputFilename)
+	assert.Equal(t, 2, len(podTimes))
+	assert.Equal(t, "23:36:27.556371", podTimes["saturation-deployment-0-c47675f5-scn2w"].createdByRSControllerTime)
+	assert.Equal(t, "23:36:27.600000", podTimes["saturation-deployment-0-c47675f5-abcde"].createdByRSControllerTime)
+}
+
+func Test_getPodNameFromCreateEvent(t *testing.T) {
+	inputLine := "I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:\"ReplicaSet\", Namespace:\"0pd8pj-testns\", Name:\"saturation-deployment-0-c47675f5\", UID:\"60f0ef4c-683d-4f4a-9278-c9cd19021e4d\", APIVersion:\"apps/v1\", ResourceVersion:\"9989\", FieldPath:\"\", Tenant:\"arktos\"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: saturation-deployment-0-c47675f5-scn2w"
+	event, isOK := log_util.ParseEventLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-scn2w", getPodNameFromCreateEvent(event))
+
+	inputLine = "I1012 10:00:00.000000       1 event.go:294] \"Event occurred\" object=\"0pd8pj-testns/saturation-deployment-0-c47675f5\" kind=\"ReplicaSet\" apiVersion=\"apps/v1\" type=\"Normal\" reason=\"SuccessfulCreate\" message=\"Created pod: saturation-deployment-0-c47675f5-scn2w\""
+	event, isOK = log_util.ParseEventLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-scn2w", getPodNameFromCreateEvent(event))
+}
+
+func Test_getPhaseDuration(t *testing.T) {
+	duration, hasPhase := getPhaseDuration("saturation-deployment-0-c47675f5-xf258", "filter", "22:32:36.212748", "22:32:36.228494")
+	assert.True(t, hasPhase)
+	assert.Equal(t, 15746*time.Microsecond, duration)
+
+	_, hasPhase = getPhaseDuration("saturation-deployment-0-c47675f5-xf258", "assume", "", "22:32:36.246120")
+	assert.False(t, hasPhase)
+}
+
+func Test_getBucketOutputHeader(t *testing.T) {
+	histogram := log_util.NewHistogram(schedulingDurationBounds, false)
+	assert.True(t, histogram.Add(3*time.Second))
+	assert.False(t, histogram.Add(0))
+	assert.Equal(t, "<=32ms, <=50ms, <=64ms, <=128ms, <=256ms, <=512ms, <=1s, <=2s, 2-inf", getBucketOutputHeader(histogram))
+	assert.Equal(t, "1, 0, 0, 0, 0, 0, 0, 0, 1", histogram.GetCsvLine())
 }
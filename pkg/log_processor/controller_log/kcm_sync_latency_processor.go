@@ -0,0 +1,384 @@
+package controller_log
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"tools/pkg/log_util"
+)
+
+type kcmSyncEventType string
+
+const (
+	kcmSyncFinished kcmSyncEventType = "finished"
+	kcmSyncError    kcmSyncEventType = "error"   // requeued with rate limit
+	kcmSyncDropped  kcmSyncEventType = "dropped" // dropped out of the queue after max retries
+)
+
+// Slowest objects by max sync duration in .sync.slowest file
+const slowestSyncObjectCount = 100
+
+// Sync of an object by a controller of kube-controller-manager
+type kcmSyncEvent struct {
+	logTime    string
+	eventType  kcmSyncEventType
+	controller string // lower case kind without spaces, e.g. replicaset
+	key        string // namespace/name, or tenant/namespace/name of Arktos
+	duration   time.Duration
+	err        string
+}
+
+type kcmSyncObjectStats struct {
+	controller string
+	key        string
+	syncs      int
+	total      time.Duration
+	max        time.Duration
+	retries    int
+	dropped    int
+	lastError  string
+}
+
+type kcmControllerStats struct {
+	controller string
+	latency    *log_util.QuantileSketch
+	objects    int
+	retries    int
+	dropped    int
+	errors     map[string]int // error template -> count
+}
+
+// replica_set.go:658] Finished syncing ReplicaSet "ns/name" (1.23ms)
+// deployment_controller.go:583] Finished syncing deployment "ns/name" (2.6ms)
+// endpoints_controller.go:385] Finished syncing service "ns/name" endpoints. (1.2ms)
+var regexKcmSyncFinished = regexp.MustCompile(`Finished syncing ([A-Za-z][A-Za-z ]*?) "([^"]+)"([^(]*)\(([^)]+)\)`)
+
+// deployment_controller.go:490] Error syncing deployment ns/name: Operation cannot be fulfilled on deployments.apps "name": the object has been modified
+// endpoints_controller.go:340] Error syncing endpoints for service "ns/name", retrying. Error: endpoints "name" is forbidden
+var regexKcmSyncError = regexp.MustCompile(`Error syncing ([A-Za-z][A-Za-z ]*?)(?: for service)? "?([\w.-]+(?:/[\w.-]+)+)"?(?:, retrying\.)?[:,]? (?:Error: )?(.*)`)
+
+// deployment_controller.go:498] Dropping deployment "ns/name" out of the queue: Operation cannot be fulfilled ...
+var regexKcmSyncDropped = regexp.MustCompile(`Dropping ([A-Za-z][A-Za-z ]*?) "?([\w.-]+(?:/[\w.-]+)+)"? out of the queue: (.*)`)
+
+// replica_set.go:532] sync "ns/name" failed with pods "name" is forbidden: exceeded quota
+var regexKcmSyncFailed = regexp.MustCompile(`sync "([^"]+)" failed with (.*)`)
+
+// Controller of sync failure lines by source file
+var kcmSourceControllers = map[string]string{
+	"replica_set.go":    "replicaset",
+	"daemon_controller": "daemonset",
+	"job_controller.go": "job",
+	"stateful_set.go":   "statefulset",
+}
+
+// Keys of the synced object in structured logs, e.g. "Finished syncing" kind="ReplicaSet" key="ns/name" duration="1.23ms"
+var kcmStructuredKeyNames = []string{"key", "replicaSet", "deployment", "service", "daemonset", "job", "statefulSet",
+	"namespace", "node", "pod", "object"}
+
+// Normalize names, numbers and quoted values in sync errors
+var kcmSyncErrorReplacements = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`"[^"]*"`), `"<name>"`},
+	{regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`), "<uid>"},
+	{regexp.MustCompile(`\b\d+(\.\d+)?(ns|µs|us|ms|s|m|h)?\b`), "<n>"},
+}
+
+// Sync latency, retries and errors per controller and per object in kube-controller-manager log.
+// Outputs are named after the input file:
+// .sync.controller: latency percentiles, retries and dropped objects per controller
+// .sync.slowest: objects with the largest max sync duration
+// .sync.errors: sync errors per controller, counted by error template
+func ExtractControllerSyncLatency(pathToFind string, inputFile string) {
+	inputFilename := path.Join(pathToFind, inputFile)
+	controllerFilename := inputFilename + ".sync.controller"
+	slowestFilename := inputFilename + ".sync.slowest"
+	errorsFilename := inputFilename + ".sync.errors"
+
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	controllers := make(map[string]*kcmControllerStats)
+	objects := make(map[string]*kcmSyncObjectStats)
+	lineCount := 0
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		lineCount++
+		event, isOK := parseKcmSyncLine(line)
+		if !isOK {
+			continue
+		}
+		addKcmSyncEvent(controllers, objects, event)
+	}
+
+	controllerFileHandler, err := os.Create(controllerFilename)
+	if err != nil {
+		fmt.Printf("Error create controller sync file [%s]: %v\n", controllerFilename, err)
+		panic(err)
+	}
+	defer controllerFileHandler.Close()
+
+	slowestFileHandler, err := os.Create(slowestFilename)
+	if err != nil {
+		fmt.Printf("Error create slowest sync file [%s]: %v\n", slowestFilename, err)
+		panic(err)
+	}
+	defer slowestFileHandler.Close()
+
+	errorsFileHandler, err := os.Create(errorsFilename)
+	if err != nil {
+		fmt.Printf("Error create sync errors file [%s]: %v\n", errorsFilename, err)
+		panic(err)
+	}
+	defer errorsFileHandler.Close()
+
+	controllerNames := make([]string, 0, len(controllers))
+	for controller := range controllers {
+		controllerNames = append(controllerNames, controller)
+	}
+	sort.Strings(controllerNames)
+
+	controllerFileHandler.WriteString("controller, syncs, p50_ms, p90_ms, p99_ms, max_ms, total_ms, objects, retries, dropped\n")
+	errorsFileHandler.WriteString("controller, count, error\n")
+	for _, controller := range controllerNames {
+		stats := controllers[controller]
+		controllerFileHandler.WriteString(fmt.Sprintf("%s, %s, %.3f, %d, %d, %d\n", controller,
+			log_util.GetSketchStatsOutput(stats.latency), log_util.GetDurationInMilliSecond(stats.latency.Sum()),
+			stats.objects, stats.retries, stats.dropped))
+
+		for _, syncError := range getSortedKcmSyncErrors(stats.errors) {
+			errorsFileHandler.WriteString(fmt.Sprintf("%s, %d, %s\n", controller, stats.errors[syncError], syncError))
+		}
+	}
+
+	slowestFileHandler.WriteString("controller, key, syncs, max_ms, total_ms, retries, dropped, last_error\n")
+	for _, object := range getSlowestKcmSyncObjects(objects, slowestSyncObjectCount) {
+		slowestFileHandler.WriteString(fmt.Sprintf("%s, %s, %d, %.3f, %.3f, %d, %d, %s\n", object.controller, object.key,
+			object.syncs, log_util.GetDurationInMilliSecond(object.max), log_util.GetDurationInMilliSecond(object.total),
+			object.retries, object.dropped, object.lastError))
+	}
+	fmt.Printf("Total line %d, controllers %d, objects %d\n", lineCount, len(controllers), len(objects))
+}
+
+// Returns the sync event in the line in legacy or structured format, or false if the line is not a sync event
+func parseKcmSyncLine(line string) (*kcmSyncEvent, bool) {
+	klogLine, isOK := log_util.ParseKlogLine(line)
+	if !isOK {
+		return nil, false
+	}
+
+	var event *kcmSyncEvent
+	if len(klogLine.Values) > 0 {
+		event, isOK = parseStructuredKcmSyncEvent(klogLine)
+	} else {
+		event, isOK = parseLegacyKcmSyncEvent(klogLine)
+	}
+	if !isOK {
+		return nil, false
+	}
+	event.logTime = klogLine.Time
+	event.err = strings.ReplaceAll(strings.TrimSpace(event.err), ",", ";")
+	return event, true
+}
+
+func parseLegacyKcmSyncEvent(klogLine *log_util.KlogLine) (*kcmSyncEvent, bool) {
+	message := klogLine.Message
+	if matches := regexKcmSyncFinished.FindStringSubmatch(message); matches != nil {
+		duration, err := time.ParseDuration(matches[4])
+		if err != nil {
+			return nil, false
+		}
+		controller := matches[1]
+		if strings.Contains(matches[3], "endpoints") {
+			controller = "endpoints"
+		}
+		return &kcmSyncEvent{eventType: kcmSyncFinished, controller: getKcmControllerName(controller), key: matches[2],
+			duration: duration}, true
+	}
+	if matches := regexKcmSyncDropped.FindStringSubmatch(message); matches != nil {
+		return &kcmSyncEvent{eventType: kcmSyncDropped, controller: getKcmControllerName(matches[1]), key: matches[2],
+			err: matches[3]}, true
+	}
+	if matches := regexKcmSyncError.FindStringSubmatch(message); matches != nil {
+		return &kcmSyncEvent{eventType: kcmSyncError, controller: getKcmControllerName(matches[1]), key: matches[2],
+			err: matches[3]}, true
+	}
+	if matches := regexKcmSyncFailed.FindStringSubmatch(message); matches != nil {
+		return &kcmSyncEvent{eventType: kcmSyncError, controller: getKcmControllerFromSource(klogLine.Source),
+			key: matches[1], err: matches[2]}, true
+	}
+	return nil, false
+}
+
+// "Finished syncing" kind="ReplicaSet" key="ns/name" duration="1.23ms"
+// "Finished syncing service endpoints" service="ns/name" startTime="1.2ms"
+// "Error syncing deployment" deployment="ns/name" err="..."
+// "Dropping deployment out of the queue" deployment="ns/name" err="..."
+func parseStructuredKcmSyncEvent(klogLine *log_util.KlogLine) (*kcmSyncEvent, bool) {
+	values := klogLine.Values
+	key := getKcmStructuredKey(values)
+	if key == "" {
+		return nil, false
+	}
+
+	message := klogLine.Message
+	switch {
+	case strings.HasPrefix(message, "Finished syncing"):
+		duration, err := time.ParseDuration(values["duration"])
+		if err != nil {
+			// endpoints controller logs the time since start as startTime
+			duration, err = time.ParseDuration(values["startTime"])
+		}
+		if err != nil {
+			return nil, false
+		}
+		controller := values["kind"]
+		if controller == "" {
+			controller = strings.TrimSpace(strings.TrimPrefix(message, "Finished syncing"))
+		}
+		if strings.HasSuffix(controller, "endpoints") {
+			controller = "endpoints"
+		}
+		return &kcmSyncEvent{eventType: kcmSyncFinished, controller: getKcmControllerName(controller), key: key,
+			duration: duration}, true
+	case strings.HasPrefix(message, "Dropping ") && strings.HasSuffix(message, " out of the queue"):
+		controller := strings.TrimSuffix(strings.TrimPrefix(message, "Dropping "), " out of the queue")
+		return &kcmSyncEvent{eventType: kcmSyncDropped, controller: getKcmControllerName(controller), key: key,
+			err: values["err"]}, true
+	case strings.HasPrefix(message, "Error syncing "):
+		controller := strings.TrimPrefix(message, "Error syncing ")
+		if strings.HasPrefix(controller, "endpoints") {
+			controller = "endpoints"
+		}
+		return &kcmSyncEvent{eventType: kcmSyncError, controller: getKcmControllerName(controller), key: key,
+			err: values["err"]}, true
+	}
+	return nil, false
+}
+
+func getKcmStructuredKey(values map[string]string) string {
+	for _, name := range kcmStructuredKeyNames {
+		if values[name] != "" {
+			return values[name]
+		}
+	}
+	return ""
+}
+
+// "replica set", "ReplicaSet" -> replicaset
+func getKcmControllerName(kind string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(kind), " ", ""))
+}
+
+// replica_set.go:532 -> replicaset, source file name otherwise
+func getKcmControllerFromSource(source string) string {
+	for file, controller := range kcmSourceControllers {
+		if strings.HasPrefix(source, file) {
+			return controller
+		}
+	}
+	if index := strings.Index(source, ".go"); index != -1 {
+		return source[:index]
+	}
+	return source
+}
+
+func addKcmSyncEvent(controllers map[string]*kcmControllerStats, objects map[string]*kcmSyncObjectStats, event *kcmSyncEvent) {
+	stats, isOK := controllers[event.controller]
+	if !isOK {
+		stats = &kcmControllerStats{
+			controller: event.controller,
+			latency:    log_util.NewDefaultQuantileSketch(),
+			errors:     make(map[string]int),
+		}
+		controllers[event.controller] = stats
+	}
+	objectKey := event.controller + " " + event.key
+	object, isOK := objects[objectKey]
+	if !isOK {
+		object = &kcmSyncObjectStats{controller: event.controller, key: event.key}
+		objects[objectKey] = object
+		stats.objects++
+	}
+
+	switch event.eventType {
+	case kcmSyncFinished:
+		stats.latency.Add(event.duration)
+		object.syncs++
+		object.total += event.duration
+		if event.duration > object.max {
+			object.max = event.duration
+		}
+	case kcmSyncError:
+		stats.retries++
+		stats.errors[getKcmSyncErrorTemplate(event.err)]++
+		object.retries++
+		object.lastError = event.err
+	case kcmSyncDropped:
+		stats.dropped++
+		object.dropped++
+		object.lastError = event.err
+	}
+}
+
+func getKcmSyncErrorTemplate(syncError string) string {
+	for _, replacement := range kcmSyncErrorReplacements {
+		syncError = replacement.regex.ReplaceAllString(syncError, replacement.replacement)
+	}
+	return syncError
+}
+
+// Most frequent errors first
+func getSortedKcmSyncErrors(errors map[string]int) []string {
+	result := make([]string, 0, len(errors))
+	for syncError := range errors {
+		result = append(result, syncError)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if errors[result[i]] != errors[result[j]] {
+			return errors[result[i]] > errors[result[j]]
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// Objects with the largest max sync duration, then the most retries
+func getSlowestKcmSyncObjects(objects map[string]*kcmSyncObjectStats, count int) []*kcmSyncObjectStats {
+	result := make([]*kcmSyncObjectStats, 0, len(objects))
+	for _, object := range objects {
+		result = append(result, object)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].max != result[j].max {
+			return result[i].max > result[j].max
+		}
+		if result[i].retries != result[j].retries {
+			return result[i].retries > result[j].retries
+		}
+		if result[i].controller != result[j].controller {
+			return result[i].controller < result[j].controller
+		}
+		return result[i].key < result[j].key
+	})
+	if len(result) > count {
+		result = result[:count]
+	}
+	return result
+}
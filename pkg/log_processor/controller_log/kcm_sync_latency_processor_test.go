@@ -0,0 +1,96 @@
+package controller_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_parseKcmSyncLine(t *testing.T) {
+	event, isOK := parseKcmSyncLine("I0409 23:36:27.556371       1 replica_set.go:658] Finished syncing ReplicaSet \"arktos/0pd8pj-testns/saturation-deployment-0-c47675f5\" (1.23ms)")
+	assert.True(t, isOK)
+	assert.Equal(t, &kcmSyncEvent{logTime: "23:36:27.556371", eventType: kcmSyncFinished, controller: "replicaset",
+		key: "arktos/0pd8pj-testns/saturation-deployment-0-c47675f5", duration: 1230 * time.Microsecond}, event)
+
+	event, isOK = parseKcmSyncLine("I0409 23:36:27.556371       1 replica_set.go:658] Finished syncing replica set \"ns/rs\" (2ms)")
+	assert.True(t, isOK)
+	assert.Equal(t, "replicaset", event.controller)
+
+	event, isOK = parseKcmSyncLine("I0409 23:36:27.600000       1 endpoints_controller.go:385] Finished syncing service \"ns/svc\" endpoints. (1.2ms)")
+	assert.True(t, isOK)
+	assert.Equal(t, "endpoints", event.controller)
+	assert.Equal(t, "ns/svc", event.key)
+
+	event, isOK = parseKcmSyncLine("I0409 23:36:28.000000       1 deployment_controller.go:490] Error syncing deployment ns/d: Operation cannot be fulfilled on deployments.apps \"d\": the object has been modified, please apply your changes")
+	assert.True(t, isOK)
+	assert.Equal(t, kcmSyncError, event.eventType)
+	assert.Equal(t, "deployment", event.controller)
+	assert.Equal(t, "ns/d", event.key)
+	assert.Equal(t, "Operation cannot be fulfilled on deployments.apps \"d\": the object has been modified; please apply your changes", event.err)
+
+	event, isOK = parseKcmSyncLine("I0409 23:36:28.000000       1 endpoints_controller.go:340] Error syncing endpoints for service \"ns/svc\", retrying. Error: endpoints \"svc\" is forbidden")
+	assert.True(t, isOK)
+	assert.Equal(t, "endpoints", event.controller)
+	assert.Equal(t, "ns/svc", event.key)
+	assert.Equal(t, "endpoints \"svc\" is forbidden", event.err)
+
+	event, isOK = parseKcmSyncLine("I0409 23:36:29.000000       1 deployment_controller.go:498] Dropping deployment \"ns/d\" out of the queue: the server is unavailable")
+	assert.True(t, isOK)
+	assert.Equal(t, kcmSyncDropped, event.eventType)
+	assert.Equal(t, "ns/d", event.key)
+
+	event, isOK = parseKcmSyncLine("E0409 23:36:30.000000       1 replica_set.go:532] sync \"ns/rs\" failed with pods \"p\" is forbidden: exceeded quota")
+	assert.True(t, isOK)
+	assert.Equal(t, kcmSyncError, event.eventType)
+	assert.Equal(t, "replicaset", event.controller)
+	assert.Equal(t, "pods \"p\" is forbidden: exceeded quota", event.err)
+
+	_, isOK = parseKcmSyncLine("I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:\"ReplicaSet\"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: p")
+	assert.False(t, isOK)
+}
+
+func Test_parseKcmSyncLine_structured(t *testing.T) {
+	event, isOK := parseKcmSyncLine("I1012 10:00:00.000000       1 replica_set.go:667] \"Finished syncing\" kind=\"ReplicaSet\" key=\"ns/rs\" duration=\"1.23ms\"")
+	assert.True(t, isOK)
+	assert.Equal(t, &kcmSyncEvent{logTime: "10:00:00.000000", eventType: kcmSyncFinished, controller: "replicaset",
+		key: "ns/rs", duration: 1230 * time.Microsecond}, event)
+
+	event, isOK = parseKcmSyncLine("I1012 10:00:00.000000       1 endpoints_controller.go:370] \"Finished syncing service endpoints\" service=\"ns/svc\" startTime=\"1.5ms\"")
+	assert.True(t, isOK)
+	assert.Equal(t, "endpoints", event.controller)
+	assert.Equal(t, 1500*time.Microsecond, event.duration)
+
+	event, isOK = parseKcmSyncLine("I1012 10:00:01.000000       1 deployment_controller.go:494] \"Error syncing deployment\" deployment=\"ns/d\" err=\"the server is unavailable\"")
+	assert.True(t, isOK)
+	assert.Equal(t, kcmSyncError, event.eventType)
+	assert.Equal(t, "deployment", event.controller)
+	assert.Equal(t, "the server is unavailable", event.err)
+
+	event, isOK = parseKcmSyncLine("I1012 10:00:02.000000       1 deployment_controller.go:502] \"Dropping deployment out of the queue\" deployment=\"ns/d\" err=\"the server is unavailable\"")
+	assert.True(t, isOK)
+	assert.Equal(t, kcmSyncDropped, event.eventType)
+	assert.Equal(t, "deployment", event.controller)
+}
+
+func Test_addKcmSyncEvent(t *testing.T) {
+	controllers := make(map[string]*kcmControllerStats)
+	objects := make(map[string]*kcmSyncObjectStats)
+	addKcmSyncEvent(controllers, objects, &kcmSyncEvent{eventType: kcmSyncFinished, controller: "deployment", key: "ns/d1", duration: time.Second})
+	addKcmSyncEvent(controllers, objects, &kcmSyncEvent{eventType: kcmSyncError, controller: "deployment", key: "ns/d1", err: "deployments.apps \"d1\" not found"})
+	addKcmSyncEvent(controllers, objects, &kcmSyncEvent{eventType: kcmSyncError, controller: "deployment", key: "ns/d2", err: "deployments.apps \"d2\" not found"})
+	addKcmSyncEvent(controllers, objects, &kcmSyncEvent{eventType: kcmSyncFinished, controller: "deployment", key: "ns/d2", duration: time.Millisecond})
+	addKcmSyncEvent(controllers, objects, &kcmSyncEvent{eventType: kcmSyncDropped, controller: "deployment", key: "ns/d2", err: "gone"})
+
+	stats := controllers["deployment"]
+	assert.Equal(t, 2, stats.latency.Count())
+	assert.Equal(t, 2, stats.objects)
+	assert.Equal(t, 2, stats.retries)
+	assert.Equal(t, 1, stats.dropped)
+	assert.Equal(t, map[string]int{"deployments.apps \"<name>\" not found": 2}, stats.errors)
+
+	slowest := getSlowestKcmSyncObjects(objects, 1)
+	assert.Equal(t, 1, len(slowest))
+	assert.Equal(t, "ns/d1", slowest[0].key)
+	assert.Equal(t, 1, slowest[0].retries)
+	assert.Equal(t, "gone", objects["deployment ns/d2"].lastError)
+}
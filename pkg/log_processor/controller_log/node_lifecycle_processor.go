@@ -0,0 +1,409 @@
+package controller_log
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"tools/pkg/log_util"
+)
+
+type nodeLifecycleEventType string
+
+const (
+	nodeRegistered   nodeLifecycleEventType = "registered"
+	nodeReady        nodeLifecycleEventType = "ready"
+	nodeNotReady     nodeLifecycleEventType = "not_ready"
+	nodeUnresponsive nodeLifecycleEventType = "unresponsive" // status not updated by kubelet, condition Unknown
+	nodeEvicting     nodeLifecycleEventType = "evicting"
+	nodeTaintAdded   nodeLifecycleEventType = "taint_added"
+	nodeTaintRemoved nodeLifecycleEventType = "taint_removed"
+	nodeRemoved      nodeLifecycleEventType = "removed"
+	// Cluster events have no node
+	clusterDisruption nodeLifecycleEventType = "disruption" // master disruption mode, evictions are stopped
+	clusterNormal     nodeLifecycleEventType = "normal"
+)
+
+// Node states in .node.timeline file
+const (
+	nodeStateRegistered = "Registered"
+	nodeStateReady      = "Ready"
+	nodeStateNotReady   = "NotReady"
+	nodeStateUnknown    = "Unknown"
+	nodeStateRemoved    = "Removed"
+)
+
+type nodeLifecycleEvent struct {
+	logTime   string
+	node      string
+	eventType nodeLifecycleEventType
+	detail    string
+}
+
+type nodeLifecycleStats struct {
+	node           string
+	state          string
+	unhealthyCount int // transitions into NotReady or Unknown
+	unhealthySince time.Time
+	unhealthyTotal time.Duration
+	firstUnhealthy string
+	lastReady      string
+	evictions      int
+	taintsAdded    int
+	taintsRemoved  int
+}
+
+type nodeLifecycleInterval struct {
+	unhealthy    int // at the end of the interval
+	maxUnhealthy int
+	notReady     int // transitions into NotReady or Unknown in the interval
+	ready        int
+	evictions    int
+	removed      int
+	disruption   bool // cluster is in disruption mode at the end of the interval
+}
+
+type nodeLifecycleMatcher struct {
+	regex     *regexp.Regexp
+	eventType nodeLifecycleEventType
+}
+
+// Legacy messages of node lifecycle controller. Node name is in the node group, taints in the detail group.
+var nodeLifecycleMatchers = []nodeLifecycleMatcher{
+	// Node hollow-node-1 is NotReady as of 2021-04-09 23:36:27.556371 +0000 UTC. Adding it to the Taint queue.
+	{regexp.MustCompile(`Node (?P<node>\S+) is NotReady as of`), nodeNotReady},
+	// Node hollow-node-1 is unresponsive as of 2021-04-09 23:36:27.556371 +0000 UTC. Adding it to the Taint queue.
+	{regexp.MustCompile(`Node (?P<node>\S+) is unresponsive as of`), nodeUnresponsive},
+	// node hollow-node-1 hasn't been updated for 40.0s. Last Ready is: &NodeCondition{...}
+	{regexp.MustCompile(`node (?P<node>\S+) hasn't been updated for`), nodeUnresponsive},
+	// Node hollow-node-1 is healthy again, removing all taints
+	{regexp.MustCompile(`Node (?P<node>\S+) is healthy again, removing all taints`), nodeReady},
+	// Evicting pods on node hollow-node-1: 2021-04-09 23:36:27 is later than 2021-04-09 23:35:47 + 40s
+	{regexp.MustCompile(`Evicting pods on node (?P<node>[^\s:]+)`), nodeEvicting},
+	// Added taint [node.kubernetes.io/unreachable:NoExecute] to node hollow-node-1
+	{regexp.MustCompile(`(?i)\badd(?:ed|ing)? taints? \[?(?P<detail>[^\]]*?)\]? (?:to|on) node "?(?P<node>[\w.-]+)"?`), nodeTaintAdded},
+	// Removed taint [node.kubernetes.io/unreachable:NoExecute] from node hollow-node-1
+	{regexp.MustCompile(`(?i)\bremov(?:ed|ing)? taints? \[?(?P<detail>[^\]]*?)\]? (?:from|on) node "?(?P<node>[\w.-]+)"?`), nodeTaintRemoved},
+	// Controller detected that some Nodes are Ready. Exiting master disruption mode.
+	{regexp.MustCompile(`Controller detected that some Nodes are Ready\. Exiting master disruption mode`), clusterNormal},
+	// Controller detected that some Nodes are Not Ready. Entering master disruption mode.
+	// Controller detected that all Nodes are not-Ready. Entering master disruption mode.
+	{regexp.MustCompile(`Controller detected that (?:some|all) Nodes are (?i:not[- ]ready)`), clusterDisruption},
+}
+
+// Structured messages of node lifecycle controller, node name is in node value
+var nodeLifecycleStructuredMessages = map[string]nodeLifecycleEventType{
+	"Node is NotReady. Adding it to the Taint queue":     nodeNotReady,
+	"Node is unresponsive. Adding it to the Taint queue": nodeUnresponsive,
+	"Node hasn't been updated":                           nodeUnresponsive,
+	"Node is healthy again, removing all taints":         nodeReady,
+	"Evicting pods on node":                              nodeEvicting,
+}
+
+// Recording status change NodeNotReady event message for node hollow-node-1
+var regexNodeStatusChange = regexp.MustCompile(`Recording status change (\w+) event message for node (\S+)`)
+
+// Node events and node status changes
+var nodeStatusEventTypes = map[string]nodeLifecycleEventType{
+	"RegisteredNode": nodeRegistered,
+	"NodeReady":      nodeReady,
+	"NodeNotReady":   nodeNotReady,
+	"RemovingNode":   nodeRemoved,
+	"DeletingNode":   nodeRemoved,
+}
+
+// Node state timeline and unhealthy nodes over time from node lifecycle controller messages and node events in
+// kube-controller-manager log. Outputs are named after the input file:
+// .node.timeline: node lifecycle events in log order, with the state of the node after each event
+// .node.summary: unhealthy transitions, time not ready, evictions and taints per node, unhealthy nodes first
+// .node.unhealthy: unhealthy nodes, transitions and evictions per interval. Interval is one minute if not set.
+func ExtractNodeLifecycle(pathToFind string, inputFile string, interval time.Duration) {
+	inputFilename := path.Join(pathToFind, inputFile)
+	timelineFilename := inputFilename + ".node.timeline"
+	summaryFilename := inputFilename + ".node.summary"
+	unhealthyFilename := inputFilename + ".node.unhealthy"
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	timelineFileHandler, err := os.Create(timelineFilename)
+	if err != nil {
+		fmt.Printf("Error create node timeline file [%s]: %v\n", timelineFilename, err)
+		panic(err)
+	}
+	defer timelineFileHandler.Close()
+
+	summaryFileHandler, err := os.Create(summaryFilename)
+	if err != nil {
+		fmt.Printf("Error create node summary file [%s]: %v\n", summaryFilename, err)
+		panic(err)
+	}
+	defer summaryFileHandler.Close()
+
+	unhealthyFileHandler, err := os.Create(unhealthyFilename)
+	if err != nil {
+		fmt.Printf("Error create unhealthy node file [%s]: %v\n", unhealthyFilename, err)
+		panic(err)
+	}
+	defer unhealthyFileHandler.Close()
+
+	nodes := make(map[string]*nodeLifecycleStats)
+	intervals := make([]*nodeLifecycleInterval, 0)
+	startTime := time.Time{}
+	lastTime := time.Time{}
+	unhealthy := 0
+	disruption := false
+	lineCount := 0
+	eventCount := 0
+
+	timelineFileHandler.WriteString("time, node, event, state, detail\n")
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		lineCount++
+		event, isOK := parseNodeLifecycleLine(line)
+		if !isOK {
+			continue
+		}
+		eventTime, err := log_util.ParseLogTime(event.logTime)
+		if err != nil {
+			fmt.Printf("Error parsing node event time [%s]: %v\n", event.logTime, err)
+			continue
+		}
+		if startTime.IsZero() {
+			startTime = eventTime.Truncate(interval)
+		}
+		// Log time has no date, time earlier than the first event is taken as the next day
+		if eventTime.Before(startTime) {
+			eventTime = eventTime.Add(24 * time.Hour)
+		}
+		lastTime = eventTime
+		eventCount++
+
+		index := int(eventTime.Sub(startTime) / interval)
+		for len(intervals) <= index {
+			intervals = append(intervals, &nodeLifecycleInterval{unhealthy: unhealthy, maxUnhealthy: unhealthy, disruption: disruption})
+		}
+		current := intervals[index]
+
+		state := ""
+		switch event.eventType {
+		case clusterDisruption:
+			disruption = true
+		case clusterNormal:
+			disruption = false
+		default:
+			stats := getNodeLifecycleStats(nodes, event.node)
+			wasUnhealthy := isNodeUnhealthy(stats.state)
+			addNodeLifecycleEvent(stats, event, eventTime)
+			state = stats.state
+			if !wasUnhealthy && isNodeUnhealthy(state) {
+				unhealthy++
+				current.notReady++
+			} else if wasUnhealthy && !isNodeUnhealthy(state) {
+				unhealthy--
+				if state == nodeStateReady {
+					current.ready++
+				}
+			}
+			if event.eventType == nodeEvicting {
+				current.evictions++
+			}
+			if event.eventType == nodeRemoved {
+				current.removed++
+			}
+		}
+		current.unhealthy = unhealthy
+		if unhealthy > current.maxUnhealthy {
+			current.maxUnhealthy = unhealthy
+		}
+		current.disruption = disruption
+
+		timelineFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %s, %s\n", event.logTime, event.node,
+			event.eventType, state, event.detail))
+	}
+
+	summaryFileHandler.WriteString("node, state, unhealthy_count, first_unhealthy, last_ready, unhealthy_ms, evictions, taints_added, taints_removed\n")
+	for _, stats := range getSortedNodeLifecycleStats(nodes) {
+		unhealthyTotal := stats.unhealthyTotal
+		// still unhealthy at the end of the log
+		if isNodeUnhealthy(stats.state) {
+			unhealthyTotal += lastTime.Sub(stats.unhealthySince)
+		}
+		summaryFileHandler.WriteString(fmt.Sprintf("%s, %s, %d, %s, %s, %.3f, %d, %d, %d\n", stats.node, stats.state,
+			stats.unhealthyCount, stats.firstUnhealthy, stats.lastReady, log_util.GetDurationInMilliSecond(unhealthyTotal),
+			stats.evictions, stats.taintsAdded, stats.taintsRemoved))
+	}
+
+	unhealthyFileHandler.WriteString("time, unhealthy, max_unhealthy, not_ready, ready, evictions, removed, disruption\n")
+	for i, current := range intervals {
+		timeOfInterval := startTime.Add(time.Duration(i) * interval).Format("15:04:05")
+		unhealthyFileHandler.WriteString(fmt.Sprintf("%s, %d, %d, %d, %d, %d, %d, %t\n", timeOfInterval, current.unhealthy,
+			current.maxUnhealthy, current.notReady, current.ready, current.evictions, current.removed, current.disruption))
+	}
+	fmt.Printf("Total line %d, node events %d, nodes %d, unhealthy at the end %d\n", lineCount, eventCount, len(nodes), unhealthy)
+}
+
+// Returns node lifecycle event of the line in legacy or structured format, including node events of event.go
+func parseNodeLifecycleLine(line string) (*nodeLifecycleEvent, bool) {
+	if event, isOK := log_util.ParseEventLine(line); isOK {
+		eventType, isNodeStatus := nodeStatusEventTypes[event.Reason]
+		if event.Kind != "Node" || !isNodeStatus {
+			return nil, false
+		}
+		return &nodeLifecycleEvent{logTime: event.LogTime, node: event.Name, eventType: eventType, detail: "event"}, true
+	}
+
+	klogLine, isOK := log_util.ParseKlogLine(line)
+	if !isOK {
+		return nil, false
+	}
+	var event *nodeLifecycleEvent
+	if len(klogLine.Values) > 0 {
+		event, isOK = parseStructuredNodeLifecycleEvent(klogLine)
+	} else {
+		event, isOK = parseLegacyNodeLifecycleEvent(klogLine.Message)
+	}
+	if !isOK {
+		return nil, false
+	}
+	event.logTime = klogLine.Time
+	return event, true
+}
+
+func parseLegacyNodeLifecycleEvent(message string) (*nodeLifecycleEvent, bool) {
+	if matches := regexNodeStatusChange.FindStringSubmatch(message); matches != nil {
+		eventType, isOK := nodeStatusEventTypes[matches[1]]
+		if !isOK {
+			return nil, false
+		}
+		return &nodeLifecycleEvent{node: matches[2], eventType: eventType, detail: "status change"}, true
+	}
+
+	for _, matcher := range nodeLifecycleMatchers {
+		matches := matcher.regex.FindStringSubmatch(message)
+		if matches == nil {
+			continue
+		}
+		event := &nodeLifecycleEvent{eventType: matcher.eventType}
+		for i, name := range matcher.regex.SubexpNames() {
+			switch name {
+			case "node":
+				event.node = matches[i]
+			case "detail":
+				event.detail = strings.ReplaceAll(matches[i], ",", ";")
+			}
+		}
+		return event, true
+	}
+	return nil, false
+}
+
+// "Node is NotReady. Adding it to the Taint queue" node="hollow-node-1" timeStamp="2021-10-12 10:00:00 +0000 UTC"
+// "Recording status change event message for node" status="NodeNotReady" node="hollow-node-1"
+// "Controller detected that some Nodes are Not Ready. Entering master disruption mode"
+func parseStructuredNodeLifecycleEvent(klogLine *log_util.KlogLine) (*nodeLifecycleEvent, bool) {
+	message := klogLine.Message
+	node := klogLine.Values["node"]
+	if message == "Recording status change event message for node" {
+		eventType, isOK := nodeStatusEventTypes[klogLine.Values["status"]]
+		if !isOK || node == "" {
+			return nil, false
+		}
+		return &nodeLifecycleEvent{node: node, eventType: eventType, detail: "status change"}, true
+	}
+
+	if eventType, isOK := nodeLifecycleStructuredMessages[message]; isOK && node != "" {
+		return &nodeLifecycleEvent{node: node, eventType: eventType}, true
+	}
+
+	// Cluster messages are the same as legacy ones
+	if event, isOK := parseLegacyNodeLifecycleEvent(message); isOK && event.node == "" {
+		return event, true
+	}
+	return nil, false
+}
+
+func getNodeLifecycleStats(nodes map[string]*nodeLifecycleStats, node string) *nodeLifecycleStats {
+	stats, isOK := nodes[node]
+	if !isOK {
+		stats = &nodeLifecycleStats{node: node}
+		nodes[node] = stats
+	}
+	return stats
+}
+
+// Update node state by the event. Evictions and taints do not change the state.
+func addNodeLifecycleEvent(stats *nodeLifecycleStats, event *nodeLifecycleEvent, eventTime time.Time) {
+	wasUnhealthy := isNodeUnhealthy(stats.state)
+	switch event.eventType {
+	case nodeRegistered:
+		if stats.state == "" || stats.state == nodeStateRemoved {
+			stats.state = nodeStateRegistered
+		}
+	case nodeReady:
+		stats.state = nodeStateReady
+		stats.lastReady = event.logTime
+	case nodeNotReady:
+		stats.state = nodeStateNotReady
+	case nodeUnresponsive:
+		stats.state = nodeStateUnknown
+	case nodeRemoved:
+		stats.state = nodeStateRemoved
+	case nodeEvicting:
+		stats.evictions++
+	case nodeTaintAdded:
+		stats.taintsAdded++
+	case nodeTaintRemoved:
+		stats.taintsRemoved++
+	}
+
+	isUnhealthy := isNodeUnhealthy(stats.state)
+	if !wasUnhealthy && isUnhealthy {
+		stats.unhealthyCount++
+		stats.unhealthySince = eventTime
+		if stats.firstUnhealthy == "" {
+			stats.firstUnhealthy = event.logTime
+		}
+	} else if wasUnhealthy && !isUnhealthy {
+		stats.unhealthyTotal += eventTime.Sub(stats.unhealthySince)
+	}
+}
+
+func isNodeUnhealthy(state string) bool {
+	return state == nodeStateNotReady || state == nodeStateUnknown
+}
+
+// Nodes unhealthy the most times first, then by name
+func getSortedNodeLifecycleStats(nodes map[string]*nodeLifecycleStats) []*nodeLifecycleStats {
+	result := make([]*nodeLifecycleStats, 0, len(nodes))
+	for _, stats := range nodes {
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].unhealthyCount != result[j].unhealthyCount {
+			return result[i].unhealthyCount > result[j].unhealthyCount
+		}
+		if result[i].evictions != result[j].evictions {
+			return result[i].evictions > result[j].evictions
+		}
+		return result[i].node < result[j].node
+	})
+	return result
+}
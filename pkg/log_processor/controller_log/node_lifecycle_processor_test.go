@@ -0,0 +1,95 @@
+package controller_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_parseNodeLifecycleLine(t *testing.T) {
+	testCases := []struct {
+		line      string
+		node      string
+		eventType nodeLifecycleEventType
+		detail    string
+	}{
+		{"I0409 23:36:27.556371       1 node_lifecycle_controller.go:1021] Node hollow-node-1 is NotReady as of 2021-04-09 23:36:27.556371 +0000 UTC. Adding it to the Taint queue.", "hollow-node-1", nodeNotReady, ""},
+		{"I0409 23:36:27.556371       1 node_lifecycle_controller.go:1033] Node hollow-node-1 is unresponsive as of 2021-04-09 23:36:27.556371 +0000 UTC. Adding it to the Taint queue.", "hollow-node-1", nodeUnresponsive, ""},
+		{"I0409 23:36:27.556371       1 node_lifecycle_controller.go:1175] node hollow-node-1 hasn't been updated for 40.000271s. Last Ready is: &NodeCondition{Type:Ready,Status:True}", "hollow-node-1", nodeUnresponsive, ""},
+		{"I0409 23:36:27.556371       1 node_lifecycle_controller.go:1045] Node hollow-node-1 is healthy again, removing all taints", "hollow-node-1", nodeReady, ""},
+		{"I0409 23:36:27.556371       1 node_lifecycle_controller.go:727] Evicting pods on node hollow-node-1: 2021-04-09 23:36:27 is later than 2021-04-09 23:35:47 + 40s", "hollow-node-1", nodeEvicting, ""},
+		{"I0409 23:36:27.556371       1 controller_utils.go:161] Added taint [node.kubernetes.io/unreachable:NoExecute, node.kubernetes.io/not-ready:NoSchedule] to node hollow-node-1", "hollow-node-1", nodeTaintAdded, "node.kubernetes.io/unreachable:NoExecute; node.kubernetes.io/not-ready:NoSchedule"},
+		{"I0409 23:36:27.556371       1 controller_utils.go:173] Removed taint node.kubernetes.io/unreachable:NoExecute from node hollow-node-1", "hollow-node-1", nodeTaintRemoved, "node.kubernetes.io/unreachable:NoExecute"},
+		{"I0409 23:36:27.556371       1 controller_utils.go:181] Recording status change NodeNotReady event message for node hollow-node-1", "hollow-node-1", nodeNotReady, "status change"},
+		{"I0409 23:36:27.556371       1 node_lifecycle_controller.go:1250] Controller detected that some Nodes are Not Ready. Entering master disruption mode.", "", clusterDisruption, ""},
+		{"I0409 23:36:27.556371       1 node_lifecycle_controller.go:1250] Controller detected that all Nodes are not-Ready. Entering master disruption mode.", "", clusterDisruption, ""},
+		{"I0409 23:36:27.556371       1 node_lifecycle_controller.go:1259] Controller detected that some Nodes are Ready. Exiting master disruption mode.", "", clusterNormal, ""},
+		{"I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:\"Node\", Namespace:\"\", Name:\"hollow-node-1\", UID:\"u\", APIVersion:\"\", ResourceVersion:\"\", FieldPath:\"\"}): type: 'Normal' reason: 'RegisteredNode' Node hollow-node-1 event: Registered Node hollow-node-1 in Controller", "hollow-node-1", nodeRegistered, "event"},
+		{"I1012 10:00:00.000000       1 node_lifecycle_controller.go:1100] \"Node is NotReady. Adding it to the Taint queue\" node=\"hollow-node-1\" timeStamp=\"2021-10-12 10:00:00 +0000 UTC\"", "hollow-node-1", nodeNotReady, ""},
+		{"I1012 10:00:00.000000       1 node_lifecycle_controller.go:1100] \"Evicting pods on node\" node=\"hollow-node-1\" lastReady=\"2021-10-12 09:59:00 +0000 UTC\"", "hollow-node-1", nodeEvicting, ""},
+		{"I1012 10:00:00.000000       1 controller_utils.go:121] \"Recording status change event message for node\" status=\"NodeReady\" node=\"hollow-node-1\"", "hollow-node-1", nodeReady, "status change"},
+		{"I1012 10:00:00.000000       1 node_lifecycle_controller.go:1250] \"Controller detected that some Nodes are Not Ready. Entering master disruption mode\"", "", clusterDisruption, ""},
+	}
+	for _, testCase := range testCases {
+		event, isOK := parseNodeLifecycleLine(testCase.line)
+		assert.True(t, isOK, testCase.line)
+		if !isOK {
+			continue
+		}
+		assert.Equal(t, testCase.node, event.node, testCase.line)
+		assert.Equal(t, testCase.eventType, event.eventType, testCase.line)
+		assert.Equal(t, testCase.detail, event.detail, testCase.line)
+	}
+
+	_, isOK := parseNodeLifecycleLine("I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:\"ReplicaSet\", Namespace:\"ns\", Name:\"rs\"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: p")
+	assert.False(t, isOK)
+}
+
+func Test_addNodeLifecycleEvent(t *testing.T) {
+	stats := &nodeLifecycleStats{node: "n1"}
+	startTime := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+	addNodeLifecycleEvent(stats, &nodeLifecycleEvent{logTime: "10:00:00.000000", eventType: nodeRegistered}, startTime)
+	assert.Equal(t, nodeStateRegistered, stats.state)
+	addNodeLifecycleEvent(stats, &nodeLifecycleEvent{logTime: "10:00:01.000000", eventType: nodeUnresponsive}, startTime.Add(time.Second))
+	addNodeLifecycleEvent(stats, &nodeLifecycleEvent{logTime: "10:00:02.000000", eventType: nodeNotReady}, startTime.Add(2*time.Second))
+	addNodeLifecycleEvent(stats, &nodeLifecycleEvent{logTime: "10:00:03.000000", eventType: nodeEvicting}, startTime.Add(3*time.Second))
+	addNodeLifecycleEvent(stats, &nodeLifecycleEvent{logTime: "10:00:11.000000", eventType: nodeReady}, startTime.Add(11*time.Second))
+
+	assert.Equal(t, nodeStateReady, stats.state)
+	assert.Equal(t, 1, stats.unhealthyCount)
+	assert.Equal(t, "10:00:01.000000", stats.firstUnhealthy)
+	assert.Equal(t, "10:00:11.000000", stats.lastReady)
+	assert.Equal(t, 10*time.Second, stats.unhealthyTotal)
+	assert.Equal(t, 1, stats.evictions)
+}
+
+func Test_ExtractNodeLifecycle(t *testing.T) {
+	dir, err := os.MkdirTemp("", "node")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	lines := []string{
+		"I0409 23:59:10.000000       1 node_lifecycle_controller.go:1021] Node n1 is NotReady as of 2021-04-09 23:59:10 +0000 UTC. Adding it to the Taint queue.",
+		"I0409 23:59:20.000000       1 node_lifecycle_controller.go:1021] Node n2 is NotReady as of 2021-04-09 23:59:20 +0000 UTC. Adding it to the Taint queue.",
+		"I0409 23:59:30.000000       1 node_lifecycle_controller.go:1250] Controller detected that some Nodes are Not Ready. Entering master disruption mode.",
+		"I0410 00:01:10.000000       1 node_lifecycle_controller.go:1045] Node n1 is healthy again, removing all taints",
+	}
+	assert.Nil(t, os.WriteFile(path.Join(dir, "kcm.log"), []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	ExtractNodeLifecycle(dir, "kcm.log", time.Minute)
+
+	unhealthy, err := os.ReadFile(path.Join(dir, "kcm.log.node.unhealthy"))
+	assert.Nil(t, err)
+	assert.Equal(t, "time, unhealthy, max_unhealthy, not_ready, ready, evictions, removed, disruption\n"+
+		"23:59:00, 2, 2, 2, 0, 0, 0, true\n"+
+		"00:00:00, 2, 2, 0, 0, 0, 0, true\n"+
+		"00:01:00, 1, 2, 0, 1, 0, 0, true\n", string(unhealthy))
+
+	summary, err := os.ReadFile(path.Join(dir, "kcm.log.node.summary"))
+	assert.Nil(t, err)
+	assert.Equal(t, "node, state, unhealthy_count, first_unhealthy, last_ready, unhealthy_ms, evictions, taints_added, taints_removed\n"+
+		"n1, Ready, 1, 23:59:10.000000, 00:01:10.000000, 120000.000, 0, 0, 0\n"+
+		"n2, NotReady, 1, 23:59:20.000000, , 110000.000, 0, 0, 0\n", string(summary))
+}
@@ -0,0 +1,315 @@
+package controller_log
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"tools/pkg/log_processor/kubelet_log"
+	"tools/pkg/log_util"
+)
+
+// Log files of the components in the path, relative to the path
+type PodStartupLogFiles struct {
+	ControllerLogFile string
+	SchedulerLogFile  string
+	KubeletLogFile    string // kubelet or hollow-node logs, can be concatenated from all nodes
+}
+
+const defaultKubeletLogFile = "kubelet.saturation-deployment.log"
+
+// Pod startup time frame, in log time of day
+type podStartupTime struct {
+	podName            string // namespace/name
+	createdTime        string // SuccessfulCreate event of ReplicaSet controller
+	boundTime          string // bound by scheduler
+	kubeletAddTime     string // SyncLoop (ADD) of kubelet
+	containerStartTime string // first ContainerStarted of PLEG
+	runningTime        string // Running status updated by kubelet
+}
+
+// Same phases as PodStartupLatency of perf-tests. Watch time of the test client is not logged by components,
+// Running status update of kubelet is taken as the time the pod can be watched as running.
+type podStartupPhase struct {
+	name      string
+	startTime func(pod *podStartupTime) string
+	endTime   func(pod *podStartupTime) string
+}
+
+var podStartupPhases = []podStartupPhase{
+	{"create_to_schedule", func(pod *podStartupTime) string { return pod.createdTime }, func(pod *podStartupTime) string { return pod.boundTime }},
+	{"schedule_to_run", func(pod *podStartupTime) string { return pod.boundTime }, func(pod *podStartupTime) string { return pod.containerStartTime }},
+	{"run_to_watch", func(pod *podStartupTime) string { return pod.containerStartTime }, func(pod *podStartupTime) string { return pod.runningTime }},
+	{"schedule_to_kubelet", func(pod *podStartupTime) string { return pod.boundTime }, func(pod *podStartupTime) string { return pod.kubeletAddTime }},
+	{"e2e", func(pod *podStartupTime) string { return pod.createdTime }, func(pod *podStartupTime) string { return pod.runningTime }},
+}
+
+func DefaultPodStartupLogFiles() *PodStartupLogFiles {
+	return &PodStartupLogFiles{
+		ControllerLogFile: defaultControllerLogFile,
+		SchedulerLogFile:  defaultSchedulerLogFile,
+		KubeletLogFile:    defaultKubeletLogFile,
+	}
+}
+
+// End to end pod startup latency, joined by namespace and name of pod from controller, scheduler and kubelet logs.
+// Log time has no date, time earlier than the first pod creation in controller log is taken as the next day.
+func ExtractPodStartupLatency(pathToFind string, logFiles *PodStartupLogFiles) {
+	outputFilename := path.Join(pathToFind, "pod.startup.latency.output")
+	summaryFilename := path.Join(pathToFind, "pod.startup.latency.summary")
+
+	pods := make(map[string]*podStartupTime)
+	firstCreatedTime := readPodCreatedTimes(path.Join(pathToFind, logFiles.ControllerLogFile), pods)
+	readPodBoundTimes(path.Join(pathToFind, logFiles.SchedulerLogFile), pods)
+	readKubeletPodTimes(path.Join(pathToFind, logFiles.KubeletLogFile), pods)
+
+	outputFileHandler, err := os.Create(outputFilename)
+	if err != nil {
+		fmt.Printf("Error create output file [%s]: %v\n", outputFilename, err)
+		panic(err)
+	}
+	defer outputFileHandler.Close()
+
+	summaryFileHandler, err := os.Create(summaryFilename)
+	if err != nil {
+		fmt.Printf("Error create summary file [%s]: %v\n", summaryFilename, err)
+		panic(err)
+	}
+	defer summaryFileHandler.Close()
+
+	podNames := make([]string, 0, len(pods))
+	for podName := range pods {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+
+	header := "pod_name, created_time, bound_time, kubelet_add_time, container_start_time, running_time"
+	for _, phase := range podStartupPhases {
+		header += ", " + phase.name
+	}
+	outputFileHandler.WriteString(header + "\n")
+
+	phaseDurations := make([][]time.Duration, len(podStartupPhases))
+	completedPods := 0
+	for _, podName := range podNames {
+		pod := pods[podName]
+		line := fmt.Sprintf("%s, %s, %s, %s, %s, %s", pod.podName, pod.createdTime, pod.boundTime, pod.kubeletAddTime,
+			pod.containerStartTime, pod.runningTime)
+		for i, phase := range podStartupPhases {
+			duration, hasPhase := getPodStartupPhaseDuration(podName, phase.name, phase.startTime(pod), phase.endTime(pod), firstCreatedTime)
+			if !hasPhase {
+				line += ", "
+				continue
+			}
+			phaseDurations[i] = append(phaseDurations[i], duration)
+			line += fmt.Sprintf(", %.3f", log_util.GetDurationInMilliSecond(duration))
+		}
+		outputFileHandler.WriteString(line + "\n")
+		if pod.createdTime != "" && pod.runningTime != "" {
+			completedPods++
+		}
+	}
+
+	summaryFileHandler.WriteString("phase, count, p50_ms, p90_ms, p99_ms, max_ms\n")
+	for i, phase := range podStartupPhases {
+		summaryFileHandler.WriteString(fmt.Sprintf("%s, %s\n", phase.name, getPodStartupLatencyOutput(phaseDurations[i])))
+	}
+	fmt.Printf("Total pods %d, running pods %d\n", len(pods), completedPods)
+}
+
+// Pods created by ReplicaSet controller, returns the time of the first creation in log order
+func readPodCreatedTimes(inputFilename string, pods map[string]*podStartupTime) time.Time {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	firstCreatedTime := time.Time{}
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		event, isOK := log_util.ParseEventLine(strings.TrimSpace(line))
+		if !isOK || !isPodCreateEvent(event) {
+			continue
+		}
+		// pod is in the namespace of its ReplicaSet
+		podName := event.Namespace + "/" + getPodNameFromCreateEvent(event)
+		if _, isOK := pods[podName]; isOK {
+			continue
+		}
+		pods[podName] = &podStartupTime{
+			podName:     podName,
+			createdTime: event.LogTime,
+		}
+		if firstCreatedTime.IsZero() {
+			if createdTime, err := log_util.ParseLogTime(event.LogTime); err == nil {
+				firstCreatedTime = createdTime
+			}
+		}
+	}
+	return firstCreatedTime
+}
+
+// First bound time of the pods in scheduler log
+func readPodBoundTimes(inputFilename string, pods map[string]*podStartupTime) {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		isMatch, caseId, _ := getMatchCase(strings.TrimSpace(line))
+		// is bound successfully on node
+		if !isMatch || caseId != 5 {
+			continue
+		}
+		podName := getNamespacedPodName(getPodFullNameFromArktosSchedulerLog(strings.TrimSpace(line), caseId))
+		pod, isOK := pods[podName]
+		if !isOK || pod.boundTime != "" {
+			continue
+		}
+		pod.boundTime, err = log_util.GetTimeFromLog(line)
+		if err != nil {
+			fmt.Printf("Error getting time from log [%s]. error [%v]\n", line, err)
+		}
+	}
+}
+
+// First SyncLoop ADD, ContainerStarted and Running status of the pods in kubelet log
+func readKubeletPodTimes(inputFilename string, pods map[string]*podStartupTime) {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		podNames, event := getKubeletPodEvent(strings.TrimSpace(line))
+		if event == "" {
+			continue
+		}
+		logTime, err := log_util.GetTimeFromLog(line)
+		if err != nil {
+			fmt.Printf("Error getting time from log [%s]. error [%v]\n", line, err)
+			continue
+		}
+		for _, podName := range podNames {
+			pod, isOK := pods[podName]
+			if !isOK {
+				continue
+			}
+			switch event {
+			case "add":
+				if pod.kubeletAddTime == "" {
+					pod.kubeletAddTime = logTime
+				}
+			case "container_started":
+				if pod.containerStartTime == "" {
+					pod.containerStartTime = logTime
+				}
+			case "running":
+				if pod.runningTime == "" {
+					pod.runningTime = logTime
+				}
+			}
+		}
+	}
+}
+
+// Returns the pod namespace/names and event: add, container_started or running. Event is empty if the line is not a
+// pod event.
+func getKubeletPodEvent(line string) ([]string, string) {
+	kubeletEvent, isOK := kubelet_log.ParseKubeletLine(line)
+	if !isOK {
+		return nil, ""
+	}
+
+	event := ""
+	switch kubeletEvent.EventType {
+	case kubelet_log.KubeletSyncLoopAdd:
+		event = "add"
+	case kubelet_log.KubeletContainerStarted:
+		event = "container_started"
+	case kubelet_log.KubeletPodRunning:
+		event = "running"
+	default:
+		return nil, ""
+	}
+	podNames := make([]string, 0, len(kubeletEvent.Pods))
+	for _, pod := range kubeletEvent.Pods {
+		podNames = append(podNames, getNamespacedPodName(pod))
+	}
+	return podNames, event
+}
+
+// <tenant>/<namespace>/<name> -> <namespace>/<name>
+func getNamespacedPodName(fullPodName string) string {
+	fields := strings.Split(fullPodName, "/")
+	if len(fields) <= 2 {
+		return fullPodName
+	}
+	return strings.Join(fields[len(fields)-2:], "/")
+}
+
+// Returns false if either phase time is not logged. Time earlier than the first pod creation is taken as the next day.
+func getPodStartupPhaseDuration(podName, phase, startTime, endTime string, firstCreatedTime time.Time) (time.Duration, bool) {
+	if startTime == "" || endTime == "" {
+		return 0, false
+	}
+
+	phaseTimes := make([]time.Time, 2)
+	for i, logTime := range []string{startTime, endTime} {
+		parsedTime, err := log_util.ParseLogTime(logTime)
+		if err != nil {
+			fmt.Printf("Error getting %s duration. pod name [%s], start time [%s], end time [%s], error [%v]\n",
+				phase, podName, startTime, endTime, err)
+			return 0, false
+		}
+		if parsedTime.Before(firstCreatedTime) {
+			parsedTime = parsedTime.Add(24 * time.Hour)
+		}
+		phaseTimes[i] = parsedTime
+	}
+	return phaseTimes[1].Sub(phaseTimes[0]), true
+}
+
+// count, p50_ms, p90_ms, p99_ms, max_ms
+func getPodStartupLatencyOutput(durations []time.Duration) string {
+	if len(durations) == 0 {
+		return "0, , , , "
+	}
+
+	log_util.SortDurations(durations)
+	return fmt.Sprintf("%d, %.3f, %.3f, %.3f, %.3f", len(durations),
+		log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(durations, 50)),
+		log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(durations, 90)),
+		log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(durations, 99)),
+		log_util.GetDurationInMilliSecond(durations[len(durations)-1]))
+}
@@ -0,0 +1,102 @@
+package controller_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+	"tools/pkg/log_util"
+)
+
+func Test_getKubeletPodEvent(t *testing.T) {
+	podNames, event := getKubeletPodEvent("I0409 22:32:36.300000       1 kubelet.go:1908] SyncLoop (ADD, \"api\"): \"saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11), saturation-deployment-0-c47675f5-scn2w_1ea47i-testns(6ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)\"")
+	assert.Equal(t, "add", event)
+	assert.Equal(t, []string{"1ea47i-testns/saturation-deployment-0-c47675f5-xf258", "1ea47i-testns/saturation-deployment-0-c47675f5-scn2w"}, podNames)
+
+	podNames, event = getKubeletPodEvent("I0409 22:32:37.100000       1 kubelet.go:1946] SyncLoop (PLEG): \"saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)\", event: &pleg.PodLifecycleEvent{ID:\"5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11\", Type:\"ContainerStarted\", Data:\"0d5f4c1b\"}")
+	assert.Equal(t, "container_started", event)
+	assert.Equal(t, []string{"1ea47i-testns/saturation-deployment-0-c47675f5-xf258"}, podNames)
+
+	podNames, event = getKubeletPodEvent("I0409 22:32:37.200000       1 status_manager.go:575] Status for pod \"saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)\" updated successfully: (2, {Phase:Running Conditions:[]})")
+	assert.Equal(t, "running", event)
+	assert.Equal(t, []string{"1ea47i-testns/saturation-deployment-0-c47675f5-xf258"}, podNames)
+
+	_, event = getKubeletPodEvent("I0409 22:32:37.000000       1 status_manager.go:575] Status for pod \"saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)\" updated successfully: (1, {Phase:Pending Conditions:[]})")
+	assert.Equal(t, "", event)
+}
+
+func Test_podStartupPhases(t *testing.T) {
+	pod := &podStartupTime{
+		createdTime:        "22:32:35.800000",
+		boundTime:          "22:32:36.248275",
+		kubeletAddTime:     "22:32:36.300000",
+		containerStartTime: "22:32:37.100000",
+		runningTime:        "22:32:37.200000",
+	}
+	expected := []time.Duration{
+		448275 * time.Microsecond,
+		851725 * time.Microsecond,
+		100 * time.Millisecond,
+		51725 * time.Microsecond,
+		1400 * time.Millisecond,
+	}
+	firstCreatedTime, err := log_util.ParseLogTime(pod.createdTime)
+	assert.Nil(t, err)
+	for i, phase := range podStartupPhases {
+		duration, hasPhase := getPodStartupPhaseDuration("p", phase.name, phase.startTime(pod), phase.endTime(pod), firstCreatedTime)
+		assert.True(t, hasPhase)
+		assert.Equal(t, expected[i], duration, phase.name)
+	}
+
+	pod.runningTime = ""
+	_, hasPhase := getPodStartupPhaseDuration("p", podStartupPhases[2].name, podStartupPhases[2].startTime(pod), podStartupPhases[2].endTime(pod), firstCreatedTime)
+	assert.False(t, hasPhase)
+
+	// across midnight, bound is after the first pod creation at 23:59:59
+	firstCreatedTime, err = log_util.ParseLogTime("23:59:59.000000")
+	assert.Nil(t, err)
+	duration, hasPhase := getPodStartupPhaseDuration("p", "create_to_schedule", "23:59:59.900000", "00:00:00.100000", firstCreatedTime)
+	assert.True(t, hasPhase)
+	assert.Equal(t, 200*time.Millisecond, duration)
+	// kubelet clock slightly behind scheduler is not taken as the next day
+	duration, hasPhase = getPodStartupPhaseDuration("p", "schedule_to_kubelet", "00:00:00.100000", "00:00:00.099000", firstCreatedTime)
+	assert.True(t, hasPhase)
+	assert.Equal(t, -time.Millisecond, duration)
+}
+
+func Test_ExtractPodStartupLatency(t *testing.T) {
+	dir, err := os.MkdirTemp("", "startup")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeLines := func(filename string, lines ...string) {
+		assert.Nil(t, os.WriteFile(path.Join(dir, filename), []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	}
+	// same pod name in two namespaces
+	writeLines("kcm.log",
+		"I0409 23:59:59.000000       1 event.go:259] Event(v1.ObjectReference{Kind:\"ReplicaSet\", Namespace:\"ns-1\", Name:\"rs\"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: p1",
+		"I0409 23:59:59.500000       1 event.go:259] Event(v1.ObjectReference{Kind:\"ReplicaSet\", Namespace:\"ns-2\", Name:\"rs\"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: p1")
+	writeLines("scheduler.log",
+		"I0410 00:00:00.000000       1 scheduler.go:596] pod arktos/ns-2/p1 is bound successfully on node n1, 500 nodes evaluated, 500 nodes were found feasible",
+		"I0410 00:00:00.500000       1 scheduler.go:596] pod arktos/ns-1/p1 is bound successfully on node n1, 500 nodes evaluated, 500 nodes were found feasible")
+	writeLines("kubelet.log",
+		"I0410 00:00:01.000000       1 kubelet.go:1908] SyncLoop (ADD, \"api\"): \"p1_ns-1(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)\"")
+	ExtractPodStartupLatency(dir, &PodStartupLogFiles{ControllerLogFile: "kcm.log", SchedulerLogFile: "scheduler.log", KubeletLogFile: "kubelet.log"})
+
+	output, err := os.ReadFile(path.Join(dir, "pod.startup.latency.output"))
+	assert.Nil(t, err)
+	assert.Equal(t, "pod_name, created_time, bound_time, kubelet_add_time, container_start_time, running_time, create_to_schedule, schedule_to_run, run_to_watch, schedule_to_kubelet, e2e\n"+
+		"ns-1/p1, 23:59:59.000000, 00:00:00.500000, 00:00:01.000000, , , 1500.000, , , 500.000, \n"+
+		"ns-2/p1, 23:59:59.500000, 00:00:00.000000, , , , 500.000, , , , \n", string(output))
+}
+
+func Test_isPodCreateEvent(t *testing.T) {
+	event, isOK := log_util.ParseEventLine("I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:\"ReplicaSet\", Namespace:\"0pd8pj-testns\", Name:\"saturation-deployment-0-c47675f5\"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: saturation-deployment-0-c47675f5-scn2w")
+	assert.True(t, isOK)
+	assert.True(t, isPodCreateEvent(event))
+	event, isOK = log_util.ParseEventLine("I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:\"Deployment\", Namespace:\"0pd8pj-testns\", Name:\"saturation-deployment-0\"}): type: 'Normal' reason: 'ScalingReplicaSet' Scaled up replica set saturation-deployment-0-c47675f5 to 10")
+	assert.True(t, isOK)
+	assert.False(t, isPodCreateEvent(event))
+}
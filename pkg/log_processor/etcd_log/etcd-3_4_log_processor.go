@@ -290,8 +290,19 @@ func NoReadOnlyRangeRequest_Parser(inputFileName, outputFileName, nonMatchingFil
 }
 
 func NoReadOnlyRangeRequest(line string) (bool, string) {
+	req, hasError := parseNoReadOnlyRangeRequest(line)
+	if !hasError {
+		outputLine := fmt.Sprintf("%s, %s, %s, %s, %s, %s, %s, %s\n", req.key, req.method, req.mod_revision,
+			req.success_method, req.success_value_size, req.failure_method, req.size, req.durationInMicroSec)
+		return false, outputLine
+	} else {
+		return true, ""
+	}
+}
+
+func parseNoReadOnlyRangeRequest(line string) (*NoRangeRequest, bool) {
 	fields := strings.Split(line, " ")
-	req := NoRangeRequest{
+	req := &NoRangeRequest{
 	}
 
 	hasError := false
@@ -522,13 +533,7 @@ func NoReadOnlyRangeRequest(line string) (bool, string) {
 		}
 	}
 
-	if !hasError {
-		outputLine := fmt.Sprintf("%s, %s, %s, %s, %s, %s, %s, %s\n", req.key, req.method, req.mod_revision,
-			req.success_method, req.success_value_size, req.failure_method, req.size, req.durationInMicroSec)
-		return false, outputLine
-	} else {
-		return true, ""
-	}
+	return req, hasError
 }
 
 func ExtractEtcdRangeLog(pathToFind string) {
@@ -0,0 +1,204 @@
+package etcd_log
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type etcdOperationType string
+
+const (
+	etcdOpLeaderElected    etcdOperationType = "leader_elected"
+	etcdOpLeaderLost       etcdOperationType = "leader_lost"
+	etcdOpElectionStarted  etcdOperationType = "election_started"
+	etcdOpCompaction       etcdOperationType = "compaction"
+	etcdOpSlowFsync        etcdOperationType = "slow_fsync"
+	etcdOpSnapshotSaved    etcdOperationType = "snapshot_saved"
+	etcdOpSlowApply        etcdOperationType = "slow_apply"
+	etcdOpHeartbeatDelayed etcdOperationType = "heartbeat_delayed"
+	etcdOpServerOverloaded etcdOperationType = "server_overloaded"
+)
+
+const etcdLogTimeLayout = "2006-01-02 15:04:05.000000"
+
+type EtcdOperationEvent struct {
+	logTime  time.Time
+	source   string
+	level    string
+	opType   etcdOperationType
+	duration time.Duration
+	detail   string
+}
+
+type etcdOperationMatcher struct {
+	opType     etcdOperationType
+	pattern    string
+	durationRe *regexp.Regexp // optional, first sub match is the duration
+}
+
+// first matching pattern wins
+var etcdOperationMatchers = []etcdOperationMatcher{
+	// raft: raft.node: 8e9e05c52164694d elected leader 8e9e05c52164694d at term 2
+	// "8e9e05c52164694d became leader at term 2" of the same election is not counted again
+	{etcdOpLeaderElected, "elected leader", nil},
+	// raft: raft.node: 8e9e05c52164694d lost leader 8e9e05c52164694d at term 3
+	{etcdOpLeaderLost, "lost leader", nil},
+	// raft: 8e9e05c52164694d is starting a new election at term 2
+	{etcdOpElectionStarted, "is starting a new election", nil},
+	// mvcc: finished scheduled compaction at 1000 (took 2.337296ms)
+	{etcdOpCompaction, "finished scheduled compaction", regexp.MustCompile(`\(took ([^)]+)\)`)},
+	// wal: sync duration of 1.523469s, expected less than 1s
+	{etcdOpSlowFsync, "sync duration of", regexp.MustCompile(`sync duration of ([^,]+),`)},
+	// etcdserver: saved snapshot at index 100001
+	{etcdOpSnapshotSaved, "saved snapshot", nil},
+	// etcdserver: apply entries took too long [1.234567s for 1 entries]
+	{etcdOpSlowApply, "apply entries took too long", regexp.MustCompile(`\[([^ ]+) for`)},
+	// etcdserver: failed to send out heartbeat on time (exceeded the 100ms timeout for 52.3ms, to 3b4a7d2a6f6a1a2c)
+	{etcdOpHeartbeatDelayed, "failed to send out heartbeat on time", regexp.MustCompile(`timeout for ([^,]+),`)},
+	// etcdserver: server is likely overloaded
+	{etcdOpServerOverloaded, "server is likely overloaded", nil},
+}
+
+// etcd.log-20200922-1600800918.gz:2020-09-22 18:21:59.761339 I | mvcc: finished scheduled compaction at 1000 (took 2.337296ms)
+var etcdLogLineRegex = regexp.MustCompile(`^(.*?)(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{6}) ([A-Z]) \| (.*)$`)
+
+func ExtractEtcdOperationLog(pathToFind string) {
+	inputFile := "etcd.operation.log"
+	inputFilename := path.Join(pathToFind, inputFile)
+	outputFilename := path.Join(pathToFind, inputFile+".timeline")
+	summaryFilename := path.Join(pathToFind, inputFile+".summary")
+	EtcdOperation_Parser(inputFilename, outputFilename, summaryFilename)
+}
+
+// Input is the etcd log (or a grep of it over rotated files) and the output is a time sorted timeline of
+// leader changes, compactions, disk stalls, snapshots and overload warnings so that request latency spikes
+// can be lined up with them.
+func EtcdOperation_Parser(inputFileName, outputFileName, summaryFileName string) {
+	inputfileHandler, err := os.Open(inputFileName)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFileName, err)
+		panic(err)
+	}
+	defer inputfileHandler.Close()
+
+	outputFileHandler, err := os.Create(outputFileName)
+	if err != nil {
+		fmt.Printf("Error open output file [%s]: %v\n", outputFileName, err)
+		panic(err)
+	}
+	defer outputFileHandler.Close()
+
+	summaryFileHandler, err := os.Create(summaryFileName)
+	if err != nil {
+		fmt.Printf("Error open summary file [%s]: %v\n", summaryFileName, err)
+		panic(err)
+	}
+	defer summaryFileHandler.Close()
+
+	lineReader := bufio.NewReader(inputfileHandler)
+	lineCount := 0
+	events := make([]*EtcdOperationEvent, 0)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		lineCount++
+		event, isMatch := ParseEtcdOperationLine(line)
+		if isMatch {
+			events = append(events, event)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].logTime.Before(events[j].logTime)
+	})
+
+	outputFileHandler.WriteString("time, source, level, operation, duration, detail\n")
+	countByType := make(map[etcdOperationType]int)
+	totalByType := make(map[etcdOperationType]time.Duration)
+	maxByType := make(map[etcdOperationType]time.Duration)
+	for _, event := range events {
+		outputLine := fmt.Sprintf("%s, %s, %s, %s, %d, %s\n", event.logTime.Format(etcdLogTimeLayout), event.source,
+			event.level, event.opType, event.duration.Nanoseconds(), event.detail)
+		outputFileHandler.WriteString(outputLine)
+
+		countByType[event.opType]++
+		totalByType[event.opType] += event.duration
+		if event.duration > maxByType[event.opType] {
+			maxByType[event.opType] = event.duration
+		}
+	}
+
+	summaryFileHandler.WriteString("operation, count, total_duration, max_duration\n")
+	for _, matcher := range etcdOperationMatchers {
+		count, isOK := countByType[matcher.opType]
+		if !isOK {
+			continue
+		}
+		summaryFileHandler.WriteString(fmt.Sprintf("%s, %d, %d, %d\n", matcher.opType, count,
+			totalByType[matcher.opType].Nanoseconds(), maxByType[matcher.opType].Nanoseconds()))
+	}
+	fmt.Printf("Total line %d, operation events %d\n", lineCount, len(events))
+}
+
+// Returns the operation event in the line, or false if the line is not an etcd operation event
+func ParseEtcdOperationLine(line string) (*EtcdOperationEvent, bool) {
+	source, logTime, level, message, err := getEtcdLogHeader(line)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, matcher := range etcdOperationMatchers {
+		if !strings.Contains(message, matcher.pattern) {
+			continue
+		}
+
+		event := &EtcdOperationEvent{
+			logTime: logTime,
+			source:  source,
+			level:   level,
+			opType:  matcher.opType,
+			detail:  strings.ReplaceAll(message, ",", ";"),
+		}
+		if matcher.durationRe != nil {
+			subMatches := matcher.durationRe.FindStringSubmatch(message)
+			if len(subMatches) == 2 {
+				duration, err := time.ParseDuration(strings.TrimSpace(subMatches[1]))
+				if err != nil {
+					fmt.Printf("Cannot parse duration [%s] from line [%s]\n", subMatches[1], line)
+				} else {
+					event.duration = duration
+				}
+			}
+		}
+		return event, true
+	}
+
+	return nil, false
+}
+
+// Split etcd 3.4 log line into source file prefix (from grep), log time, level and message
+func getEtcdLogHeader(line string) (string, time.Time, string, string, error) {
+	subMatches := etcdLogLineRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if len(subMatches) != 5 {
+		return "", time.Time{}, "", "", errors.New(fmt.Sprintf("Invalid etcd log line [%s]", line))
+	}
+
+	logTime, err := time.Parse(etcdLogTimeLayout, subMatches[2])
+	if err != nil {
+		return "", time.Time{}, "", "", err
+	}
+
+	source := strings.TrimSuffix(subMatches[1], ":")
+	return source, logTime, subMatches[3], subMatches[4], nil
+}
@@ -0,0 +1,68 @@
+package etcd_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_ParseEtcdOperationLine(t *testing.T) {
+	// etcd.log-20200922-1600800918.gz:2020-09-22 18:21:59.761339 I | mvcc: finished scheduled compaction at 1000 (took 2.337296ms)
+	line := "etcd.log-20200922-1600800918.gz:2020-09-22 18:21:59.761339 I | mvcc: finished scheduled compaction at 1000 (took 2.337296ms)\n"
+	event, isMatch := ParseEtcdOperationLine(line)
+	assert.True(t, isMatch)
+	assert.Equal(t, "etcd.log-20200922-1600800918.gz", event.source)
+	assert.Equal(t, "I", event.level)
+	assert.Equal(t, etcdOpCompaction, event.opType)
+	assert.Equal(t, 2337296*time.Nanosecond, event.duration)
+	assert.Equal(t, "2020-09-22 18:21:59.761339", event.logTime.Format(etcdLogTimeLayout))
+
+	// 2020-09-25 19:58:45.805789 W | wal: sync duration of 1.523469s, expected less than 1s
+	line = "2020-09-25 19:58:45.805789 W | wal: sync duration of 1.523469s, expected less than 1s"
+	event, isMatch = ParseEtcdOperationLine(line)
+	assert.True(t, isMatch)
+	assert.Equal(t, "", event.source)
+	assert.Equal(t, etcdOpSlowFsync, event.opType)
+	assert.Equal(t, 1523469*time.Microsecond, event.duration)
+	assert.Equal(t, "wal: sync duration of 1.523469s; expected less than 1s", event.detail)
+
+	// etcd.log:2020-09-25 19:58:45.805789 W | etcdserver: apply entries took too long [1.234567s for 1 entries]
+	line = "etcd.log:2020-09-25 19:58:45.805789 W | etcdserver: apply entries took too long [1.234567s for 1 entries]"
+	event, isMatch = ParseEtcdOperationLine(line)
+	assert.True(t, isMatch)
+	assert.Equal(t, etcdOpSlowApply, event.opType)
+	assert.Equal(t, 1234567*time.Microsecond, event.duration)
+
+	// etcd.log:2020-09-25 19:58:45.805789 W | etcdserver: failed to send out heartbeat on time (exceeded the 100ms timeout for 52.3ms, to 3b4a7d2a6f6a1a2c)
+	line = "etcd.log:2020-09-25 19:58:45.805789 W | etcdserver: failed to send out heartbeat on time (exceeded the 100ms timeout for 52.3ms, to 3b4a7d2a6f6a1a2c)"
+	event, isMatch = ParseEtcdOperationLine(line)
+	assert.True(t, isMatch)
+	assert.Equal(t, etcdOpHeartbeatDelayed, event.opType)
+	assert.Equal(t, 52300*time.Microsecond, event.duration)
+
+	// etcd.log:2020-09-25 19:24:07.605099 I | raft: raft.node: 8e9e05c52164694d elected leader 8e9e05c52164694d at term 2
+	line = "etcd.log:2020-09-25 19:24:07.605099 I | raft: raft.node: 8e9e05c52164694d elected leader 8e9e05c52164694d at term 2"
+	event, isMatch = ParseEtcdOperationLine(line)
+	assert.True(t, isMatch)
+	assert.Equal(t, etcdOpLeaderElected, event.opType)
+	assert.Equal(t, time.Duration(0), event.duration)
+
+	// etcd logs both lines for one election, only elected leader is counted
+	line = "etcd.log:2020-09-25 19:24:07.605000 I | raft: 8e9e05c52164694d became leader at term 2"
+	_, isMatch = ParseEtcdOperationLine(line)
+	assert.False(t, isMatch)
+
+	// etcd.log:2020-09-25 19:24:07.605099 I | etcdserver: saved snapshot at index 100001
+	line = "etcd.log:2020-09-25 19:24:07.605099 I | etcdserver: saved snapshot at index 100001"
+	event, isMatch = ParseEtcdOperationLine(line)
+	assert.True(t, isMatch)
+	assert.Equal(t, etcdOpSnapshotSaved, event.opType)
+
+	// request lines are not operation events
+	line = "etcd.log:2020-09-25 19:24:07.605099 I | etcdserver: read-only range request \"key:\\\"/registry/masterleases/10.40.0.12\\\" \" with result \"range_response_count:0 size:4\" took (237.078µs) to execute"
+	_, isMatch = ParseEtcdOperationLine(line)
+	assert.False(t, isMatch)
+
+	_, isMatch = ParseEtcdOperationLine("")
+	assert.False(t, isMatch)
+}
@@ -0,0 +1,78 @@
+package etcd_log
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Read-only range request, LogTime is the time the request completed
+type EtcdRangeRequest struct {
+	LogTime  time.Time
+	Key      string
+	RangeEnd string
+	Duration time.Duration
+}
+
+// read-only range request "key:\"/registry/replicasets/kube-system/\" range_end:\"/registry/replicasets/kube-system0\" " with result "range_response_count:1 size:1655" took too long (129.790871ms) to execute
+var regexEtcdRangeRequest = regexp.MustCompile(`read-only range request "key:\\"(.*?)\\" (?:range_end:\\"(.*?)\\" )?.*took (?:too long )?\(([^)]+)\) to execute`)
+
+// Parse raw read-only range request line of etcd 3.4, e.g. lines in etcd.to.execute.range.log
+func ParseEtcdRangeLine(line string) (*EtcdRangeRequest, bool) {
+	_, logTime, _, message, err := getEtcdLogHeader(line)
+	if err != nil {
+		return nil, false
+	}
+	subMatches := regexEtcdRangeRequest.FindStringSubmatch(message)
+	if subMatches == nil {
+		return nil, false
+	}
+	duration, err := time.ParseDuration(subMatches[3])
+	if err != nil {
+		return nil, false
+	}
+
+	return &EtcdRangeRequest{
+		LogTime:  logTime,
+		Key:      subMatches[1],
+		RangeEnd: subMatches[2],
+		Duration: duration,
+	}, true
+}
+
+// Range requests in the file sorted by log time. Lines that are not range requests are skipped.
+func ReadEtcdRangeRequests(inputFileName string) []*EtcdRangeRequest {
+	inputfileHandler, err := os.Open(inputFileName)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFileName, err)
+		panic(err)
+	}
+	defer inputfileHandler.Close()
+
+	lineReader := bufio.NewReader(inputfileHandler)
+	requests := make([]*EtcdRangeRequest, 0)
+	skippedCount := 0
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		req, isOK := ParseEtcdRangeLine(line)
+		if !isOK {
+			skippedCount++
+			continue
+		}
+		requests = append(requests, req)
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].LogTime.Before(requests[j].LogTime)
+	})
+	fmt.Printf("Range requests %d, skipped lines %d\n", len(requests), skippedCount)
+	return requests
+}
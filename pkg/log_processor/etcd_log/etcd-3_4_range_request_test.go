@@ -0,0 +1,33 @@
+package etcd_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_ParseEtcdRangeLine(t *testing.T) {
+	line := "etcd.log:2020-09-25 19:24:07.605099 I | etcdserver: read-only range request \"key:\\\"/registry/masterleases/10.40.0.12\\\" \" with result \"range_response_count:0 size:4\" took (237.078µs) to execute\n"
+	req, isOK := ParseEtcdRangeLine(line)
+	assert.True(t, isOK)
+	assert.Equal(t, "2020-09-25 19:24:07.605099", req.LogTime.Format(etcdLogTimeLayout))
+	assert.Equal(t, "/registry/masterleases/10.40.0.12", req.Key)
+	assert.Equal(t, "", req.RangeEnd)
+	assert.Equal(t, 237078*time.Nanosecond, req.Duration)
+
+	line = "etcd.log-20200923-1600899026.gz:2020-09-23 21:40:29.312181 W | etcdserver: read-only range request \"key:\\\"/registry/replicasets/kube-system/\\\" range_end:\\\"/registry/replicasets/kube-system0\\\" \" with result \"range_response_count:1 size:1655\" took too long (129.790871ms) to execute"
+	req, isOK = ParseEtcdRangeLine(line)
+	assert.True(t, isOK)
+	assert.Equal(t, "/registry/replicasets/kube-system/", req.Key)
+	assert.Equal(t, "/registry/replicasets/kube-system0", req.RangeEnd)
+	assert.Equal(t, 129790871*time.Nanosecond, req.Duration)
+
+	line = "etcd.log:2020-09-25 20:01:55.283926 I | etcdserver: read-only range request \"key:\\\"/registry/minions/hollow-node-zz46z\\\\000\\\" range_end:\\\"/registry/minions0\\\" limit:500 revision:24335 \" with result \"range_response_count:1 size:6044\" took (221.845µs) to execute"
+	req, isOK = ParseEtcdRangeLine(line)
+	assert.True(t, isOK)
+	assert.Equal(t, "/registry/minions/hollow-node-zz46z\\\\000", req.Key)
+	assert.Equal(t, "/registry/minions0", req.RangeEnd)
+
+	_, isOK = ParseEtcdRangeLine("etcd.log:2020-09-22 18:21:59.761339 I | mvcc: finished scheduled compaction at 1000 (took 2.337296ms)")
+	assert.False(t, isOK)
+}
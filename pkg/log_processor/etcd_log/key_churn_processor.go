@@ -0,0 +1,257 @@
+package etcd_log
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type keyChurn struct {
+	key            string
+	writeTimes     []time.Time
+	casCount       int // compare on an existing mod_revision
+	deletes        int // value sizes below only track puts
+	firstValueSize int
+	lastValueSize  int
+	maxValueSize   int
+	peakWrites     int // max writes inside one sliding window
+}
+
+// Keys in .caskeys file
+const topCasKeyCount = 100
+
+type largeValueWrite struct {
+	logTime   time.Time
+	key       string
+	method    string
+	valueSize int
+}
+
+func ExtractEtcdKeyChurn(pathToFind string, largeValueThreshold int, window time.Duration) {
+	inputFile := "etcd.to.execute.norange.log"
+	inputFilename := path.Join(pathToFind, inputFile)
+	outputFilename := path.Join(pathToFind, inputFile+".keychurn")
+	prefixFilename := path.Join(pathToFind, inputFile+".prefixchurn")
+	largeValueFilename := path.Join(pathToFind, inputFile+".largevalue")
+	casFilename := path.Join(pathToFind, inputFile+".caskeys")
+	KeyChurn_Parser(inputFilename, outputFilename, prefixFilename, largeValueFilename, casFilename, largeValueThreshold, window)
+}
+
+func KeyChurn_Parser(inputFileName, outputFileName, prefixFileName, largeValueFileName, casFileName string, largeValueThreshold int, window time.Duration) {
+	inputfileHandler, err := os.Open(inputFileName)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFileName, err)
+		panic(err)
+	}
+	defer inputfileHandler.Close()
+
+	outputFileHandler, err := os.Create(outputFileName)
+	if err != nil {
+		fmt.Printf("Error open output file [%s]: %v\n", outputFileName, err)
+		panic(err)
+	}
+	defer outputFileHandler.Close()
+
+	prefixFileHandler, err := os.Create(prefixFileName)
+	if err != nil {
+		fmt.Printf("Error open prefix file [%s]: %v\n", prefixFileName, err)
+		panic(err)
+	}
+	defer prefixFileHandler.Close()
+
+	largeValueFileHandler, err := os.Create(largeValueFileName)
+	if err != nil {
+		fmt.Printf("Error open large value file [%s]: %v\n", largeValueFileName, err)
+		panic(err)
+	}
+	defer largeValueFileHandler.Close()
+
+	casFileHandler, err := os.Create(casFileName)
+	if err != nil {
+		fmt.Printf("Error open compare-and-swap key file [%s]: %v\n", casFileName, err)
+		panic(err)
+	}
+	defer casFileHandler.Close()
+
+	lineReader := bufio.NewReader(inputfileHandler)
+	lineCount := 0
+	writeCount := 0
+	keyChurns := make(map[string]*keyChurn)
+	prefixChurns := make(map[string]*keyChurn)
+	largeValues := make([]largeValueWrite, 0)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		lineCount++
+		req, hasError := parseNoReadOnlyRangeRequest(line)
+		if hasError || !isWriteRequest(req) {
+			continue
+		}
+		_, logTime, _, _, err := getEtcdLogHeader(line)
+		if err != nil {
+			fmt.Printf("Cannot parse log time from line [%s]\n", line)
+			continue
+		}
+
+		writeCount++
+		valueSize, _ := strconv.Atoi(req.success_value_size)
+		isCAS := req.mod_revision != "" && req.mod_revision != "0"
+		isDelete := req.success_method == "request_delete_range"
+		key := trimKeySeparator(req.key)
+		addKeyWrite(keyChurns, key, logTime, valueSize, isCAS, isDelete)
+		addKeyWrite(prefixChurns, getKeyPrefix(key), logTime, valueSize, isCAS, isDelete)
+
+		if largeValueThreshold > 0 && valueSize > largeValueThreshold {
+			largeValues = append(largeValues, largeValueWrite{
+				logTime:   logTime,
+				key:       key,
+				method:    req.success_method,
+				valueSize: valueSize,
+			})
+		}
+	}
+
+	outputFileHandler.WriteString(fmt.Sprintf("key, writes, peak_writes_per_%v, cas_count, deletes, first_value_size, last_value_size, max_value_size, value_size_growth\n", window))
+	sortedKeys := getSortedKeyChurns(keyChurns, window)
+	for _, churn := range sortedKeys {
+		outputFileHandler.WriteString(getKeyChurnOutputLine(churn))
+	}
+
+	prefixFileHandler.WriteString(fmt.Sprintf("prefix, writes, peak_writes_per_%v, cas_count, deletes, first_value_size, last_value_size, max_value_size, value_size_growth\n", window))
+	for _, churn := range getSortedKeyChurns(prefixChurns, window) {
+		prefixFileHandler.WriteString(getKeyChurnOutputLine(churn))
+	}
+
+	largeValueFileHandler.WriteString("time, key, method, value_size\n")
+	for _, largeValue := range largeValues {
+		largeValueFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %d\n", largeValue.logTime.Format(etcdLogTimeLayout),
+			largeValue.key, largeValue.method, largeValue.valueSize))
+	}
+
+	casFileHandler.WriteString("key, cas_count, writes\n")
+	for _, churn := range getTopCasKeyChurns(sortedKeys, topCasKeyCount) {
+		casFileHandler.WriteString(fmt.Sprintf("%s, %d, %d\n", churn.key, churn.casCount, len(churn.writeTimes)))
+	}
+	fmt.Printf("Total line %d, writes %d, keys %d, prefixes %d, values larger than %d bytes %d\n",
+		lineCount, writeCount, len(keyChurns), len(prefixChurns), largeValueThreshold, len(largeValues))
+}
+
+// Keys with the most compare-and-swap writes, keys without any are skipped. Sorted churns keep their order.
+func getTopCasKeyChurns(sortedChurns []*keyChurn, count int) []*keyChurn {
+	casChurns := make([]*keyChurn, 0)
+	for _, churn := range sortedChurns {
+		if churn.casCount > 0 {
+			casChurns = append(casChurns, churn)
+		}
+	}
+	sort.SliceStable(casChurns, func(i, j int) bool {
+		return casChurns[i].casCount > casChurns[j].casCount
+	})
+	if len(casChurns) > count {
+		casChurns = casChurns[:count]
+	}
+	return casChurns
+}
+
+func isWriteRequest(req *NoRangeRequest) bool {
+	return req.success_method == "request_put" || req.success_method == "request_delete_range"
+}
+
+// Deletes are counted apart, value sizes and growth only come from puts
+func addKeyWrite(churns map[string]*keyChurn, key string, logTime time.Time, valueSize int, isCAS bool, isDelete bool) {
+	churn, isOK := churns[key]
+	if !isOK {
+		churn = &keyChurn{
+			key: key,
+		}
+		churns[key] = churn
+	}
+
+	if isCAS {
+		churn.casCount++
+	}
+	if isDelete {
+		churn.writeTimes = append(churn.writeTimes, logTime)
+		churn.deletes++
+		return
+	}
+	if len(churn.writeTimes) == churn.deletes {
+		churn.firstValueSize = valueSize
+	}
+	churn.writeTimes = append(churn.writeTimes, logTime)
+	churn.lastValueSize = valueSize
+	if valueSize > churn.maxValueSize {
+		churn.maxValueSize = valueSize
+	}
+}
+
+// Sorted by write count, most written first
+func getSortedKeyChurns(churns map[string]*keyChurn, window time.Duration) []*keyChurn {
+	sortedChurns := make([]*keyChurn, 0, len(churns))
+	for _, churn := range churns {
+		churn.peakWrites = getPeakWritesInWindow(churn.writeTimes, window)
+		sortedChurns = append(sortedChurns, churn)
+	}
+	sort.Slice(sortedChurns, func(i, j int) bool {
+		if len(sortedChurns[i].writeTimes) != len(sortedChurns[j].writeTimes) {
+			return len(sortedChurns[i].writeTimes) > len(sortedChurns[j].writeTimes)
+		}
+		return sortedChurns[i].key < sortedChurns[j].key
+	})
+	return sortedChurns
+}
+
+// Max number of writes inside any window of the given size
+func getPeakWritesInWindow(writeTimes []time.Time, window time.Duration) int {
+	sort.Slice(writeTimes, func(i, j int) bool {
+		return writeTimes[i].Before(writeTimes[j])
+	})
+
+	peak := 0
+	start := 0
+	for end := 0; end < len(writeTimes); end++ {
+		for writeTimes[end].Sub(writeTimes[start]) >= window {
+			start++
+		}
+		if end-start+1 > peak {
+			peak = end - start + 1
+		}
+	}
+	return peak
+}
+
+func getKeyChurnOutputLine(churn *keyChurn) string {
+	return fmt.Sprintf("%s, %d, %d, %d, %d, %d, %d, %d, %d\n", churn.key, len(churn.writeTimes), churn.peakWrites,
+		churn.casCount, churn.deletes, churn.firstValueSize, churn.lastValueSize, churn.maxValueSize,
+		churn.lastValueSize-churn.firstValueSize)
+}
+
+// Get resource prefix of key
+// /registry/pods/test-6vwfq1-3/saturation-deployment-0-7675bfc6b9-76zbj -> /registry/pods
+// compact_rev_key -> compact_rev_key
+func getKeyPrefix(key string) string {
+	key = trimKeySeparator(key)
+	if !strings.HasPrefix(key, "/") {
+		return key
+	}
+
+	fields := strings.Split(key, "/")
+	if len(fields) <= 3 {
+		return key
+	}
+	return strings.Join(fields[:3], "/")
+}
+
+// Trailing separator of the key in etcd log is trimmed, /registry/namespaces\ -> /registry/namespaces
+func trimKeySeparator(key string) string {
+	return strings.TrimRight(key, "/\\")
+}
@@ -0,0 +1,83 @@
+package etcd_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_getKeyPrefix(t *testing.T) {
+	assert.Equal(t, "/registry/pods", getKeyPrefix("/registry/pods/test-6vwfq1-3/saturation-deployment-0-7675bfc6b9-76zbj\\"))
+	assert.Equal(t, "/registry/minions", getKeyPrefix("/registry/minions/hollow-node-hmhjh\\"))
+	assert.Equal(t, "/registry/namespaces", getKeyPrefix("/registry/namespaces\\"))
+	assert.Equal(t, "/registry/namespaces", getKeyPrefix("/registry/namespaces"))
+	assert.Equal(t, "/registry/pods", getKeyPrefix("/registry/pods/"))
+	assert.Equal(t, "/registry/pods", getKeyPrefix("/registry/pods/ns/"))
+	assert.Equal(t, "compact_rev_key", getKeyPrefix("compact_rev_key\\"))
+}
+
+func Test_getTopCasKeyChurns(t *testing.T) {
+	sortedChurns := []*keyChurn{{key: "a", casCount: 0}, {key: "b", casCount: 2}, {key: "c", casCount: 5}, {key: "d", casCount: 2}}
+	top := getTopCasKeyChurns(sortedChurns, 2)
+	assert.Equal(t, 2, len(top))
+	assert.Equal(t, "c", top[0].key)
+	assert.Equal(t, "b", top[1].key)
+	assert.Equal(t, 3, len(getTopCasKeyChurns(sortedChurns, 10)))
+}
+
+func Test_getPeakWritesInWindow(t *testing.T) {
+	start := time.Date(2020, 9, 25, 20, 0, 0, 0, time.UTC)
+	writeTimes := []time.Time{
+		start.Add(12 * time.Second),
+		start,
+		start.Add(time.Second),
+		start.Add(2 * time.Second),
+		start.Add(11 * time.Second),
+		start.Add(13 * time.Second),
+		start.Add(14 * time.Second),
+	}
+	assert.Equal(t, 4, getPeakWritesInWindow(writeTimes, 10*time.Second))
+	assert.Equal(t, 3, getPeakWritesInWindow(writeTimes, 3*time.Second))
+	assert.Equal(t, 0, getPeakWritesInWindow([]time.Time{}, 10*time.Second))
+}
+
+func Test_parseNoReadOnlyRangeRequest_write(t *testing.T) {
+	// etcd.log-20200923-1600899026.gz:2020-09-23 21:50:05.805743 W | etcdserver: request "header:<ID:10592876152217224613 > txn:<compare:<target:MOD key:\"/registry/minions/hollow-node-hmhjh\" mod_revision:412936 > success:<request_put:<key:\"/registry/minions/hollow-node-hmhjh\" value_size:1390 >> failure:<request_range:<key:\"/registry/minions/hollow-node-hmhjh\" > >>" with result "size:18" took too long (118.23637ms) to execute
+	line := "etcd.log-20200923-1600899026.gz:2020-09-23 21:50:05.805743 W | etcdserver: request \"header:<ID:10592876152217224613 > txn:<compare:<target:MOD key:\\\"/registry/minions/hollow-node-hmhjh\\\" mod_revision:412936 > success:<request_put:<key:\\\"/registry/minions/hollow-node-hmhjh\\\" value_size:1390 >> failure:<request_range:<key:\\\"/registry/minions/hollow-node-hmhjh\\\" > >>\" with result \"size:18\" took too long (118.23637ms) to execute\n"
+	req, hasError := parseNoReadOnlyRangeRequest(line)
+	assert.False(t, hasError)
+	assert.True(t, isWriteRequest(req))
+	assert.Equal(t, "412936", req.mod_revision)
+	assert.Equal(t, "1390", req.success_value_size)
+
+	// etcd.log:2020-09-22 18:12:26.540548 I | etcdserver: request "header:<ID:10592876127946486339 > lease_revoke:<id:130174b703f730e4>" with result "size:27" took (65.923µs) to execute
+	line = "etcd.log:2020-09-22 18:12:26.540548 I | etcdserver: request \"header:<ID:10592876127946486339 > lease_revoke:<id:130174b703f730e4>\" with result \"size:27\" took (65.923µs) to execute"
+	req, hasError = parseNoReadOnlyRangeRequest(line)
+	assert.False(t, hasError)
+	assert.False(t, isWriteRequest(req))
+}
+
+func Test_trimKeySeparator(t *testing.T) {
+	assert.Equal(t, "/registry/minions/hollow-node-hmhjh", trimKeySeparator("/registry/minions/hollow-node-hmhjh\\"))
+	assert.Equal(t, "/registry/pods/ns", trimKeySeparator("/registry/pods/ns/"))
+	assert.Equal(t, "compact_rev_key", trimKeySeparator("compact_rev_key"))
+}
+
+func Test_addKeyWrite(t *testing.T) {
+	start := time.Date(2020, 9, 25, 20, 0, 0, 0, time.UTC)
+	churns := make(map[string]*keyChurn)
+	addKeyWrite(churns, "a", start, 0, false, true)
+	addKeyWrite(churns, "a", start.Add(time.Second), 100, false, false)
+	addKeyWrite(churns, "a", start.Add(2*time.Second), 300, true, false)
+	addKeyWrite(churns, "a", start.Add(3*time.Second), 0, true, true)
+
+	churn := churns["a"]
+	assert.Equal(t, 4, len(churn.writeTimes))
+	assert.Equal(t, 2, churn.deletes)
+	assert.Equal(t, 2, churn.casCount)
+	assert.Equal(t, 100, churn.firstValueSize)
+	assert.Equal(t, 300, churn.lastValueSize)
+	assert.Equal(t, 300, churn.maxValueSize)
+	churn.peakWrites = 4
+	assert.Equal(t, "a, 4, 4, 2, 2, 100, 300, 300, 200\n", getKeyChurnOutputLine(churn))
+}
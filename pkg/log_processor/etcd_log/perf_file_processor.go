@@ -8,25 +8,39 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"tools/pkg/log_util"
 )
 
 func ParseRangeLog(pathToFind string) {
 	inputFile := "etcd.to.execute.range.log.compacted"
 	inputFilename := path.Join(pathToFind, inputFile)
 	outputFilename := path.Join(pathToFind, inputFile + ".keycount")
+	slowCountFilename := path.Join(pathToFind, inputFile + ".slowcount")
 	fmt.Println("File " + inputFilename)
-	AnalysisReadOnlyRangePerfData(inputFilename, outputFilename, "RangeOnly")
+	AnalysisReadOnlyRangePerfData(inputFilename, outputFilename, slowCountFilename, "RangeOnly")
 }
 
 func ParNonRangeLog(pathToFind string) {
 	inputFile := "etcd.to.execute.norange.log.compacted"
 	inputFilename := path.Join(pathToFind, inputFile)
 	outputFilename := path.Join(pathToFind, inputFile + ".keycount")
+	slowCountFilename := path.Join(pathToFind, inputFile + ".slowcount")
 	fmt.Println("File " + inputFilename)
-	AnalysisReadOnlyRangePerfData(inputFilename, outputFilename,"NonRange")
+	AnalysisReadOnlyRangePerfData(inputFilename, outputFilename, slowCountFilename, "NonRange")
 }
 
-func AnalysisReadOnlyRangePerfData(inputFilename, outputFilename string, perfFileType string) {
+// Thresholds of slow request counts in .slowcount file, e.g. 100ms, 12
+var slowRequestThresholds = []time.Duration{100 * time.Millisecond, time.Second, 5 * time.Second, 10 * time.Second}
+
+type keyPerfData struct {
+	count int
+	totalDuration int
+	maxDuration int
+	latency *log_util.QuantileSketch
+}
+
+func AnalysisReadOnlyRangePerfData(inputFilename, outputFilename, slowCountFilename string, perfFileType string) {
 	inputfileHandler, err := os.Open(inputFilename)
 	if err != nil {
 		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
@@ -41,18 +55,19 @@ func AnalysisReadOnlyRangePerfData(inputFilename, outputFilename string, perfFil
 	}
 	defer outputFileHandler.Close()
 
+	slowCountFileHandler, err := os.Create(slowCountFilename)
+	if err != nil {
+		fmt.Printf("Error open slow count file [%s]: %v\n", slowCountFilename, err)
+		panic(err)
+	}
+	defer slowCountFileHandler.Close()
+
 	lineReader := bufio.NewReader(inputfileHandler)
 	lineCount := 0
-	timeLimit1 := 100000	// 100 ms
-	timeLimit2 := 10 * timeLimit1 // 1s
-	timeLimit3 := 5 * timeLimit2 // 5s
-	timeLimit4 := 10 * timeLimit2 // 10s
-	countExceed1 := 0
-	countExceed2 := 0
-	countExceed3 := 0
-	countExceed4 := 0
-
-	keyCount := make(map[string]int)
+	// duration in compacted file is in nano seconds
+	slowHistogram := log_util.NewHistogram(slowRequestThresholds, false)
+
+	keyCount := make(map[string]*keyPerfData)
 	for {
 		line, err := lineReader.ReadString('\n')
 		if err != nil {
@@ -71,29 +86,34 @@ func AnalysisReadOnlyRangePerfData(inputFilename, outputFilename string, perfFil
 		durationInMicroSec, _ := strconv.Atoi(durationStr)
 
 		if durationInMicroSec > 0 {
-			if durationInMicroSec > timeLimit1 {
-				countExceed1++
-				if durationInMicroSec > timeLimit2 {
-					countExceed2++
-					if durationInMicroSec > timeLimit3 {
-						countExceed3++
-						if durationInMicroSec > timeLimit4 {
-							countExceed4++
-						}
-					}
-				}
-			}
+			slowHistogram.Add(time.Duration(durationInMicroSec))
 
 			if v, isOK := keyCount[key]; isOK {
-				keyCount[key] = v + 1
+				v.count++
+				v.totalDuration += durationInMicroSec
+				if durationInMicroSec > v.maxDuration {
+					v.maxDuration = durationInMicroSec
+				}
+				v.latency.Add(time.Duration(durationInMicroSec))
 			} else {
-				keyCount[key] = 1
+				keyCount[key] = &keyPerfData{
+					count: 1,
+					totalDuration: durationInMicroSec,
+					maxDuration: durationInMicroSec,
+					latency: log_util.NewDefaultQuantileSketch(),
+				}
+				keyCount[key].latency.Add(time.Duration(durationInMicroSec))
 			}
 		}
 	}
 
 	fmt.Printf("Scanned %d lines, found %d line exceeds 100ms, %d line excceds 1s, %d line exceeds 5s, %d line exceeds 10s\n",
-		lineCount, countExceed1, countExceed2, countExceed3, countExceed4)
+		lineCount, slowHistogram.CountAbove(slowRequestThresholds[0]), slowHistogram.CountAbove(slowRequestThresholds[1]),
+		slowHistogram.CountAbove(slowRequestThresholds[2]), slowHistogram.CountAbove(slowRequestThresholds[3]))
+	slowCountFileHandler.WriteString("threshold, count\n")
+	for _, threshold := range slowRequestThresholds {
+		slowCountFileHandler.WriteString(fmt.Sprintf("%s, %d\n", threshold, slowHistogram.CountAbove(threshold)))
+	}
 
 	// output key count into file
 	keyArray := make([]string, len(keyCount))
@@ -103,15 +123,24 @@ func AnalysisReadOnlyRangePerfData(inputFilename, outputFilename string, perfFil
 		index++
 	}
 	sort.Strings(keyArray)
-	outputFileHandler.WriteString("key, count\n")
+	// percentiles are within the relative accuracy of the sketch
+	outputFileHandler.WriteString("key, count, avg_duration, max_duration, p50_duration, p99_duration\n")
 	countTotal := 0
 	for i:=0; i < index; i++ {
 		v, _ := keyCount[keyArray[i]]
-		outputLine := fmt.Sprintf("%s, %d\n", keyArray[i], v)
+		outputLine := fmt.Sprintf("%s, %d, %d, %d, %d, %d\n", keyArray[i], v.count, v.totalDuration/v.count, v.maxDuration,
+			int64(v.latency.Quantile(50)), int64(v.latency.Quantile(99)))
 		outputFileHandler.WriteString(outputLine)
-		countTotal += v
+		countTotal += v.count
 	}
 	outputFileHandler.WriteString(fmt.Sprintf("Total %d keys\n", index))
+
+	// latency sketches by key, e.g. etcd.to.execute.range.log.compacted.keycount.sketch
+	keySketches := make(map[string]*log_util.QuantileSketch, len(keyCount))
+	for k, v := range keyCount {
+		keySketches[k] = v.latency
+	}
+	log_util.WriteQuantileSketches(outputFilename + log_util.QuantileSketchFileSuffix, keySketches)
 	fmt.Printf("Key count file generated. Total %d keys, count total %d. Equal line total %v\n",
 		index, countTotal, countTotal + 1 == lineCount)
 }
@@ -0,0 +1,30 @@
+package etcd_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+	"tools/pkg/log_util"
+)
+
+func Test_AnalysisReadOnlyRangePerfData(t *testing.T) {
+	dir, err := os.MkdirTemp("", "perf")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	inputFilename := path.Join(dir, "etcd.to.execute.range.log.compacted")
+	outputFilename := inputFilename + ".keycount"
+	lines := "/registry/pods/t1/ns/p1, , false, 0, 1, 100, 2000\n" +
+		"/registry/pods/t1/ns/p1, , false, 0, 1, 100, 4000\n" +
+		"/registry/pods/t1/ns/p2, , false, 0, 1, 100, 1000\n"
+	assert.Nil(t, os.WriteFile(inputFilename, []byte(lines), 0644))
+	AnalysisReadOnlyRangePerfData(inputFilename, outputFilename, inputFilename+".slowcount", "RangeOnly")
+
+	sketches := make(map[string]*log_util.QuantileSketch)
+	assert.Nil(t, log_util.MergeQuantileSketchFile(outputFilename+log_util.QuantileSketchFileSuffix, sketches))
+	assert.Equal(t, 2, len(sketches))
+	assert.Equal(t, 2, sketches["/registry/pods/t1/ns/p1"].Count())
+	assert.Equal(t, int64(4000), int64(sketches["/registry/pods/t1/ns/p1"].Max()))
+	assert.Equal(t, 1, sketches["/registry/pods/t1/ns/p2"].Count())
+}
@@ -0,0 +1,227 @@
+package kubelet_log
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+	"tools/pkg/log_util"
+)
+
+type KubeletEventType string
+
+const (
+	KubeletSyncLoopAdd      KubeletEventType = "sync_loop_add"
+	KubeletSandboxCreating  KubeletEventType = "sandbox_creating"
+	KubeletSandboxCreated   KubeletEventType = "sandbox_created"
+	KubeletImagePulling     KubeletEventType = "image_pulling"
+	KubeletImagePulled      KubeletEventType = "image_pulled"
+	KubeletContainerStarted KubeletEventType = "container_started"
+	KubeletPodRunning       KubeletEventType = "pod_running"
+	KubeletProbeFailed      KubeletEventType = "probe_failed"
+	KubeletPlegRelist       KubeletEventType = "pleg_relist"
+	KubeletPlegUnhealthy    KubeletEventType = "pleg_unhealthy"
+)
+
+// Pod and node event in kubelet or hollow-node log
+type KubeletEvent struct {
+	Source    string // log file prefixed by grep, e.g. hollow-node-1-btv5d.log
+	LogTime   string // 15:04:05.000000
+	EventType KubeletEventType
+	Pods      []string      // namespace/name, tenant/namespace/name of Arktos
+	Duration  time.Duration // image pull, PLEG relist, or time since PLEG was last seen active
+	Detail    string        // probe type
+}
+
+type kubeletEventMatcher struct {
+	eventType KubeletEventType
+	regex     *regexp.Regexp // named groups: pods (kubelet pod names), namespace and name, duration, detail
+}
+
+// Legacy log lines, first matching regex wins
+var kubeletEventMatchers = []kubeletEventMatcher{
+	// kubelet.go:1908] SyncLoop (ADD, "api"): "saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)"
+	{KubeletSyncLoopAdd, regexp.MustCompile(`SyncLoop \(ADD, "[^"]*"\): "(?P<pods>[^"]*)"`)},
+	// kuberuntime_manager.go:651] Creating sandbox for pod "saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-...)"
+	{KubeletSandboxCreating, regexp.MustCompile(`Creating sandbox for pod "(?P<pods>[^"]+)"`)},
+	// kuberuntime_manager.go:660] Created PodSandbox "0d5f4c1b" for pod "saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-...)"
+	{KubeletSandboxCreated, regexp.MustCompile(`Created PodSandbox "[^"]*" for pod "(?P<pods>[^"]+)"`)},
+	// event.go:291] Event(v1.ObjectReference{Kind:"Pod", Namespace:"1ea47i-testns", Name:"saturation-deployment-0-c47675f5-xf258", ...}): type: 'Normal' reason: 'Pulling' Pulling image "k8s.gcr.io/pause:3.2"
+	{KubeletImagePulling, regexp.MustCompile(`Kind:"Pod", Namespace:"(?P<namespace>[^"]*)", Name:"(?P<name>[^"]*)".*reason: 'Pulling'`)},
+	// event.go:291] Event(v1.ObjectReference{Kind:"Pod", ...}): type: 'Normal' reason: 'Pulled' Successfully pulled image "k8s.gcr.io/pause:3.2" in 1.234s
+	{KubeletImagePulled, regexp.MustCompile(`Kind:"Pod", Namespace:"(?P<namespace>[^"]*)", Name:"(?P<name>[^"]*)".*reason: 'Pulled'(?:.* in (?P<duration>[0-9.]+[a-zµ]+))?`)},
+	// kubelet.go:1946] SyncLoop (PLEG): "saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-...)", event: &pleg.PodLifecycleEvent{ID:"5ae3c8f8-...", Type:"ContainerStarted", Data:"0d5f4c1b"}
+	{KubeletContainerStarted, regexp.MustCompile(`SyncLoop \(PLEG\): "(?P<pods>[^"]+)", event: .*Type:"ContainerStarted"`)},
+	// status_manager.go:575] Status for pod "saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-...)" updated successfully: (2, {Phase:Running ...
+	{KubeletPodRunning, regexp.MustCompile(`Status for pod "(?P<pods>[^"]+)" updated successfully: .*Phase:Running`)},
+	// prober.go:116] Readiness probe for "saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-...):pause" failed (failure): Get http://10.0.0.1:8080/: dial tcp 10.0.0.1:8080: connect: connection refused
+	{KubeletProbeFailed, regexp.MustCompile(`(?P<detail>Readiness|Liveness|Startup) probe for "(?P<pods>[^":]+)(?::[^"]*)?" failed`)},
+	// generic.go:200] GenericPLEG: Relisting took 1.523s
+	{KubeletPlegRelist, regexp.MustCompile(`(?i)relisting took (?P<duration>[0-9.]+[a-zµ]+)`)},
+	// kubelet.go:1876] skipping pod synchronization - PLEG is not healthy: pleg was last seen active 3m5.1s ago; threshold is 3m0s
+	{KubeletPlegUnhealthy, regexp.MustCompile(`PLEG is not healthy: pleg was last seen active (?P<duration>[0-9.hmµns]+) ago`)},
+}
+
+// Parse kubelet log line in legacy or structured format. Returns false if the line is not a kubelet event.
+func ParseKubeletLine(line string) (*KubeletEvent, bool) {
+	// hollow-node-1-btv5d.log:I0409 22:32:36.300000       1 kubelet.go:1908] ...
+	source, line := log_util.SplitLogSource(strings.TrimSpace(line))
+	klogLine, isOK := log_util.ParseKlogLine(line)
+	if !isOK {
+		return nil, false
+	}
+
+	event, isOK := parseStructuredKubeletEvent(klogLine)
+	if !isOK {
+		event, isOK = parseLegacyKubeletEvent(klogLine.Message)
+	}
+	if !isOK {
+		return nil, false
+	}
+	event.Source = source
+	event.LogTime = klogLine.Time
+	return event, true
+}
+
+func parseLegacyKubeletEvent(message string) (*KubeletEvent, bool) {
+	for _, matcher := range kubeletEventMatchers {
+		matches := matcher.regex.FindStringSubmatch(message)
+		if matches == nil {
+			continue
+		}
+
+		event := &KubeletEvent{EventType: matcher.eventType}
+		namespace := ""
+		name := ""
+		for i, group := range matcher.regex.SubexpNames() {
+			switch group {
+			case "pods":
+				// "p1_ns(uid), p2_ns(uid)"
+				for _, pod := range strings.Split(matches[i], ", ") {
+					if pod != "" {
+						event.Pods = append(event.Pods, GetPodFromKubeletPodName(pod))
+					}
+				}
+			case "namespace":
+				namespace = matches[i]
+			case "name":
+				name = matches[i]
+			case "duration":
+				if matches[i] != "" {
+					event.Duration, _ = time.ParseDuration(matches[i])
+				}
+			case "detail":
+				event.Detail = matches[i]
+			}
+		}
+		if name != "" {
+			event.Pods = append(event.Pods, namespace+"/"+name)
+		}
+		return event, true
+	}
+	return nil, false
+}
+
+// "SyncLoop ADD" source="api" pods=[ns/p1 ns/p2]
+// "SyncLoop (PLEG): event for pod" pod="ns/p1" event=&{ID:5ae3c8f8 Type:ContainerStarted Data:0d5f4c1b}
+func parseStructuredKubeletEvent(klogLine *log_util.KlogLine) (*KubeletEvent, bool) {
+	values := klogLine.Values
+	if len(values) == 0 {
+		return nil, false
+	}
+
+	event := &KubeletEvent{}
+	switch klogLine.Message {
+	case "SyncLoop ADD":
+		event.EventType = KubeletSyncLoopAdd
+		event.Pods = getStructuredPods(values["pods"])
+		return event, true
+	case "Creating PodSandbox for pod":
+		event.EventType = KubeletSandboxCreating
+	case "Created PodSandbox for pod":
+		event.EventType = KubeletSandboxCreated
+	case "SyncLoop (PLEG): event for pod":
+		if !strings.Contains(values["event"], "ContainerStarted") {
+			return nil, false
+		}
+		event.EventType = KubeletContainerStarted
+	case "Status for pod updated successfully":
+		if !strings.Contains(values["status"], "Phase:Running") {
+			return nil, false
+		}
+		event.EventType = KubeletPodRunning
+	case "Probe failed":
+		event.EventType = KubeletProbeFailed
+		event.Detail = values["probeType"]
+	case "Event occurred":
+		if values["kind"] != "Pod" {
+			return nil, false
+		}
+		switch values["reason"] {
+		case "Pulling":
+			event.EventType = KubeletImagePulling
+		case "Pulled":
+			event.EventType = KubeletImagePulled
+			if matches := regexImagePulledDuration.FindStringSubmatch(values["message"]); matches != nil {
+				event.Duration, _ = time.ParseDuration(matches[1])
+			}
+		default:
+			return nil, false
+		}
+		event.Pods = []string{values["object"]}
+		return event, true
+	default:
+		return nil, false
+	}
+
+	if values["pod"] == "" {
+		return nil, false
+	}
+	event.Pods = []string{values["pod"]}
+	return event, true
+}
+
+// pods=[ns/p1 ns/p2] in structured text, "pods":[{"name":"p1","namespace":"ns"}] in JSON
+func getStructuredPods(value string) []string {
+	if !strings.HasPrefix(value, "[{") {
+		return strings.Fields(strings.Trim(value, "[]"))
+	}
+
+	objects := make([]struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	}, 0)
+	if err := json.Unmarshal([]byte(value), &objects); err != nil {
+		return nil
+	}
+	pods := make([]string, 0, len(objects))
+	for _, object := range objects {
+		pods = append(pods, object.Namespace+"/"+object.Name)
+	}
+	return pods
+}
+
+// Successfully pulled image "k8s.gcr.io/pause:3.2" in 1.234s
+var regexImagePulledDuration = regexp.MustCompile(` in ([0-9.]+[a-zµ]+)`)
+
+// saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11) -> 1ea47i-testns/saturation-deployment-0-c47675f5-xf258
+// Arktos kubelet adds tenant, saturation-deployment-0-c47675f5-xf258_1ea47i-testns_arktos -> arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258
+// Pod name has no "_", the first "_" ends the name.
+func GetPodFromKubeletPodName(pod string) string {
+	if index := strings.Index(pod, "("); index != -1 {
+		pod = pod[:index]
+	}
+	parts := strings.SplitN(pod, "_", 3)
+	switch len(parts) {
+	case 2:
+		return parts[1] + "/" + parts[0]
+	case 3:
+		return parts[2] + "/" + parts[1] + "/" + parts[0]
+	}
+	return pod
+}
+
+// Name of namespace/name or tenant/namespace/name
+func GetPodName(pod string) string {
+	return pod[strings.LastIndex(pod, "/")+1:]
+}
@@ -0,0 +1,84 @@
+package kubelet_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_ParseKubeletLine(t *testing.T) {
+	event, isOK := ParseKubeletLine("hollow-node-1-btv5d.log:I0409 22:32:36.300000       1 kubelet.go:1908] SyncLoop (ADD, \"api\"): \"saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11), saturation-deployment-0-c47675f5-scn2w_1ea47i-testns(6ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)\"")
+	assert.True(t, isOK)
+	assert.Equal(t, "hollow-node-1-btv5d.log", event.Source)
+	assert.Equal(t, "22:32:36.300000", event.LogTime)
+	assert.Equal(t, KubeletSyncLoopAdd, event.EventType)
+	assert.Equal(t, []string{"1ea47i-testns/saturation-deployment-0-c47675f5-xf258", "1ea47i-testns/saturation-deployment-0-c47675f5-scn2w"}, event.Pods)
+
+	event, isOK = ParseKubeletLine("I0409 22:32:36.400000       1 kuberuntime_manager.go:660] Created PodSandbox \"0d5f4c1b\" for pod \"saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)\"")
+	assert.True(t, isOK)
+	assert.Equal(t, "", event.Source)
+	assert.Equal(t, KubeletSandboxCreated, event.EventType)
+	assert.Equal(t, []string{"1ea47i-testns/saturation-deployment-0-c47675f5-xf258"}, event.Pods)
+
+	event, isOK = ParseKubeletLine("I0409 22:32:36.900000       1 event.go:291] Event(v1.ObjectReference{Kind:\"Pod\", Namespace:\"1ea47i-testns\", Name:\"saturation-deployment-0-c47675f5-xf258\", UID:\"5ae3c8f8\", APIVersion:\"v1\", ResourceVersion:\"100\", FieldPath:\"spec.containers{pause}\"}): type: 'Normal' reason: 'Pulled' Successfully pulled image \"k8s.gcr.io/pause:3.2\" in 1.234s")
+	assert.True(t, isOK)
+	assert.Equal(t, KubeletImagePulled, event.EventType)
+	assert.Equal(t, 1234*time.Millisecond, event.Duration)
+	assert.Equal(t, []string{"1ea47i-testns/saturation-deployment-0-c47675f5-xf258"}, event.Pods)
+
+	event, isOK = ParseKubeletLine("W0409 22:32:38.000000       1 prober.go:116] Readiness probe for \"saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11):pause\" failed (failure): Get http://10.0.0.1:8080/: connect: connection refused")
+	assert.True(t, isOK)
+	assert.Equal(t, KubeletProbeFailed, event.EventType)
+	assert.Equal(t, "Readiness", event.Detail)
+	assert.Equal(t, []string{"1ea47i-testns/saturation-deployment-0-c47675f5-xf258"}, event.Pods)
+
+	event, isOK = ParseKubeletLine("hollow-node-2-x8k2p.log.1:I0409 22:32:39.000000       1 generic.go:200] GenericPLEG: Relisting took 1.523s")
+	assert.True(t, isOK)
+	assert.Equal(t, "hollow-node-2-x8k2p.log.1", event.Source)
+	assert.Equal(t, KubeletPlegRelist, event.EventType)
+	assert.Equal(t, 1523*time.Millisecond, event.Duration)
+
+	event, isOK = ParseKubeletLine("I0409 22:35:39.000000       1 kubelet.go:1876] skipping pod synchronization - PLEG is not healthy: pleg was last seen active 3m5.1s ago; threshold is 3m0s")
+	assert.True(t, isOK)
+	assert.Equal(t, KubeletPlegUnhealthy, event.EventType)
+	assert.Equal(t, 3*time.Minute+5100*time.Millisecond, event.Duration)
+
+	_, isOK = ParseKubeletLine("I0409 22:32:37.000000       1 status_manager.go:575] Status for pod \"saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)\" updated successfully: (1, {Phase:Pending Conditions:[]})")
+	assert.False(t, isOK)
+	_, isOK = ParseKubeletLine("not a klog line")
+	assert.False(t, isOK)
+}
+
+func Test_ParseKubeletLine_structured(t *testing.T) {
+	event, isOK := ParseKubeletLine("I1012 10:00:00.000000       1 kubelet.go:2092] \"SyncLoop ADD\" source=\"api\" pods=[ns/p1 ns/p2]")
+	assert.True(t, isOK)
+	assert.Equal(t, KubeletSyncLoopAdd, event.EventType)
+	assert.Equal(t, []string{"ns/p1", "ns/p2"}, event.Pods)
+
+	event, isOK = ParseKubeletLine("I1012 10:00:01.000000       1 kubelet.go:2125] \"SyncLoop (PLEG): event for pod\" pod=\"ns/p1\" event=&{ID:5ae3c8f8 Type:ContainerStarted Data:0d5f4c1b}")
+	assert.True(t, isOK)
+	assert.Equal(t, KubeletContainerStarted, event.EventType)
+	assert.Equal(t, "10:00:01.000000", event.LogTime)
+	assert.Equal(t, []string{"ns/p1"}, event.Pods)
+
+	event, isOK = ParseKubeletLine("I1012 10:00:02.000000       1 event.go:294] \"Event occurred\" object=\"ns/p1\" kind=\"Pod\" apiVersion=\"v1\" type=\"Normal\" reason=\"Pulled\" message=\"Successfully pulled image \\\"k8s.gcr.io/pause:3.2\\\" in 500ms\"")
+	assert.True(t, isOK)
+	assert.Equal(t, KubeletImagePulled, event.EventType)
+	assert.Equal(t, 500*time.Millisecond, event.Duration)
+	assert.Equal(t, []string{"ns/p1"}, event.Pods)
+
+	event, isOK = ParseKubeletLine("{\"ts\":1634032800.000123,\"caller\":\"kubelet/kubelet.go:2092\",\"msg\":\"SyncLoop ADD\",\"v\":2,\"source\":\"api\",\"pods\":[{\"name\":\"p1\",\"namespace\":\"ns\"}]}")
+	assert.True(t, isOK)
+	assert.Equal(t, KubeletSyncLoopAdd, event.EventType)
+	assert.Equal(t, []string{"ns/p1"}, event.Pods)
+
+	_, isOK = ParseKubeletLine("I1012 10:00:01.000000       1 kubelet.go:2125] \"SyncLoop (PLEG): event for pod\" pod=\"ns/p1\" event=&{ID:5ae3c8f8 Type:ContainerDied Data:0d5f4c1b}")
+	assert.False(t, isOK)
+}
+
+func Test_GetPodFromKubeletPodName(t *testing.T) {
+	assert.Equal(t, "1ea47i-testns/saturation-deployment-0-c47675f5-xf258", GetPodFromKubeletPodName("saturation-deployment-0-c47675f5-xf258_1ea47i-testns(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)"))
+	assert.Equal(t, "arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258", GetPodFromKubeletPodName("saturation-deployment-0-c47675f5-xf258_1ea47i-testns_arktos(5ae3c8f8-3b5f-4c5f-9d2e-9b2d7b3e6f11)"))
+	assert.Equal(t, "p1", GetPodFromKubeletPodName("p1"))
+	assert.Equal(t, "p1", GetPodName(GetPodFromKubeletPodName("p1_ns_arktos")))
+}
@@ -0,0 +1,264 @@
+package kubelet_log
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"tools/pkg/log_util"
+)
+
+// Pod lifecycle on one node, in log time of day
+type kubeletPodTime struct {
+	node               string
+	pod                string // namespace/name
+	addTime            string // first SyncLoop ADD
+	sandboxCreateTime  string // first Creating sandbox
+	sandboxCreatedTime string // first Created PodSandbox
+	containerStartTime string // first ContainerStarted of PLEG
+	runningTime        string // first Running status update
+	imagePullDuration  time.Duration
+	probeFailures      int
+}
+
+type kubeletNodeStats struct {
+	node             string
+	pods             []*kubeletPodTime
+	plegRelists      []time.Duration
+	plegUnhealthy    []time.Duration // time since PLEG was last seen active
+	imagePulls       int
+	imagePullElapsed time.Duration
+	probeFailures    int
+}
+
+// Per pod and per node latency of kubelet or hollow-node logs in the path. The input file can be concatenated from
+// all nodes by grep, e.g. grep -E "SyncLoop|PodSandbox|Pull|probe|PLEG|Relisting" hollow-node-*.log > kubelet.log,
+// in which case node is the log file prefixed to the lines, otherwise the input file.
+func ExtractKubeletLog(pathToFind string, inputFile string) {
+	inputFilename := path.Join(pathToFind, inputFile)
+	podFilename := inputFilename + ".pod.latency"
+	nodeFilename := inputFilename + ".node.latency"
+	plegFilename := inputFilename + ".pleg"
+
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	plegFileHandler, err := os.Create(plegFilename)
+	if err != nil {
+		fmt.Printf("Error create pleg file [%s]: %v\n", plegFilename, err)
+		panic(err)
+	}
+	defer plegFileHandler.Close()
+	plegFileHandler.WriteString("time, node, event, duration_ms\n")
+
+	nodes := make(map[string]*kubeletNodeStats)
+	pods := make(map[string]*kubeletPodTime)
+	events := 0
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		event, isOK := ParseKubeletLine(line)
+		if !isOK {
+			continue
+		}
+		events++
+		node := getKubeletNodeName(event.Source, inputFile)
+		nodeStats := getKubeletNodeStats(nodes, node)
+
+		switch event.EventType {
+		case KubeletPlegRelist:
+			nodeStats.plegRelists = append(nodeStats.plegRelists, event.Duration)
+		case KubeletPlegUnhealthy:
+			nodeStats.plegUnhealthy = append(nodeStats.plegUnhealthy, event.Duration)
+		case KubeletImagePulled:
+			nodeStats.imagePulls++
+			nodeStats.imagePullElapsed += event.Duration
+		case KubeletProbeFailed:
+			nodeStats.probeFailures++
+		}
+		if event.EventType == KubeletPlegRelist || event.EventType == KubeletPlegUnhealthy {
+			plegFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %.3f\n", event.LogTime, node, event.EventType,
+				log_util.GetDurationInMilliSecond(event.Duration)))
+			continue
+		}
+
+		for _, pod := range event.Pods {
+			addKubeletPodEvent(getKubeletPodTime(pods, nodeStats, pod), event)
+		}
+	}
+
+	writeKubeletPodLatency(podFilename, nodes)
+	writeKubeletNodeLatency(nodeFilename, nodes)
+	fmt.Printf("Kubelet events %d, nodes %d, pods %d\n", events, len(nodes), len(pods))
+}
+
+// First time of each event of the pod, later events are retries or resyncs
+func addKubeletPodEvent(podTime *kubeletPodTime, event *KubeletEvent) {
+	setFirstTime := func(logTime *string) {
+		if *logTime == "" {
+			*logTime = event.LogTime
+		}
+	}
+
+	switch event.EventType {
+	case KubeletSyncLoopAdd:
+		setFirstTime(&podTime.addTime)
+	case KubeletSandboxCreating:
+		setFirstTime(&podTime.sandboxCreateTime)
+	case KubeletSandboxCreated:
+		setFirstTime(&podTime.sandboxCreatedTime)
+	case KubeletImagePulled:
+		podTime.imagePullDuration += event.Duration
+	case KubeletContainerStarted:
+		setFirstTime(&podTime.containerStartTime)
+	case KubeletPodRunning:
+		setFirstTime(&podTime.runningTime)
+	case KubeletProbeFailed:
+		podTime.probeFailures++
+	}
+}
+
+func writeKubeletPodLatency(podFilename string, nodes map[string]*kubeletNodeStats) {
+	podFileHandler, err := os.Create(podFilename)
+	if err != nil {
+		fmt.Printf("Error create pod latency file [%s]: %v\n", podFilename, err)
+		panic(err)
+	}
+	defer podFileHandler.Close()
+
+	podFileHandler.WriteString("node, pod, add_time, sandbox_created_time, container_start_time, running_time, " +
+		"add_to_sandbox_ms, sandbox_create_ms, sandbox_to_container_ms, image_pull_ms, add_to_running_ms, probe_failures\n")
+	for _, nodeStats := range getSortedKubeletNodeStats(nodes) {
+		for _, podTime := range nodeStats.pods {
+			podFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %s, %s, %s, %s, %s, %s, %.3f, %s, %d\n", podTime.node,
+				podTime.pod, podTime.addTime, podTime.sandboxCreatedTime, podTime.containerStartTime, podTime.runningTime,
+				getKubeletLatencyOutput(podTime.addTime, podTime.sandboxCreatedTime),
+				getKubeletLatencyOutput(podTime.sandboxCreateTime, podTime.sandboxCreatedTime),
+				getKubeletLatencyOutput(podTime.sandboxCreatedTime, podTime.containerStartTime),
+				log_util.GetDurationInMilliSecond(podTime.imagePullDuration),
+				getKubeletLatencyOutput(podTime.addTime, podTime.runningTime), podTime.probeFailures))
+		}
+	}
+}
+
+func writeKubeletNodeLatency(nodeFilename string, nodes map[string]*kubeletNodeStats) {
+	nodeFileHandler, err := os.Create(nodeFilename)
+	if err != nil {
+		fmt.Printf("Error create node latency file [%s]: %v\n", nodeFilename, err)
+		panic(err)
+	}
+	defer nodeFileHandler.Close()
+
+	nodeFileHandler.WriteString("node, pods, add_to_running_p50_ms, add_to_running_p99_ms, add_to_running_max_ms, " +
+		"add_to_sandbox_p50_ms, add_to_sandbox_p99_ms, image_pulls, image_pull_ms, probe_failures, " +
+		"pleg_relists, pleg_relist_max_ms, pleg_unhealthy, pleg_unhealthy_max_ms\n")
+	for _, nodeStats := range getSortedKubeletNodeStats(nodes) {
+		addToRunning := make([]time.Duration, 0, len(nodeStats.pods))
+		addToSandbox := make([]time.Duration, 0, len(nodeStats.pods))
+		for _, podTime := range nodeStats.pods {
+			if duration, isOK := getKubeletLatency(podTime.addTime, podTime.runningTime); isOK {
+				addToRunning = append(addToRunning, duration)
+			}
+			if duration, isOK := getKubeletLatency(podTime.addTime, podTime.sandboxCreatedTime); isOK {
+				addToSandbox = append(addToSandbox, duration)
+			}
+		}
+		log_util.SortDurations(addToRunning)
+		log_util.SortDurations(addToSandbox)
+		log_util.SortDurations(nodeStats.plegRelists)
+		log_util.SortDurations(nodeStats.plegUnhealthy)
+
+		nodeFileHandler.WriteString(fmt.Sprintf("%s, %d, %.3f, %.3f, %.3f, %.3f, %.3f, %d, %.3f, %d, %d, %.3f, %d, %.3f\n",
+			nodeStats.node, len(nodeStats.pods),
+			getPercentileInMilliSecond(addToRunning, 50), getPercentileInMilliSecond(addToRunning, 99),
+			getPercentileInMilliSecond(addToRunning, 100),
+			getPercentileInMilliSecond(addToSandbox, 50), getPercentileInMilliSecond(addToSandbox, 99),
+			nodeStats.imagePulls, log_util.GetDurationInMilliSecond(nodeStats.imagePullElapsed), nodeStats.probeFailures,
+			len(nodeStats.plegRelists), getPercentileInMilliSecond(nodeStats.plegRelists, 100),
+			len(nodeStats.plegUnhealthy), getPercentileInMilliSecond(nodeStats.plegUnhealthy, 100)))
+	}
+}
+
+// hollow-node-1-btv5d.log -> hollow-node-1-btv5d, input file if the line has no log file prefix
+func getKubeletNodeName(source, inputFile string) string {
+	if source == "" {
+		source = inputFile
+	}
+	source = path.Base(source)
+	if index := strings.Index(source, ".log"); index > 0 {
+		source = source[:index]
+	}
+	return source
+}
+
+func getKubeletNodeStats(nodes map[string]*kubeletNodeStats, node string) *kubeletNodeStats {
+	nodeStats, isOK := nodes[node]
+	if !isOK {
+		nodeStats = &kubeletNodeStats{node: node}
+		nodes[node] = nodeStats
+	}
+	return nodeStats
+}
+
+// Pod is tracked on the node it was first seen on
+func getKubeletPodTime(pods map[string]*kubeletPodTime, nodeStats *kubeletNodeStats, pod string) *kubeletPodTime {
+	podTime, isOK := pods[pod]
+	if !isOK {
+		podTime = &kubeletPodTime{node: nodeStats.node, pod: pod}
+		pods[pod] = podTime
+		nodeStats.pods = append(nodeStats.pods, podTime)
+	}
+	return podTime
+}
+
+func getSortedKubeletNodeStats(nodes map[string]*kubeletNodeStats) []*kubeletNodeStats {
+	result := make([]*kubeletNodeStats, 0, len(nodes))
+	for _, nodeStats := range nodes {
+		sort.Slice(nodeStats.pods, func(i, j int) bool {
+			return nodeStats.pods[i].pod < nodeStats.pods[j].pod
+		})
+		result = append(result, nodeStats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].node < result[j].node
+	})
+	return result
+}
+
+func getKubeletLatency(startTime, endTime string) (time.Duration, bool) {
+	if startTime == "" || endTime == "" {
+		return 0, false
+	}
+	duration, err := log_util.GetTimeDiff(startTime, endTime)
+	if err != nil {
+		fmt.Printf("Error getting time diff of [%s] and [%s]. error [%v]\n", startTime, endTime, err)
+		return 0, false
+	}
+	return duration, true
+}
+
+// Empty if either time is missing
+func getKubeletLatencyOutput(startTime, endTime string) string {
+	duration, isOK := getKubeletLatency(startTime, endTime)
+	if !isOK {
+		return ""
+	}
+	return fmt.Sprintf("%.3f", log_util.GetDurationInMilliSecond(duration))
+}
+
+// 0 if there are no durations, percentile 100 is the max
+func getPercentileInMilliSecond(sortedDurations []time.Duration, percentile float64) float64 {
+	return log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(sortedDurations, percentile))
+}
@@ -0,0 +1,37 @@
+package kubelet_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_getKubeletNodeName(t *testing.T) {
+	assert.Equal(t, "hollow-node-1-btv5d", getKubeletNodeName("hollow-node-1-btv5d.log", "kubelet.log"))
+	assert.Equal(t, "hollow-node-1-btv5d", getKubeletNodeName("logs/hollow-node-1-btv5d.log.1", "kubelet.log"))
+	assert.Equal(t, "kubelet", getKubeletNodeName("", "kubelet.log"))
+}
+
+func Test_addKubeletPodEvent(t *testing.T) {
+	podTime := &kubeletPodTime{}
+	addKubeletPodEvent(podTime, &KubeletEvent{LogTime: "22:32:36.300000", EventType: KubeletSyncLoopAdd})
+	addKubeletPodEvent(podTime, &KubeletEvent{LogTime: "22:32:40.000000", EventType: KubeletSyncLoopAdd})
+	addKubeletPodEvent(podTime, &KubeletEvent{LogTime: "22:32:36.400000", EventType: KubeletImagePulled, Duration: time.Second})
+	addKubeletPodEvent(podTime, &KubeletEvent{LogTime: "22:32:36.500000", EventType: KubeletImagePulled, Duration: time.Second})
+	addKubeletPodEvent(podTime, &KubeletEvent{LogTime: "22:32:37.200000", EventType: KubeletPodRunning})
+	addKubeletPodEvent(podTime, &KubeletEvent{LogTime: "22:32:38.000000", EventType: KubeletProbeFailed})
+
+	assert.Equal(t, "22:32:36.300000", podTime.addTime)
+	assert.Equal(t, "22:32:37.200000", podTime.runningTime)
+	assert.Equal(t, 2*time.Second, podTime.imagePullDuration)
+	assert.Equal(t, 1, podTime.probeFailures)
+	assert.Equal(t, "900.000", getKubeletLatencyOutput(podTime.addTime, podTime.runningTime))
+	assert.Equal(t, "", getKubeletLatencyOutput(podTime.addTime, podTime.containerStartTime))
+}
+
+func Test_getPercentileInMilliSecond(t *testing.T) {
+	assert.Equal(t, 0.0, getPercentileInMilliSecond([]time.Duration{}, 50))
+	durations := []time.Duration{time.Millisecond, 2 * time.Millisecond, 10 * time.Millisecond}
+	assert.Equal(t, 2.0, getPercentileInMilliSecond(durations, 50))
+	assert.Equal(t, 10.0, getPercentileInMilliSecond(durations, 100))
+}
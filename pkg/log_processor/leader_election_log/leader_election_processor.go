@@ -0,0 +1,380 @@
+package leader_election_log
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"tools/pkg/log_util"
+)
+
+type leaderEventType string
+
+const (
+	leaderAttempting  leaderEventType = "attempting"
+	leaderAcquired    leaderEventType = "acquired"
+	leaderRenewFailed leaderEventType = "renew_failed"
+	leaderLost        leaderEventType = "lost"
+)
+
+// Leader changes within the window more than the limit are flagged as flapping
+const (
+	leaderFlappingWindow = 5 * time.Minute
+	leaderFlappingLimit  = 3
+)
+
+// Node lease updates before each leader event are summed over the default renew deadline of kube-controller-manager and
+// scheduler
+const leaseUpdateWindow = 10 * time.Second
+
+// Klog has date without year, lease update audit log is aligned at the same year
+const leaderLogTimeLayout = "0102 15:04:05.000000"
+
+// 2021-04-09T23:36:27 of ExtractLeaseUpdateAuditLog output
+const leaseUpdateTimeLayout = "2006-01-02T15:04:05"
+
+type leaderEvent struct {
+	logTime   time.Time
+	instance  string // replica of the component, named after the relative path of its log file
+	lease     string // kube-system/kube-controller-manager
+	eventType leaderEventType
+	detail    string
+}
+
+type leaderGap struct {
+	lease          string
+	startTime      time.Time
+	endTime        time.Time
+	previousLeader string // empty for the first election
+	nextLeader     string // empty if there is no leader at the end of the logs
+}
+
+type leaseLeaderStats struct {
+	lease        string
+	leader       string
+	everLed      bool
+	gap          *leaderGap
+	gaps         []*leaderGap
+	acquireTimes []time.Time // times the leader changed
+	leaders      map[string]bool
+}
+
+// attempting to acquire leader lease  kube-system/kube-controller-manager...
+// successfully acquired lease kube-system/kube-controller-manager
+// failed to renew lease kube-system/kube-controller-manager: timed out waiting for the condition
+// leaderelection lost
+var leaderEventMatchers = []struct {
+	regex     *regexp.Regexp
+	eventType leaderEventType
+}{
+	{regexp.MustCompile(`(?i)^attempting to acquire leader lease(?:\s+(\S+))?`), leaderAttempting},
+	{regexp.MustCompile(`(?i)^successfully acquired lease(?:\s+(\S+))?`), leaderAcquired},
+	{regexp.MustCompile(`(?i)^failed to renew lease(?:\s+([^\s:]+))?:?\s*(.*)`), leaderRenewFailed},
+	{regexp.MustCompile(`(?i)^leaderelection lost`), leaderLost},
+}
+
+// Leadership of each lease over time from the logs of all replicas of kube-controller-manager, scheduler or any
+// component using client-go leader election, e.g. kcm-1.log, kcm-2.log, or master-1/kube-controller-manager.log,
+// master-2/kube-controller-manager.log. Each log file is one replica.
+// Node lease updates per second from leaseUpdateFile, output of ExtractLeaseUpdateAuditLog, are lined up with the leader
+// events. These are updates of hollow-node leases in kube-node-lease that show the apiserver load around the events,
+// not the renewals of the lease of the component. Node lease update columns are empty if leaseUpdateFile is not set.
+// Outputs in pathToFind:
+// leader.election.timeline.output: leader events of all replicas in time order, with the leader after each event
+// leader.election.gaps.output: periods without leader of each lease
+// leader.election.summary.output: leader changes, gaps and flapping per lease
+func ExtractLeaderElection(pathToFind string, logFiles []string, leaseUpdateFile string) {
+	timelineFilename := path.Join(pathToFind, "leader.election.timeline.output")
+	gapsFilename := path.Join(pathToFind, "leader.election.gaps.output")
+	summaryFilename := path.Join(pathToFind, "leader.election.summary.output")
+
+	events := make([]*leaderEvent, 0)
+	for _, logFile := range logFiles {
+		events = append(events, readLeaderEvents(path.Join(pathToFind, logFile), getLeaderInstanceName(logFile))...)
+	}
+	sortLeaderEvents(events)
+
+	var leaseUpdates map[time.Time]int
+	if leaseUpdateFile != "" {
+		leaseUpdates = readLeaseUpdates(path.Join(pathToFind, leaseUpdateFile))
+	}
+
+	timelineFileHandler, err := os.Create(timelineFilename)
+	if err != nil {
+		fmt.Printf("Error create leader election timeline file [%s]: %v\n", timelineFilename, err)
+		panic(err)
+	}
+	defer timelineFileHandler.Close()
+
+	gapsFileHandler, err := os.Create(gapsFilename)
+	if err != nil {
+		fmt.Printf("Error create leader election gaps file [%s]: %v\n", gapsFilename, err)
+		panic(err)
+	}
+	defer gapsFileHandler.Close()
+
+	summaryFileHandler, err := os.Create(summaryFilename)
+	if err != nil {
+		fmt.Printf("Error create leader election summary file [%s]: %v\n", summaryFilename, err)
+		panic(err)
+	}
+	defer summaryFileHandler.Close()
+
+	leases := make(map[string]*leaseLeaderStats)
+	timelineFileHandler.WriteString(fmt.Sprintf("datetime, lease, instance, event, leader, node_lease_updates, node_lease_updates_%ds, detail\n",
+		int(leaseUpdateWindow/time.Second)))
+	for _, event := range events {
+		stats := getLeaseLeaderStats(leases, event.lease)
+		addLeaderEvent(stats, event)
+
+		updates, updatesInWindow := "", ""
+		if leaseUpdates != nil {
+			second := event.logTime.Truncate(time.Second)
+			updates = strconv.Itoa(leaseUpdates[second])
+			updatesInWindow = strconv.Itoa(getLeaseUpdateCount(leaseUpdates, second.Add(-leaseUpdateWindow+time.Second), second))
+		}
+		timelineFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %s, %s, %s, %s, %s\n", event.logTime.Format(leaderLogTimeLayout),
+			event.lease, event.instance, event.eventType, stats.leader, updates, updatesInWindow, event.detail))
+	}
+
+	lastTime := time.Time{}
+	if len(events) > 0 {
+		lastTime = events[len(events)-1].logTime
+	}
+	leaseNames := make([]string, 0, len(leases))
+	for lease := range leases {
+		leaseNames = append(leaseNames, lease)
+	}
+	sort.Strings(leaseNames)
+
+	gapsFileHandler.WriteString("lease, start, end, duration_ms, previous_leader, next_leader, node_lease_updates\n")
+	summaryFileHandler.WriteString(fmt.Sprintf("lease, leader, leader_changes, leaders, gaps, total_gap_ms, max_gap_ms, max_changes_in_%dm, flapping\n",
+		int(leaderFlappingWindow/time.Minute)))
+	for _, lease := range leaseNames {
+		stats := leases[lease]
+		// no leader at the end of the logs
+		if stats.gap != nil {
+			stats.gap.endTime = lastTime
+			stats.gaps = append(stats.gaps, stats.gap)
+			stats.gap = nil
+		}
+
+		totalGap := time.Duration(0)
+		maxGap := time.Duration(0)
+		for _, gap := range stats.gaps {
+			duration := gap.endTime.Sub(gap.startTime)
+			totalGap += duration
+			if duration > maxGap {
+				maxGap = duration
+			}
+			updates := ""
+			if leaseUpdates != nil {
+				updates = strconv.Itoa(getLeaseUpdateCount(leaseUpdates, gap.startTime.Truncate(time.Second), gap.endTime.Truncate(time.Second)))
+			}
+			gapsFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %.3f, %s, %s, %s\n", lease, gap.startTime.Format(leaderLogTimeLayout),
+				gap.endTime.Format(leaderLogTimeLayout), log_util.GetDurationInMilliSecond(duration), gap.previousLeader,
+				gap.nextLeader, updates))
+		}
+
+		maxChanges := getMaxLeaderChangesInWindow(stats.acquireTimes, leaderFlappingWindow)
+		summaryFileHandler.WriteString(fmt.Sprintf("%s, %s, %d, %d, %d, %.3f, %.3f, %d, %t\n", lease, stats.leader,
+			len(stats.acquireTimes), len(stats.leaders), len(stats.gaps), log_util.GetDurationInMilliSecond(totalGap),
+			log_util.GetDurationInMilliSecond(maxGap), maxChanges, maxChanges > leaderFlappingLimit))
+	}
+	fmt.Printf("Leader events %d from %d logs, leases %d\n", len(events), len(logFiles), len(leases))
+}
+
+// kcm-1.log -> kcm-1, master-1/kube-controller-manager.log -> master-1/kube-controller-manager
+func getLeaderInstanceName(logFile string) string {
+	dir, file := path.Split(path.Clean(logFile))
+	if index := strings.Index(file, ".log"); index > 0 {
+		file = file[:index]
+	}
+	return dir + file
+}
+
+func readLeaderEvents(inputFilename string, instance string) []*leaderEvent {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	events := make([]*leaderEvent, 0)
+	lease := "" // leaderelection lost has no lease, it is the lease the instance tried to acquire
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			break
+		}
+
+		event, isOK := parseLeaderEventLine(line)
+		if !isOK {
+			continue
+		}
+		if event.lease == "" {
+			event.lease = lease
+		}
+		lease = event.lease
+		event.instance = instance
+		events = append(events, event)
+	}
+	return events
+}
+
+// Parse leader election line in legacy or structured format, e.g.
+// I0409 23:36:27.556371       1 leaderelection.go:242] attempting to acquire leader lease  kube-system/kube-controller-manager...
+// I1012 10:00:00.000000       1 leaderelection.go:258] "Successfully acquired lease" lease="kube-system/kube-scheduler"
+func parseLeaderEventLine(line string) (*leaderEvent, bool) {
+	klogLine, isOK := log_util.ParseKlogLine(line)
+	if !isOK {
+		return nil, false
+	}
+	logTime, err := time.Parse(leaderLogTimeLayout, klogLine.Date+" "+klogLine.Time)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, matcher := range leaderEventMatchers {
+		matches := matcher.regex.FindStringSubmatch(klogLine.Message)
+		if matches == nil {
+			continue
+		}
+		event := &leaderEvent{logTime: logTime, eventType: matcher.eventType}
+		if len(matches) > 1 {
+			event.lease = strings.TrimRight(matches[1], ".")
+		}
+		if len(matches) > 2 {
+			event.detail = matches[2]
+		}
+		if lease, isOK := klogLine.Values["lease"]; isOK {
+			event.lease = lease
+		}
+		if detail, isOK := klogLine.Values["err"]; isOK {
+			event.detail = detail
+		}
+		event.detail = strings.ReplaceAll(event.detail, ",", ";")
+		return event, true
+	}
+	return nil, false
+}
+
+// By time, then by instance so that events of the same time are in a stable order
+func sortLeaderEvents(events []*leaderEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].logTime.Equal(events[j].logTime) {
+			return events[i].logTime.Before(events[j].logTime)
+		}
+		return events[i].instance < events[j].instance
+	})
+}
+
+func getLeaseLeaderStats(leases map[string]*leaseLeaderStats, lease string) *leaseLeaderStats {
+	stats, isOK := leases[lease]
+	if !isOK {
+		stats = &leaseLeaderStats{lease: lease, leaders: make(map[string]bool)}
+		leases[lease] = stats
+	}
+	return stats
+}
+
+// Gap starts when the leader fails to renew or loses the lease, or at the first attempt before any leader,
+// and ends when any replica acquires the lease
+func addLeaderEvent(stats *leaseLeaderStats, event *leaderEvent) {
+	switch event.eventType {
+	case leaderAttempting:
+		if !stats.everLed && stats.gap == nil {
+			stats.gap = &leaderGap{lease: stats.lease, startTime: event.logTime}
+		}
+	case leaderAcquired:
+		if stats.gap != nil {
+			stats.gap.endTime = event.logTime
+			stats.gap.nextLeader = event.instance
+			stats.gaps = append(stats.gaps, stats.gap)
+			stats.gap = nil
+		}
+		if stats.leader != event.instance {
+			stats.acquireTimes = append(stats.acquireTimes, event.logTime)
+		}
+		stats.leader = event.instance
+		stats.leaders[event.instance] = true
+		stats.everLed = true
+	case leaderRenewFailed, leaderLost:
+		if stats.leader == event.instance {
+			stats.leader = ""
+			stats.gap = &leaderGap{lease: stats.lease, startTime: event.logTime, previousLeader: event.instance}
+		}
+	}
+}
+
+// Most leader changes in any window, of sorted change times
+func getMaxLeaderChangesInWindow(changeTimes []time.Time, window time.Duration) int {
+	maxChanges := 0
+	start := 0
+	for end := range changeTimes {
+		for changeTimes[end].Sub(changeTimes[start]) >= window {
+			start++
+		}
+		if end-start+1 > maxChanges {
+			maxChanges = end - start + 1
+		}
+	}
+	return maxChanges
+}
+
+// Node lease updates per second from ExtractLeaseUpdateAuditLog, lines are not sorted:
+// datetime, count
+// 2021-04-09T23:36:27, 120
+func readLeaseUpdates(inputFilename string) map[time.Time]int {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open lease update file [%s]: %v\n", inputFilename, err)
+		return nil
+	}
+	defer inputFileHandler.Close()
+
+	leaseUpdates := make(map[time.Time]int)
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			break
+		}
+
+		fields := strings.Split(strings.TrimSpace(line), ", ")
+		if len(fields) != 2 {
+			continue
+		}
+		updateTime, err := time.Parse(leaseUpdateTimeLayout, fields[0])
+		if err != nil {
+			// header
+			continue
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil {
+			fmt.Printf("Error parsing lease update count [%s]: %v\n", line, err)
+			continue
+		}
+		// same year as klog time
+		updateTime = time.Date(0, updateTime.Month(), updateTime.Day(), updateTime.Hour(), updateTime.Minute(),
+			updateTime.Second(), 0, time.UTC)
+		leaseUpdates[updateTime] += count
+	}
+	return leaseUpdates
+}
+
+// Lease updates from start to end seconds, both included
+func getLeaseUpdateCount(leaseUpdates map[time.Time]int, start, end time.Time) int {
+	count := 0
+	for second := start; !second.After(end); second = second.Add(time.Second) {
+		count += leaseUpdates[second]
+	}
+	return count
+}
@@ -0,0 +1,146 @@
+package leader_election_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_parseLeaderEventLine(t *testing.T) {
+	testCases := []struct {
+		line      string
+		lease     string
+		eventType leaderEventType
+		detail    string
+	}{
+		{"I0409 23:36:27.556371       1 leaderelection.go:242] attempting to acquire leader lease  kube-system/kube-controller-manager...", "kube-system/kube-controller-manager", leaderAttempting, ""},
+		{"I0409 23:36:27.556371       1 leaderelection.go:252] successfully acquired lease kube-system/kube-controller-manager", "kube-system/kube-controller-manager", leaderAcquired, ""},
+		{"E0409 23:36:27.556371       1 leaderelection.go:288] failed to renew lease kube-system/kube-scheduler: timed out waiting for the condition, retrying", "kube-system/kube-scheduler", leaderRenewFailed, "timed out waiting for the condition; retrying"},
+		{"F0409 23:36:27.556371       1 controllermanager.go:279] leaderelection lost", "", leaderLost, ""},
+		{"I1012 10:00:00.000000       1 leaderelection.go:248] \"Attempting to acquire leader lease\" lease=\"kube-system/kube-scheduler\"", "kube-system/kube-scheduler", leaderAttempting, ""},
+		{"I1012 10:00:00.000000       1 leaderelection.go:258] \"Successfully acquired lease\" lease=\"kube-system/kube-scheduler\"", "kube-system/kube-scheduler", leaderAcquired, ""},
+	}
+	for _, testCase := range testCases {
+		event, isOK := parseLeaderEventLine(testCase.line)
+		assert.True(t, isOK, testCase.line)
+		if !isOK {
+			continue
+		}
+		assert.Equal(t, testCase.lease, event.lease, testCase.line)
+		assert.Equal(t, testCase.eventType, event.eventType, testCase.line)
+		assert.Equal(t, testCase.detail, event.detail, testCase.line)
+	}
+
+	event, _ := parseLeaderEventLine(testCases[0].line)
+	assert.Equal(t, "0409 23:36:27.556371", event.logTime.Format(leaderLogTimeLayout))
+
+	_, isOK := parseLeaderEventLine("I0409 23:36:27.556371       1 leaderelection.go:352] lock is held by kcm-1 and has not yet expired")
+	assert.False(t, isOK)
+}
+
+func Test_addLeaderEvent(t *testing.T) {
+	startTime := time.Date(0, 4, 9, 23, 36, 0, 0, time.UTC)
+	stats := getLeaseLeaderStats(make(map[string]*leaseLeaderStats), "kube-system/kube-controller-manager")
+	addLeaderEvent(stats, &leaderEvent{logTime: startTime, instance: "kcm-1", eventType: leaderAttempting})
+	addLeaderEvent(stats, &leaderEvent{logTime: startTime, instance: "kcm-2", eventType: leaderAttempting})
+	addLeaderEvent(stats, &leaderEvent{logTime: startTime.Add(2 * time.Second), instance: "kcm-1", eventType: leaderAcquired})
+	// renew failure of other replica does not change leader
+	addLeaderEvent(stats, &leaderEvent{logTime: startTime.Add(3 * time.Second), instance: "kcm-2", eventType: leaderRenewFailed})
+	assert.Equal(t, "kcm-1", stats.leader)
+	addLeaderEvent(stats, &leaderEvent{logTime: startTime.Add(10 * time.Second), instance: "kcm-1", eventType: leaderRenewFailed})
+	addLeaderEvent(stats, &leaderEvent{logTime: startTime.Add(10 * time.Second), instance: "kcm-1", eventType: leaderLost})
+	assert.Equal(t, "", stats.leader)
+	addLeaderEvent(stats, &leaderEvent{logTime: startTime.Add(25 * time.Second), instance: "kcm-2", eventType: leaderAcquired})
+
+	assert.Equal(t, "kcm-2", stats.leader)
+	assert.Equal(t, 2, len(stats.acquireTimes))
+	assert.Equal(t, 2, len(stats.gaps))
+	assert.Equal(t, &leaderGap{lease: stats.lease, startTime: startTime, endTime: startTime.Add(2 * time.Second), nextLeader: "kcm-1"}, stats.gaps[0])
+	assert.Equal(t, &leaderGap{lease: stats.lease, startTime: startTime.Add(10 * time.Second), endTime: startTime.Add(25 * time.Second),
+		previousLeader: "kcm-1", nextLeader: "kcm-2"}, stats.gaps[1])
+}
+
+func Test_getMaxLeaderChangesInWindow(t *testing.T) {
+	startTime := time.Date(0, 4, 9, 23, 0, 0, 0, time.UTC)
+	changeTimes := []time.Time{startTime, startTime.Add(time.Minute), startTime.Add(10 * time.Minute),
+		startTime.Add(11 * time.Minute), startTime.Add(12 * time.Minute), startTime.Add(15 * time.Minute)}
+	assert.Equal(t, 3, getMaxLeaderChangesInWindow(changeTimes, 5*time.Minute))
+	assert.Equal(t, 6, getMaxLeaderChangesInWindow(changeTimes, time.Hour))
+	assert.Equal(t, 0, getMaxLeaderChangesInWindow(nil, time.Hour))
+}
+
+func Test_getLeaderInstanceName(t *testing.T) {
+	assert.Equal(t, "kcm-1", getLeaderInstanceName("kcm-1.log"))
+	assert.Equal(t, "master-1/kube-controller-manager", getLeaderInstanceName("master-1/kube-controller-manager.log"))
+	assert.Equal(t, "master-2/kube-controller-manager", getLeaderInstanceName("./master-2/kube-controller-manager.log-20210409"))
+}
+
+func Test_ExtractLeaderElection(t *testing.T) {
+	dir, err := os.MkdirTemp("", "leader")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeLines := func(filename string, lines ...string) {
+		assert.Nil(t, os.WriteFile(path.Join(dir, filename), []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	}
+	writeLines("kcm-1.log",
+		"I0409 23:36:00.000000       1 leaderelection.go:242] attempting to acquire leader lease  kube-system/kube-controller-manager...",
+		"I0409 23:36:01.000000       1 leaderelection.go:252] successfully acquired lease kube-system/kube-controller-manager",
+		"E0409 23:36:20.500000       1 leaderelection.go:288] failed to renew lease kube-system/kube-controller-manager: timed out waiting for the condition",
+		"F0409 23:36:20.500001       1 controllermanager.go:279] leaderelection lost")
+	writeLines("kcm-2.log",
+		"I0409 23:36:00.500000       1 leaderelection.go:242] attempting to acquire leader lease  kube-system/kube-controller-manager...",
+		"I0409 23:36:30.000000       1 leaderelection.go:252] successfully acquired lease kube-system/kube-controller-manager")
+	writeLines("lease-update-audit.log",
+		"datetime, count",
+		"2021-04-09T23:36:25, 3",
+		"2021-04-09T23:36:12, 100",
+		"2021-04-09T23:36:20, 1")
+	ExtractLeaderElection(dir, []string{"kcm-1.log", "kcm-2.log"}, "lease-update-audit.log")
+
+	timeline, err := os.ReadFile(path.Join(dir, "leader.election.timeline.output"))
+	assert.Nil(t, err)
+	assert.Equal(t, "datetime, lease, instance, event, leader, node_lease_updates, node_lease_updates_10s, detail\n"+
+		"0409 23:36:00.000000, kube-system/kube-controller-manager, kcm-1, attempting, , 0, 0, \n"+
+		"0409 23:36:00.500000, kube-system/kube-controller-manager, kcm-2, attempting, , 0, 0, \n"+
+		"0409 23:36:01.000000, kube-system/kube-controller-manager, kcm-1, acquired, kcm-1, 0, 0, \n"+
+		"0409 23:36:20.500000, kube-system/kube-controller-manager, kcm-1, renew_failed, , 1, 101, timed out waiting for the condition\n"+
+		"0409 23:36:20.500001, kube-system/kube-controller-manager, kcm-1, lost, , 1, 101, \n"+
+		"0409 23:36:30.000000, kube-system/kube-controller-manager, kcm-2, acquired, kcm-2, 0, 3, \n", string(timeline))
+
+	gaps, err := os.ReadFile(path.Join(dir, "leader.election.gaps.output"))
+	assert.Nil(t, err)
+	assert.Equal(t, "lease, start, end, duration_ms, previous_leader, next_leader, node_lease_updates\n"+
+		"kube-system/kube-controller-manager, 0409 23:36:00.000000, 0409 23:36:01.000000, 1000.000, , kcm-1, 0\n"+
+		"kube-system/kube-controller-manager, 0409 23:36:20.500000, 0409 23:36:30.000000, 9500.000, kcm-1, kcm-2, 4\n", string(gaps))
+
+	summary, err := os.ReadFile(path.Join(dir, "leader.election.summary.output"))
+	assert.Nil(t, err)
+	assert.Equal(t, "lease, leader, leader_changes, leaders, gaps, total_gap_ms, max_gap_ms, max_changes_in_5m, flapping\n"+
+		"kube-system/kube-controller-manager, kcm-2, 2, 2, 2, 10500.000, 9500.000, 2, false\n", string(summary))
+}
+
+func Test_ExtractLeaderElection_sameFileName(t *testing.T) {
+	dir, err := os.MkdirTemp("", "leader")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeLines := func(filename string, lines ...string) {
+		assert.Nil(t, os.MkdirAll(path.Dir(path.Join(dir, filename)), 0755))
+		assert.Nil(t, os.WriteFile(path.Join(dir, filename), []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	}
+	writeLines("master-1/kube-controller-manager.log",
+		"I0409 23:36:01.000000       1 leaderelection.go:252] successfully acquired lease kube-system/kube-controller-manager",
+		"F0409 23:36:20.000000       1 controllermanager.go:279] leaderelection lost")
+	writeLines("master-2/kube-controller-manager.log",
+		"I0409 23:36:30.000000       1 leaderelection.go:252] successfully acquired lease kube-system/kube-controller-manager")
+	ExtractLeaderElection(dir, []string{"master-1/kube-controller-manager.log", "master-2/kube-controller-manager.log"}, "")
+
+	summary, err := os.ReadFile(path.Join(dir, "leader.election.summary.output"))
+	assert.Nil(t, err)
+	assert.Equal(t, "lease, leader, leader_changes, leaders, gaps, total_gap_ms, max_gap_ms, max_changes_in_5m, flapping\n"+
+		"kube-system/kube-controller-manager, master-2/kube-controller-manager, 2, 2, 1, 10000.000, 10000.000, 2, false\n", string(summary))
+}
@@ -0,0 +1,141 @@
+package run_analysis
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	onlyInRunA = "run_a"
+	onlyInRunB = "run_b"
+)
+
+type comparisonRow struct {
+	key     string
+	valuesA []float64
+	valuesB []float64
+	onlyIn  string // empty if key is in both runs
+}
+
+// Compare the etcd key count/latency tables, audit counts and scheduler buckets of two runs,
+// for example arktos-analysis/load-3.4.4 and k8s-analysis/load-3.4.4
+func CompareRuns(runPathA, runPathB, outputPath string) {
+	metricsA := loadRunMetrics(runPathA)
+	metricsB := loadRunMetrics(runPathB)
+	fmt.Printf("Comparing run a [%s] with run b [%s]\n", runPathA, runPathB)
+
+	compareTable(path.Join(outputPath, "compare.etcd.range.output"), "key", etcdKeyCountMetrics,
+		metricsA.etcdRangeKeys, metricsB.etcdRangeKeys)
+	compareTable(path.Join(outputPath, "compare.etcd.norange.output"), "key", etcdKeyCountMetrics,
+		metricsA.etcdNonRangeKeys, metricsB.etcdNonRangeKeys)
+	compareTable(path.Join(outputPath, "compare.audit.output"), "uri:verb:response_code", countMetrics,
+		metricsA.auditCounts, metricsB.auditCounts)
+	compareTable(path.Join(outputPath, "compare.scheduler.output"), "bucket", countMetrics,
+		metricsA.schedulerBuckets, metricsB.schedulerBuckets)
+}
+
+func compareTable(outputFilename, keyName string, metricNames []string, tableA, tableB map[string][]float64) {
+	outputFileHandler, err := os.Create(outputFilename)
+	if err != nil {
+		fmt.Printf("Error open output file [%s]: %v\n", outputFilename, err)
+		return
+	}
+	defer outputFileHandler.Close()
+
+	outputFileHandler.WriteString(getComparisonHeader(keyName, metricNames))
+	rows := getComparisonRows(tableA, tableB)
+	onlyInA := 0
+	onlyInB := 0
+	for _, row := range rows {
+		outputFileHandler.WriteString(getComparisonOutputLine(row, len(metricNames)))
+		switch row.onlyIn {
+		case onlyInRunA:
+			onlyInA++
+		case onlyInRunB:
+			onlyInB++
+		}
+	}
+	fmt.Printf("Comparison file [%s] generated. Total %d rows, only in run a %d, only in run b %d\n",
+		outputFilename, len(rows), onlyInA, onlyInB)
+}
+
+// Rows sorted by key. Keys only in one run have empty values for the other run.
+func getComparisonRows(tableA, tableB map[string][]float64) []*comparisonRow {
+	rows := make([]*comparisonRow, 0, len(tableA))
+	for key, valuesA := range tableA {
+		row := &comparisonRow{
+			key:     key,
+			valuesA: valuesA,
+		}
+		if valuesB, isOK := tableB[key]; isOK {
+			row.valuesB = valuesB
+		} else {
+			row.onlyIn = onlyInRunA
+		}
+		rows = append(rows, row)
+	}
+	for key, valuesB := range tableB {
+		if _, isOK := tableA[key]; !isOK {
+			rows = append(rows, &comparisonRow{
+				key:     key,
+				valuesB: valuesB,
+				onlyIn:  onlyInRunB,
+			})
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].key < rows[j].key
+	})
+	return rows
+}
+
+func getComparisonHeader(keyName string, metricNames []string) string {
+	header := keyName
+	for _, metric := range metricNames {
+		header += fmt.Sprintf(", %s_a, %s_b, %s_delta, %s_ratio", metric, metric, metric, metric)
+	}
+	return header + ", only_in\n"
+}
+
+// delta is b - a, ratio is b / a
+func getComparisonOutputLine(row *comparisonRow, metricCount int) string {
+	columns := []string{row.key}
+	for i := 0; i < metricCount; i++ {
+		var a, b float64
+		hasA := i < len(row.valuesA)
+		hasB := i < len(row.valuesB)
+		if hasA {
+			a = row.valuesA[i]
+		}
+		if hasB {
+			b = row.valuesB[i]
+		}
+
+		columns = append(columns, formatValue(a, hasA), formatValue(b, hasB))
+		if hasA && hasB {
+			columns = append(columns, formatValue(b-a, true), formatRatio(a, b))
+		} else {
+			columns = append(columns, "", "")
+		}
+	}
+	columns = append(columns, row.onlyIn)
+	return strings.Join(columns, ", ") + "\n"
+}
+
+func formatValue(value float64, hasValue bool) string {
+	if !hasValue {
+		return ""
+	}
+	return fmt.Sprintf("%g", value)
+}
+
+func formatRatio(a, b float64) string {
+	if a == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.3f", b/a)
+}
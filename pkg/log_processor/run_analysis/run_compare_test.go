@@ -0,0 +1,38 @@
+package run_analysis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_getComparisonRows(t *testing.T) {
+	tableA := map[string][]float64{
+		"/registry/pods\\":    {10, 2000, 5000},
+		"/registry/minions\\": {4, 1000, 1000},
+	}
+	tableB := map[string][]float64{
+		"/registry/pods\\":   {20, 1000, 8000},
+		"/registry/leases\\": {7, 300, 900},
+	}
+
+	rows := getComparisonRows(tableA, tableB)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, "/registry/leases\\", rows[0].key)
+	assert.Equal(t, onlyInRunB, rows[0].onlyIn)
+	assert.Equal(t, "/registry/minions\\", rows[1].key)
+	assert.Equal(t, onlyInRunA, rows[1].onlyIn)
+	assert.Equal(t, "/registry/pods\\", rows[2].key)
+	assert.Equal(t, "", rows[2].onlyIn)
+
+	assert.Equal(t, "/registry/leases\\, , 7, , , , 300, , , , 900, , , run_b\n", getComparisonOutputLine(rows[0], 3))
+	assert.Equal(t, "/registry/pods\\, 10, 20, 10, 2.000, 2000, 1000, -1000, 0.500, 5000, 8000, 3000, 1.600, \n", getComparisonOutputLine(rows[2], 3))
+}
+
+func Test_getComparisonHeader(t *testing.T) {
+	assert.Equal(t, "bucket, count_a, count_b, count_delta, count_ratio, only_in\n", getComparisonHeader("bucket", countMetrics))
+}
+
+func Test_formatRatio(t *testing.T) {
+	assert.Equal(t, "", formatRatio(0, 10))
+	assert.Equal(t, "0.250", formatRatio(4, 1))
+}
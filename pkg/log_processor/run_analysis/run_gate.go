@@ -0,0 +1,165 @@
+package run_analysis
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"tools/pkg/log_util"
+)
+
+type gateThreshold struct {
+	metric   string
+	maxValue float64
+}
+
+type gateViolation struct {
+	metric    string
+	value     float64
+	maxValue  float64
+	isMissing bool
+}
+
+// Evaluate derived metrics of a run against the baseline thresholds. Returns false if any threshold is violated.
+//
+// Threshold file sample:
+// # metric, max
+// scheduler.bound_duration.2-inf, 0
+// etcd.range.exceed_1s, 10
+// audit.list.p99_ms, 1000
+// trace.p99_ms, 2000
+func GateRun(runPath, thresholdFilename string) (bool, error) {
+	thresholds, err := readGateThresholds(thresholdFilename)
+	if err != nil {
+		return false, err
+	}
+
+	metrics := getRunGateMetrics(runPath)
+	violations := evaluateGate(metrics, thresholds)
+	fmt.Printf("Evaluated %d thresholds against run [%s], %d violations\n", len(thresholds), runPath, len(violations))
+	for _, violation := range violations {
+		fmt.Println(getViolationMessage(violation))
+	}
+	return len(violations) == 0, nil
+}
+
+func readGateThresholds(thresholdFilename string) ([]gateThreshold, error) {
+	thresholds := make([]gateThreshold, 0)
+	var parseErr error
+	readLines(thresholdFilename, func(fields []string) {
+		if strings.HasPrefix(fields[0], "#") || fields[0] == "metric" {
+			return
+		}
+		if len(fields) != 2 {
+			parseErr = errors.New(fmt.Sprintf("Invalid threshold line [%s]", strings.Join(fields, ", ")))
+			return
+		}
+		maxValue, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			parseErr = errors.New(fmt.Sprintf("Invalid threshold value [%s] for metric %s", fields[1], fields[0]))
+			return
+		}
+		thresholds = append(thresholds, gateThreshold{
+			metric:   fields[0],
+			maxValue: maxValue,
+		})
+	})
+
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if len(thresholds) == 0 {
+		return nil, errors.New(fmt.Sprintf("No threshold found in file [%s]", thresholdFilename))
+	}
+	return thresholds, nil
+}
+
+// Metric names:
+// scheduler.<case>.<bucket>, e.g. scheduler.bound_duration.<=1s
+// etcd.<range|norange>.exceed_<threshold>, e.g. etcd.range.exceed_100ms
+// audit.<verb>.<column>, e.g. audit.list.p99_ms
+// trace.<count|p50_ms|p90_ms|p99_ms|max_ms>
+func getRunGateMetrics(runPath string) map[string]float64 {
+	metrics := make(map[string]float64)
+
+	for bucket, values := range readSchedulerBucketFile(path.Join(runPath, schedulerBucketFile)) {
+		metrics["scheduler."+getMetricName(bucket)] = values[0]
+	}
+
+	for threshold, count := range readEtcdSlowCountFile(path.Join(runPath, etcdRangeSlowCountFile)) {
+		metrics["etcd.range.exceed_"+threshold] = count
+	}
+	for threshold, count := range readEtcdSlowCountFile(path.Join(runPath, etcdNonRangeSlowCountFile)) {
+		metrics["etcd.norange.exceed_"+threshold] = count
+	}
+
+	for verb, latencies := range readAuditLatencyFiles(runPath) {
+		for column, value := range latencies {
+			metrics[fmt.Sprintf("audit.%s.%s", verb, column)] = value
+		}
+	}
+
+	traceDurations := readTraceDurations(path.Join(runPath, traceCompactedFile))
+	if len(traceDurations) > 0 {
+		durations := make([]time.Duration, len(traceDurations))
+		for i, durationInMilliSec := range traceDurations {
+			durations[i] = time.Duration(durationInMilliSec * float64(time.Millisecond))
+		}
+		log_util.SortDurations(durations)
+		metrics["trace.count"] = float64(len(durations))
+		metrics["trace.p50_ms"] = log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(durations, 50))
+		metrics["trace.p90_ms"] = log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(durations, 90))
+		metrics["trace.p99_ms"] = log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(durations, 99))
+		metrics["trace.max_ms"] = log_util.GetDurationInMilliSecond(durations[len(durations)-1])
+	}
+
+	return metrics
+}
+
+// Violations sorted by metric name. Metric missing from the run is a violation.
+func evaluateGate(metrics map[string]float64, thresholds []gateThreshold) []gateViolation {
+	violations := make([]gateViolation, 0)
+	for _, threshold := range thresholds {
+		value, isOK := metrics[threshold.metric]
+		if !isOK {
+			violations = append(violations, gateViolation{
+				metric:    threshold.metric,
+				maxValue:  threshold.maxValue,
+				isMissing: true,
+			})
+			continue
+		}
+		if value > threshold.maxValue {
+			violations = append(violations, gateViolation{
+				metric:   threshold.metric,
+				value:    value,
+				maxValue: threshold.maxValue,
+			})
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].metric < violations[j].metric
+	})
+	return violations
+}
+
+func getViolationMessage(violation gateViolation) string {
+	if violation.isMissing {
+		return fmt.Sprintf("FAIL %s: metric not found in run, max %g", violation.metric, violation.maxValue)
+	}
+	return fmt.Sprintf("FAIL %s: %g exceeds max %g", violation.metric, violation.value, violation.maxValue)
+}
+
+// "Bound duration <=32ms" -> "bound_duration.<=32ms"
+func getMetricName(bucket string) string {
+	index := strings.LastIndex(bucket, " ")
+	if index == -1 {
+		return bucket
+	}
+	caseName := strings.ToLower(strings.ReplaceAll(bucket[:index], " ", "_"))
+	return caseName + "." + bucket[index+1:]
+}
@@ -0,0 +1,35 @@
+package run_analysis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_evaluateGate(t *testing.T) {
+	metrics := map[string]float64{
+		"scheduler.bound_duration.2-inf": 3,
+		"etcd.range.exceed_1s":           10,
+		"audit.list.p99_ms":              250.5,
+	}
+	thresholds := []gateThreshold{
+		{metric: "scheduler.bound_duration.2-inf", maxValue: 0},
+		{metric: "etcd.range.exceed_1s", maxValue: 10},
+		{metric: "audit.list.p99_ms", maxValue: 200},
+		{metric: "trace.p99_ms", maxValue: 2000},
+	}
+
+	violations := evaluateGate(metrics, thresholds)
+	assert.Equal(t, 3, len(violations))
+	assert.Equal(t, "FAIL audit.list.p99_ms: 250.5 exceeds max 200", getViolationMessage(violations[0]))
+	assert.Equal(t, "FAIL scheduler.bound_duration.2-inf: 3 exceeds max 0", getViolationMessage(violations[1]))
+	assert.Equal(t, "FAIL trace.p99_ms: metric not found in run, max 2000", getViolationMessage(violations[2]))
+
+	violations = evaluateGate(metrics, thresholds[1:2])
+	assert.Equal(t, 0, len(violations))
+}
+
+func Test_getMetricName(t *testing.T) {
+	assert.Equal(t, "bound_duration.<=32ms", getMetricName("Bound duration <=32ms"))
+	assert.Equal(t, "queued_duration.2-inf", getMetricName("Queued duration 2-inf"))
+	assert.Equal(t, "count", getMetricName("count"))
+}
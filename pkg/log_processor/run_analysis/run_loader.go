@@ -0,0 +1,288 @@
+package run_analysis
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"tools/pkg/log_util"
+)
+
+// Output files of the processors that a run directory is expected to have
+const (
+	etcdRangeKeyCountFile        = "etcd.to.execute.range.log.compacted.keycount"
+	etcdNonRangeKeyCountFile     = "etcd.to.execute.norange.log.compacted.keycount"
+	schedulerBucketFile          = "scheduler.saturation-deployment.log.bucket"
+	auditCombinedFilePrefix      = "combined-"
+	auditCombinedXLPrefix        = "combined-xl-"
+	auditCompleteFilePrefix      = "compact-complete-"
+	auditLatencyFilePrefix       = "latency-"
+	auditLatencySketchFileSuffix = log_util.QuantileSketchFileSuffix
+	etcdRangeSlowCountFile       = "etcd.to.execute.range.log.compacted.slowcount"
+	etcdNonRangeSlowCountFile    = "etcd.to.execute.norange.log.compacted.slowcount"
+	traceCompactedFile           = "apiserver.Trace.compacted"
+)
+
+// Metrics of a run. Each table maps a row key to its metric values.
+type runMetrics struct {
+	runPath          string
+	etcdRangeKeys    map[string][]float64 // key -> count, avg_duration, max_duration
+	etcdNonRangeKeys map[string][]float64 // key -> count, avg_duration, max_duration
+	auditCounts      map[string][]float64 // uri:verb:code -> count
+	schedulerBuckets map[string][]float64 // case bucket -> count
+}
+
+var etcdKeyCountMetrics = []string{"count", "avg_duration", "max_duration"}
+var countMetrics = []string{"count"}
+
+func loadRunMetrics(runPath string) *runMetrics {
+	return &runMetrics{
+		runPath:          runPath,
+		etcdRangeKeys:    readEtcdKeyCountFile(path.Join(runPath, etcdRangeKeyCountFile)),
+		etcdNonRangeKeys: readEtcdKeyCountFile(path.Join(runPath, etcdNonRangeKeyCountFile)),
+		auditCounts:      readAuditCountFiles(runPath),
+		schedulerBuckets: readSchedulerBucketFile(path.Join(runPath, schedulerBucketFile)),
+	}
+}
+
+// Sample file:
+// key, count, avg_duration, max_duration, p50_duration, p99_duration
+// /registry/configmaps/kube-system/ingress-gce-lock\, 12, 230110, 1004500, 120300, 1004500
+// Total 1 keys
+func readEtcdKeyCountFile(inputFilename string) map[string][]float64 {
+	result := make(map[string][]float64)
+	readDataLines(inputFilename, func(fields []string) {
+		if len(fields) < 2 {
+			return
+		}
+		values := make([]float64, len(etcdKeyCountMetrics))
+		for i := 1; i < len(fields) && i <= len(values); i++ {
+			values[i-1], _ = strconv.ParseFloat(fields[i], 64)
+		}
+		result[fields[0]] = values
+	})
+	return result
+}
+
+// Sample file:
+// uri, verb, response_code, count, stage
+// /api/v1/nodes/hollow-node-54fsg, get, 200, 1, ResponseComplete
+func readAuditCountFiles(runPath string) map[string][]float64 {
+	result := make(map[string][]float64)
+	filenames := getAuditCountFilenames(runPath)
+	for _, filename := range filenames {
+		readDataLines(filename, func(fields []string) {
+			if len(fields) != 5 {
+				return
+			}
+			count, err := strconv.ParseFloat(fields[3], 64)
+			if err != nil {
+				return
+			}
+			key := fmt.Sprintf("%s:%s:%s", fields[0], fields[1], fields[2])
+			if v, isOK := result[key]; isOK {
+				v[0] += count
+			} else {
+				result[key] = []float64{count}
+			}
+		})
+	}
+	return result
+}
+
+// Prefer combined audit count files. Fall back to compacted audit count files if there is none.
+func getAuditCountFilenames(runPath string) []string {
+	filenames := make([]string, 0)
+	combinedFilenames, _ := filepath.Glob(path.Join(runPath, auditCombinedFilePrefix+"*"))
+	for _, filename := range combinedFilenames {
+		if !strings.HasPrefix(path.Base(filename), auditCombinedXLPrefix) {
+			filenames = append(filenames, filename)
+		}
+	}
+	if len(filenames) > 0 {
+		return filenames
+	}
+
+	filenames, _ = filepath.Glob(path.Join(runPath, auditCompleteFilePrefix+"*"))
+	return filenames
+}
+
+// Sample file:
+// case, <=32ms, <=50ms, <=64ms, <=128ms, <=256ms, <=512ms, <=1s, <=2s, 2-inf
+// Bound duration, 1000, 0, 0, 0, 0, 0, 0, 0, 0
+// infinity pods: , pod-1
+func readSchedulerBucketFile(inputFilename string) map[string][]float64 {
+	result := make(map[string][]float64)
+	var buckets []string
+	readLines(inputFilename, func(fields []string) {
+		if fields[0] == "case" {
+			buckets = fields[1:]
+			return
+		}
+		if len(buckets) == 0 || len(fields) != len(buckets)+1 {
+			return
+		}
+		for i, bucket := range buckets {
+			count, err := strconv.ParseFloat(fields[i+1], 64)
+			if err != nil {
+				continue
+			}
+			result[fmt.Sprintf("%s %s", fields[0], bucket)] = []float64{count}
+		}
+	})
+	return result
+}
+
+// Sample file:
+// threshold, count
+// 100ms, 12
+// 1s, 3
+func readEtcdSlowCountFile(inputFilename string) map[string]float64 {
+	result := make(map[string]float64)
+	readDataLines(inputFilename, func(fields []string) {
+		count, err := strconv.ParseFloat(fields[1], 64)
+		if err == nil {
+			result[fields[0]] = count
+		}
+	})
+	return result
+}
+
+// Sample file:
+// verb, count, p50_ms, p90_ms, p99_ms, max_ms
+// list, 1200, 3.250, 20.100, 250.000, 1020.500
+// Returns verb -> column -> value. If every audit latency file has its sketch file, the sketches are merged so that the
+// percentiles are of all audit files. Otherwise counts of multiple audit latency files are summed, and for the
+// percentile and max columns the larger value is kept as an upper bound.
+func readAuditLatencyFiles(runPath string) map[string]map[string]float64 {
+	result := make(map[string]map[string]float64)
+	filenames, _ := filepath.Glob(path.Join(runPath, auditLatencyFilePrefix+"*"))
+	latencyFilenames := make([]string, 0)
+	sketchFilenames := make(map[string]bool)
+	for _, filename := range filenames {
+		if strings.HasSuffix(filename, auditLatencySketchFileSuffix) {
+			sketchFilenames[filename] = true
+		} else {
+			latencyFilenames = append(latencyFilenames, filename)
+		}
+	}
+
+	if sketches, isOK := readAuditLatencySketches(latencyFilenames, sketchFilenames); isOK {
+		for verb, sketch := range sketches {
+			result[verb] = map[string]float64{
+				"count":  float64(sketch.Count()),
+				"p50_ms": log_util.GetDurationInMilliSecond(sketch.Quantile(50)),
+				"p90_ms": log_util.GetDurationInMilliSecond(sketch.Quantile(90)),
+				"p99_ms": log_util.GetDurationInMilliSecond(sketch.Quantile(99)),
+				"max_ms": log_util.GetDurationInMilliSecond(sketch.Max()),
+			}
+		}
+		return result
+	}
+
+	for _, filename := range latencyFilenames {
+		var columns []string
+		readLines(filename, func(fields []string) {
+			if fields[0] == "verb" {
+				columns = fields
+				return
+			}
+			if len(fields) != len(columns) {
+				return
+			}
+			verbLatency, isOK := result[fields[0]]
+			if !isOK {
+				verbLatency = make(map[string]float64)
+				result[fields[0]] = verbLatency
+			}
+			for i := 1; i < len(fields); i++ {
+				value, err := strconv.ParseFloat(fields[i], 64)
+				if err != nil {
+					continue
+				}
+				if columns[i] == "count" {
+					verbLatency[columns[i]] += value
+				} else if value > verbLatency[columns[i]] {
+					verbLatency[columns[i]] = value
+				}
+			}
+		})
+	}
+	return result
+}
+
+// Merged sketches by verb. Returns false if any latency file has no sketch file or a sketch file cannot be read.
+func readAuditLatencySketches(latencyFilenames []string, sketchFilenames map[string]bool) (map[string]*log_util.QuantileSketch, bool) {
+	if len(latencyFilenames) == 0 {
+		return nil, false
+	}
+	sketches := make(map[string]*log_util.QuantileSketch)
+	for _, filename := range latencyFilenames {
+		sketchFilename := filename + auditLatencySketchFileSuffix
+		if !sketchFilenames[sketchFilename] {
+			return nil, false
+		}
+		if err := log_util.MergeQuantileSketchFile(sketchFilename, sketches); err != nil {
+			fmt.Printf("Error reading audit latency sketch file [%s]: %v\n", sketchFilename, err)
+			return nil, false
+		}
+	}
+	return sketches, true
+}
+
+// Sample file:
+// trace_id, is_completed, total_duration, start_time, steps
+// 452806332, true, 3286964.240000, 2020-10-02 09:51:41.129206438, "*****ETCD3 GetToList: ...", END, 1.714000
+// Returns total duration of traces in milliseconds
+func readTraceDurations(inputFilename string) []float64 {
+	result := make([]float64, 0)
+	readDataLines(inputFilename, func(fields []string) {
+		if len(fields) < 3 {
+			return
+		}
+		durationInMicroSec, err := strconv.ParseFloat(fields[2], 64)
+		if err == nil {
+			result = append(result, durationInMicroSec/1000)
+		}
+	})
+	return result
+}
+
+// Read ", " separated lines skipping header and lines without separator
+func readDataLines(inputFilename string, handleFields func(fields []string)) {
+	isHeader := true
+	readLines(inputFilename, func(fields []string) {
+		if isHeader {
+			isHeader = false
+			return
+		}
+		if len(fields) < 2 {
+			return
+		}
+		handleFields(fields)
+	})
+}
+
+func readLines(inputFilename string, handleFields func(fields []string)) {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		return
+	}
+	defer inputFileHandler.Close()
+
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line != "" {
+			handleFields(strings.Split(line, ", "))
+		}
+		if err != nil {
+			break
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package run_analysis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+	"time"
+	"tools/pkg/log_util"
+)
+
+func Test_readAuditLatencyFiles(t *testing.T) {
+	runPath, err := os.MkdirTemp("", "run")
+	assert.Nil(t, err)
+	defer os.RemoveAll(runPath)
+
+	// p99 of file b is 1s, p99 of both files is 10ms
+	for i, slowCount := range []int{0, 2} {
+		latencyFilename := path.Join(runPath, auditLatencyFilePrefix+string(rune('a'+i)))
+		sketch := log_util.NewDefaultQuantileSketch()
+		for j := 0; j < 100; j++ {
+			if j < slowCount {
+				sketch.Add(time.Second)
+			} else {
+				sketch.Add(10 * time.Millisecond)
+			}
+		}
+		os.WriteFile(latencyFilename, []byte("verb, count, p50_ms, p90_ms, p99_ms, max_ms\nget, "+
+			log_util.GetSketchStatsOutput(sketch)+"\n"), 0644)
+		log_util.WriteQuantileSketches(latencyFilename+auditLatencySketchFileSuffix, map[string]*log_util.QuantileSketch{"get": sketch})
+	}
+
+	latencies := readAuditLatencyFiles(runPath)
+	assert.Equal(t, 200.0, latencies["get"]["count"])
+	assert.InEpsilon(t, 10.0, latencies["get"]["p50_ms"], log_util.DefaultSketchRelativeAccuracy)
+	assert.InEpsilon(t, 10.0, latencies["get"]["p99_ms"], log_util.DefaultSketchRelativeAccuracy)
+	assert.Equal(t, 1000.0, latencies["get"]["max_ms"])
+
+	// summed count and larger percentiles of the files without sketches
+	os.Remove(path.Join(runPath, auditLatencyFilePrefix+"a"+auditLatencySketchFileSuffix))
+	latencies = readAuditLatencyFiles(runPath)
+	assert.Equal(t, 200.0, latencies["get"]["count"])
+	assert.Equal(t, 1000.0, latencies["get"]["p99_ms"])
+}
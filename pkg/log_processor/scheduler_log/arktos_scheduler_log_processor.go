@@ -1,3 +1,310 @@
 package scheduler_log
 
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"tools/pkg/log_util"
+)
 
+type arktosSchedulingPhase struct {
+	name              string
+	pattern           string
+	structuredMessage string // message of structured log with pod value, empty if the phase has none
+}
+
+// Scheduling phases in the order Arktos scheduler logs them
+/*
+I0409 22:32:35.827427       1 eventhandlers.go:164] Getting pod saturation-deployment-0-c47675f5-xf258 from API server
+I0409 22:32:35.827433       1 scheduling_queue.go:210] adding pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258 to the scheduling queue.
+I0409 22:32:36.209513       1 scheduling_queue.go:819] About to try and schedule pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258
+I0409 22:32:36.209529       1 scheduler.go:458] Attempting to schedule pod: arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258
+I0409 22:32:36.212748       1 generic_scheduler.go:211] DEBUG: Compute predicates pod: arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258
+I0409 22:32:36.228494       1 generic_scheduler.go:231] DEBUG: Prioritizing pod: arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258
+I0409 22:32:36.245901       1 generic_scheduler.go:255] DEBUG: Selecting host pod: arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258
+I0409 22:32:36.246120       1 scheduler.go:417] Attempting to bind pod: arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258
+I0409 22:32:36.248275       1 scheduler.go:596] pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258 is bound successfully on node hollow-node-1-btv5d, 500 nodes evaluated, 500 nodes were found feasible
+
+Structured log of newer scheduler, pod value is <namespace>/<pod>. Dequeued and the DEBUG phases have no structured form.
+I1012 10:00:00.000000       1 eventhandlers.go:118] "Add event for unscheduled pod" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258"
+I1012 10:00:00.000010       1 scheduling_queue.go:465] "Pod moved to an internal scheduling queue" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258" event="PodAdd" queue="Active"
+I1012 10:00:00.100000       1 schedule_one.go:81] "Attempting to schedule pod" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258"
+I1012 10:00:00.120000       1 schedule_one.go:792] "Attempting to bind pod to node" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258" node="hollow-node-1-btv5d"
+I1012 10:00:00.130000       1 schedule_one.go:252] "Successfully bound pod to node" pod="1ea47i-testns/saturation-deployment-0-c47675f5-xf258" node="hollow-node-1-btv5d" evaluatedNodes=500 feasibleNodes=500
+*/
+var arktosSchedulingPhases = []arktosSchedulingPhase{
+	{"received", "Getting pod ", "Add event for unscheduled pod"},
+	{"added", "adding pod ", "Pod moved to an internal scheduling queue"},
+	{"dequeued", logTrySchedulePod, ""},
+	{"scheduling", "Attempting to schedule pod:", logStructuredTrySchedulePod},
+	{"predicates", "DEBUG: Compute predicates pod:", ""},
+	{"prioritizing", "DEBUG: Prioritizing pod:", ""},
+	{"selecting_host", "DEBUG: Selecting host pod:", ""},
+	{"binding", "Attempting to bind pod:", "Attempting to bind pod to node"},
+	{"bound", longBoundPod, logStructuredBoundPod},
+}
+
+// Pods without tenant, e.g. <namespace>/<pod> of structured log, are in the system tenant
+const arktosSystemTenant = "system"
+
+const (
+	arktosPhaseReceived = 0
+	arktosPhaseAdded    = 1
+)
+
+var arktosPhaseBound = len(arktosSchedulingPhases) - 1
+
+type arktosPodScheduling struct {
+	fullPodName string // <tenant>/<namespace>/<pod>
+	tenant      string
+	phaseTimes  []string
+}
+
+func ExtractArktosPodSchedulingPhases(pathToFind string) {
+	inputFilename := path.Join(pathToFind, "kube-scheduler.log")
+	podPhaseFilename := path.Join(pathToFind, "arktos.scheduler.pod.phase.output")
+	phaseLatencyFilename := path.Join(pathToFind, "arktos.scheduler.phase.latency.output")
+	tenantLatencyFilename := path.Join(pathToFind, "arktos.scheduler.tenant.latency.output")
+
+	pods := readArktosSchedulerLog(inputFilename)
+
+	podPhaseFileHandler, err := os.Create(podPhaseFilename)
+	if err != nil {
+		fmt.Printf("Error create pod phase file [%s]: %v\n", podPhaseFilename, err)
+		panic(err)
+	}
+	defer podPhaseFileHandler.Close()
+
+	phaseLatencyFileHandler, err := os.Create(phaseLatencyFilename)
+	if err != nil {
+		fmt.Printf("Error create phase latency file [%s]: %v\n", phaseLatencyFilename, err)
+		panic(err)
+	}
+	defer phaseLatencyFileHandler.Close()
+
+	tenantLatencyFileHandler, err := os.Create(tenantLatencyFilename)
+	if err != nil {
+		fmt.Printf("Error create tenant latency file [%s]: %v\n", tenantLatencyFilename, err)
+		panic(err)
+	}
+	defer tenantLatencyFileHandler.Close()
+
+	// per pod phase times
+	header := "pod_name, tenant"
+	for _, phase := range arktosSchedulingPhases {
+		header += ", " + phase.name + "_time"
+	}
+	podPhaseFileHandler.WriteString(header + ", added_to_bound_duration\n")
+
+	phaseLatencies := make([][]time.Duration, len(arktosSchedulingPhases)-1)
+	tenantLatencies := make(map[string][]time.Duration)
+	tenantPodCount := make(map[string]int)
+	for _, pod := range pods {
+		for i := 0; i < len(phaseLatencies); i++ {
+			duration, isOK := getArktosPhaseDuration(pod, i, i+1)
+			if isOK {
+				phaseLatencies[i] = append(phaseLatencies[i], duration)
+			}
+		}
+
+		tenantPodCount[pod.tenant]++
+		addedToBound, isBound := getArktosPhaseDuration(pod, arktosPhaseAdded, arktosPhaseBound)
+		if isBound {
+			tenantLatencies[pod.tenant] = append(tenantLatencies[pod.tenant], addedToBound)
+		}
+
+		line := fmt.Sprintf("%s, %s, %s, ", pod.fullPodName, pod.tenant, strings.Join(pod.phaseTimes, ", "))
+		if isBound {
+			line += fmt.Sprintf("%d", addedToBound.Nanoseconds())
+		}
+		podPhaseFileHandler.WriteString(line + "\n")
+	}
+
+	// per phase latency
+	phaseLatencyFileHandler.WriteString("phase, count, p50_ms, p90_ms, p99_ms, max_ms\n")
+	for i, latencies := range phaseLatencies {
+		phaseName := arktosSchedulingPhases[i].name + "->" + arktosSchedulingPhases[i+1].name
+		phaseLatencyFileHandler.WriteString(phaseName + ", " + getLatencyOutput(latencies))
+	}
+
+	// per tenant latency from added to the queue to bound
+	tenants := make([]string, 0, len(tenantPodCount))
+	for tenant := range tenantPodCount {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	tenantLatencyFileHandler.WriteString("tenant, pods, count, p50_ms, p90_ms, p99_ms, max_ms\n")
+	for _, tenant := range tenants {
+		tenantLatencyFileHandler.WriteString(fmt.Sprintf("%s, %d, %s", tenant, tenantPodCount[tenant],
+			getLatencyOutput(tenantLatencies[tenant])))
+	}
+	fmt.Printf("Total pods %d, tenants %d\n", len(pods), len(tenants))
+}
+
+// Returns pods sorted by full pod name
+func readArktosSchedulerLog(inputFilename string) []*arktosPodScheduling {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	// "Getting pod" line has short pod name only. Received times wait in log order for the first line of the pod
+	// with <tenant>/<namespace>/<pod>, so that pods of the same name in different tenants or namespaces each get
+	// their own received time.
+	receivedTimes := make(map[string][]string)
+	pods := make(map[string]*arktosPodScheduling)
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		phase, podName := getArktosSchedulingPhase(line)
+		if phase == -1 {
+			continue
+		}
+		if podName == "" {
+			fmt.Printf("Failed to get pod name from line [%s]\n", line)
+			continue
+		}
+
+		logTime, err := log_util.GetTimeFromLog(line)
+		if err != nil {
+			fmt.Printf("Error getting time from log [%s]. error [%v]\n", line, err)
+			continue
+		}
+
+		if phase == arktosPhaseReceived {
+			receivedTimes[podName] = append(receivedTimes[podName], logTime)
+			continue
+		}
+
+		pod, isOK := pods[podName]
+		if !isOK {
+			nameParts := strings.Split(podName, "/")
+			shortName := nameParts[len(nameParts)-1]
+			tenant := arktosSystemTenant
+			if len(nameParts) == 3 {
+				tenant = nameParts[0]
+			}
+			pod = &arktosPodScheduling{
+				fullPodName: podName,
+				tenant:      tenant,
+				phaseTimes:  make([]string, len(arktosSchedulingPhases)),
+			}
+			if waiting := receivedTimes[shortName]; len(waiting) > 0 {
+				pod.phaseTimes[arktosPhaseReceived] = waiting[0]
+				receivedTimes[shortName] = waiting[1:]
+			}
+			pods[podName] = pod
+		}
+
+		// pod is added once but can be attempted multiple times. Keep the last attempt.
+		if phase != arktosPhaseAdded || pod.phaseTimes[phase] == "" {
+			pod.phaseTimes[phase] = logTime
+		}
+	}
+
+	result := make([]*arktosPodScheduling, 0, len(pods))
+	for _, pod := range pods {
+		result = append(result, pod)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].fullPodName < result[j].fullPodName
+	})
+	return result
+}
+
+// Returns phase index and pod name, phase index is -1 if line is not a scheduling phase.
+// Pod name is short name for received phase, and <tenant>/<namespace>/<pod> for others, or <namespace>/<pod> of
+// structured log.
+func getArktosSchedulingPhase(line string) (int, string) {
+	for i, phase := range arktosSchedulingPhases {
+		if podName, isOK := getArktosStructuredPhasePod(line, phase); isOK {
+			if i == arktosPhaseReceived {
+				nameParts := strings.Split(podName, "/")
+				return i, nameParts[len(nameParts)-1]
+			}
+			return i, podName
+		}
+		if !strings.Contains(line, phase.pattern) {
+			continue
+		}
+
+		if i == arktosPhaseReceived {
+			// Getting pod saturation-deployment-0-c47675f5-xf258 from API server
+			fields := strings.Fields(line[strings.Index(line, phase.pattern)+len(phase.pattern):])
+			if len(fields) == 0 {
+				return i, ""
+			}
+			return i, fields[0]
+		}
+		return i, getTenantPodFullName(line)
+	}
+	return -1, ""
+}
+
+// Pod value of the line if it is the structured log of the phase
+func getArktosStructuredPhasePod(line string, phase arktosSchedulingPhase) (string, bool) {
+	if phase.structuredMessage == "" || !strings.Contains(line, phase.structuredMessage) {
+		return "", false
+	}
+	klogLine, isOK := log_util.ParseKlogLine(line)
+	if !isOK || !log_util.IsStructuredKlogMessage(klogLine, phase.structuredMessage) {
+		return "", false
+	}
+	return klogLine.Values["pod"], true
+}
+
+// First field in the form of <tenant>/<namespace>/<pod>
+func getTenantPodFullName(line string) string {
+	index := strings.Index(line, "] ")
+	if index == -1 {
+		return ""
+	}
+
+	for _, field := range strings.Fields(line[index+2:]) {
+		field = strings.Trim(field, "\":,.")
+		if strings.Count(field, "/") == 2 && !strings.HasPrefix(field, "/") && !strings.HasSuffix(field, "/") {
+			return field
+		}
+	}
+	return ""
+}
+
+func getArktosPhaseDuration(pod *arktosPodScheduling, fromPhase, toPhase int) (time.Duration, bool) {
+	fromTime := pod.phaseTimes[fromPhase]
+	toTime := pod.phaseTimes[toPhase]
+	if fromTime == "" || toTime == "" {
+		return 0, false
+	}
+
+	duration, err := log_util.GetTimeDiff(fromTime, toTime)
+	if err != nil {
+		fmt.Printf("Error getting duration of pod [%s] from [%s] to [%s]: %v\n", pod.fullPodName, fromTime, toTime, err)
+		return 0, false
+	}
+	return duration, true
+}
+
+// count, p50_ms, p90_ms, p99_ms, max_ms
+func getLatencyOutput(latencies []time.Duration) string {
+	if len(latencies) == 0 {
+		return "0, , , , \n"
+	}
+
+	log_util.SortDurations(latencies)
+	return fmt.Sprintf("%d, %.3f, %.3f, %.3f, %.3f\n", len(latencies),
+		log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(latencies, 50)),
+		log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(latencies, 90)),
+		log_util.GetDurationInMilliSecond(log_util.GetDurationPercentile(latencies, 99)),
+		log_util.GetDurationInMilliSecond(latencies[len(latencies)-1]))
+}
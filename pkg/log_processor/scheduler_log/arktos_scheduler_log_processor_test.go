@@ -0,0 +1,114 @@
+package scheduler_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func Test_getArktosSchedulingPhase(t *testing.T) {
+	phase, podName := getArktosSchedulingPhase("I0409 22:32:35.827427       1 eventhandlers.go:164] Getting pod saturation-deployment-0-c47675f5-xf258 from API server")
+	assert.Equal(t, arktosPhaseReceived, phase)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	phase, podName = getArktosSchedulingPhase("I0409 22:32:35.827433       1 scheduling_queue.go:210] adding pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258 to the scheduling queue.")
+	assert.Equal(t, arktosPhaseAdded, phase)
+	assert.Equal(t, "arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258", podName)
+
+	phase, podName = getArktosSchedulingPhase("I0409 22:32:36.212748       1 generic_scheduler.go:211] DEBUG: Compute predicates pod: system/kube-system/coredns-5d4f7b9c4b-abcde")
+	assert.Equal(t, "predicates", arktosSchedulingPhases[phase].name)
+	assert.Equal(t, "system/kube-system/coredns-5d4f7b9c4b-abcde", podName)
+
+	phase, podName = getArktosSchedulingPhase("I0409 22:32:36.248275       1 scheduler.go:596] pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258 is bound successfully on node hollow-node-1-btv5d, 500 nodes evaluated, 500 nodes were found feasible")
+	assert.Equal(t, arktosPhaseBound, phase)
+	assert.Equal(t, "arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258", podName)
+
+	phase, _ = getArktosSchedulingPhase("I0409 22:32:36.248275       1 reflector.go:211] Listing and watching *v1.Node")
+	assert.Equal(t, -1, phase)
+}
+
+func Test_getArktosSchedulingPhase_structured(t *testing.T) {
+	phase, podName := getArktosSchedulingPhase("I1012 10:00:00.000000       1 eventhandlers.go:118] \"Add event for unscheduled pod\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\"")
+	assert.Equal(t, arktosPhaseReceived, phase)
+	assert.Equal(t, "saturation-deployment-0-c47675f5-xf258", podName)
+
+	phase, podName = getArktosSchedulingPhase("I1012 10:00:00.000010       1 scheduling_queue.go:465] \"Pod moved to an internal scheduling queue\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\" event=\"PodAdd\" queue=\"Active\"")
+	assert.Equal(t, arktosPhaseAdded, phase)
+	assert.Equal(t, "1ea47i-testns/saturation-deployment-0-c47675f5-xf258", podName)
+
+	phase, podName = getArktosSchedulingPhase("{\"ts\":1634032800.100000,\"caller\":\"scheduler/schedule_one.go:81\",\"msg\":\"Attempting to schedule pod\",\"v\":3,\"pod\":{\"name\":\"saturation-deployment-0-c47675f5-xf258\",\"namespace\":\"1ea47i-testns\"}}")
+	assert.Equal(t, "scheduling", arktosSchedulingPhases[phase].name)
+	assert.Equal(t, "1ea47i-testns/saturation-deployment-0-c47675f5-xf258", podName)
+
+	phase, podName = getArktosSchedulingPhase("I1012 10:00:00.120000       1 schedule_one.go:792] \"Attempting to bind pod to node\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\" node=\"hollow-node-1-btv5d\"")
+	assert.Equal(t, "binding", arktosSchedulingPhases[phase].name)
+	assert.Equal(t, "1ea47i-testns/saturation-deployment-0-c47675f5-xf258", podName)
+
+	phase, podName = getArktosSchedulingPhase("I1012 10:00:00.130000       1 schedule_one.go:252] \"Successfully bound pod to node\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\" node=\"hollow-node-1-btv5d\" evaluatedNodes=500 feasibleNodes=500")
+	assert.Equal(t, arktosPhaseBound, phase)
+	assert.Equal(t, "1ea47i-testns/saturation-deployment-0-c47675f5-xf258", podName)
+}
+
+func Test_getArktosPhaseDuration(t *testing.T) {
+	pod := &arktosPodScheduling{
+		fullPodName: "arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258",
+		tenant:      "arktos",
+		phaseTimes:  make([]string, len(arktosSchedulingPhases)),
+	}
+	pod.phaseTimes[arktosPhaseAdded] = "22:32:35.827433"
+	pod.phaseTimes[arktosPhaseBound] = "22:32:36.248275"
+
+	duration, isOK := getArktosPhaseDuration(pod, arktosPhaseAdded, arktosPhaseBound)
+	assert.True(t, isOK)
+	assert.Equal(t, int64(420842000), duration.Nanoseconds())
+
+	_, isOK = getArktosPhaseDuration(pod, arktosPhaseReceived, arktosPhaseAdded)
+	assert.False(t, isOK)
+}
+
+func Test_readArktosSchedulerLog(t *testing.T) {
+	dir, err := os.MkdirTemp("", "arktos")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// same pod name in two tenants
+	lines := []string{
+		"I0409 22:32:35.000000       1 eventhandlers.go:164] Getting pod p1 from API server",
+		"I0409 22:32:35.100000       1 scheduling_queue.go:210] adding pod t1/ns/p1 to the scheduling queue.",
+		"I0409 22:32:36.000000       1 eventhandlers.go:164] Getting pod p1 from API server",
+		"I0409 22:32:36.100000       1 scheduling_queue.go:210] adding pod t2/ns/p1 to the scheduling queue.",
+	}
+	inputFilename := path.Join(dir, "kube-scheduler.log")
+	assert.Nil(t, os.WriteFile(inputFilename, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	pods := readArktosSchedulerLog(inputFilename)
+	assert.Equal(t, 2, len(pods))
+	assert.Equal(t, "t1/ns/p1", pods[0].fullPodName)
+	assert.Equal(t, "22:32:35.000000", pods[0].phaseTimes[arktosPhaseReceived])
+	assert.Equal(t, "t2/ns/p1", pods[1].fullPodName)
+	assert.Equal(t, "22:32:36.000000", pods[1].phaseTimes[arktosPhaseReceived])
+}
+
+func Test_readArktosSchedulerLog_structured(t *testing.T) {
+	dir, err := os.MkdirTemp("", "arktos")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	lines := []string{
+		"I1012 10:00:00.000000       1 eventhandlers.go:118] \"Add event for unscheduled pod\" pod=\"ns/p1\"",
+		"I1012 10:00:00.000010       1 scheduling_queue.go:465] \"Pod moved to an internal scheduling queue\" pod=\"ns/p1\" event=\"PodAdd\" queue=\"Active\"",
+		"I1012 10:00:00.130000       1 schedule_one.go:252] \"Successfully bound pod to node\" pod=\"ns/p1\" node=\"n1\" evaluatedNodes=500 feasibleNodes=500",
+	}
+	inputFilename := path.Join(dir, "kube-scheduler.log")
+	assert.Nil(t, os.WriteFile(inputFilename, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	pods := readArktosSchedulerLog(inputFilename)
+	assert.Equal(t, 1, len(pods))
+	assert.Equal(t, "ns/p1", pods[0].fullPodName)
+	assert.Equal(t, arktosSystemTenant, pods[0].tenant)
+	assert.Equal(t, "10:00:00.000000", pods[0].phaseTimes[arktosPhaseReceived])
+	assert.Equal(t, "10:00:00.000010", pods[0].phaseTimes[arktosPhaseAdded])
+	assert.Equal(t, "10:00:00.130000", pods[0].phaseTimes[arktosPhaseBound])
+}
@@ -6,31 +6,71 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"tools/pkg/log_util"
 )
 
 var regexToFindScheduling = []string{
+	logAddPodToQueue,
+	logStructuredAddPodToQueue,
 	logTrySchedulePod,
 	"Attempting to schedule pod",
-	"AssumePodVolumes for pod",
+	"AssumePodVolumes",
 	"Attempting to bind",
 	longBoundPod,
+	logStructuredBoundPod,
+	logFailedSchedulePod,
 }
 
+// I0409 22:32:35.827433       1 scheduling_queue.go:210] adding pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258 to the scheduling queue.
+// I1012 10:00:00.000000       1 eventhandlers.go:118] "Add event for unscheduled pod" pod="ns/name"
+const logAddPodToQueue = "adding pod .* to the scheduling queue"
+const logStructuredAddPodToQueue = "Add event for unscheduled pod"
+
+var regexAddPodToQueue = regexp.MustCompile("adding pod ([^ ]+) to the scheduling queue")
+
 const logTrySchedulePod = "About to try and schedule pod"
 const longBoundPod = "is bound successfully on node"
 
+// Structured log messages of newer scheduler
+// I1012 10:00:00.000000       1 schedule_one.go:81] "Attempting to schedule pod" pod="ns/name"
+// I1012 10:00:00.100000       1 schedule_one.go:252] "Successfully bound pod to node" pod="ns/name" node="n1" evaluatedNodes=500 feasibleNodes=500
+const logStructuredTrySchedulePod = "Attempting to schedule pod"
+const logStructuredBoundPod = "Successfully bound pod to node"
+
+// E0709 01:24:22.406258       1 factory.go:585] Error scheduling system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h: 0/230 nodes are available: 230 Insufficient cpu.; retrying
+// I0709 01:24:22.406258       1 factory.go:462] Unable to schedule system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h: no fit: 0/230 nodes are available: 230 Insufficient cpu.; waiting
+const logFailedSchedulePod = "(?i)(failed to schedule|unable to schedule|error scheduling|nodes are available)"
+
+var regexFailedSchedulePod = regexp.MustCompile(logFailedSchedulePod)
+
 func ExtractPodSchedulingLog(pathToFind string) {
 	inputFilename := path.Join(pathToFind, "kube-scheduler.log")
 	outputFilename := path.Join(pathToFind, "scheduler.scheduling.pod.output")
-	log_util.ExtractMatchingLines(inputFilename, outputFilename, regexToFindScheduling)
+	// one regex for all so that a line matching more than one is written once
+	regexToFind := make([]string, len(regexToFindScheduling))
+	for i, regex := range regexToFindScheduling {
+		regexToFind[i] = "(?:" + regex + ")"
+	}
+	log_util.ExtractMatchingLines(inputFilename, outputFilename, []string{strings.Join(regexToFind, "|")})
 }
 
 type schedulingTime struct {
+	addedTime string // first added to the scheduling queue, empty if not logged
 	startTime string
-	duration  time.Duration
+	duration  time.Duration // first attempt -> bound, time to successful bind
+	attempts  []*schedulingAttempt
+}
+
+type schedulingAttempt struct {
+	startTime     string
+	endTime       string
+	isBound       bool
+	failureReason string
+	backoff       time.Duration // previous attempt end -> this attempt start
 }
 
 func ExtractScheduledAndNonScheduledPod(pathToFind string) {
@@ -38,15 +78,11 @@ func ExtractScheduledAndNonScheduledPod(pathToFind string) {
 	scheduledFilename := path.Join(pathToFind, "scheduler.scheduled.output")
 	nonScheduledFilename := path.Join(pathToFind, "scheduler.nonscheduled.output")
 	latencyScheduleFilename := path.Join(pathToFind, "scheduler.scheduled.latency.output")
+	attemptsFilename := path.Join(pathToFind, "scheduler.attempts.output")
+	retryFilename := path.Join(pathToFind, "scheduler.retry.output")
+	failureReasonFilename := path.Join(pathToFind, "scheduler.failure.reason.output")
 	latencyToWatch := time.Duration(100 * time.Microsecond)
 
-	inputFileHandler, err := os.Open(inputFilename)
-	if err != nil {
-		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
-		panic(err)
-	}
-	defer inputFileHandler.Close()
-
 	scheduledFileHandler, err := os.Create(scheduledFilename)
 	if err != nil {
 		fmt.Printf("Error create scheduled file [%s]: %v\n", scheduledFilename, err)
@@ -63,14 +99,111 @@ func ExtractScheduledAndNonScheduledPod(pathToFind string) {
 
 	latencyScheduledFileHandler, err := os.Create(latencyScheduleFilename)
 	if err != nil {
-		fmt.Printf("Error create scheduled file [%s]: %v\n", latencyScheduledFileHandler, err)
+		fmt.Printf("Error create scheduled file [%s]: %v\n", latencyScheduleFilename, err)
 		panic(err)
 	}
 	defer latencyScheduledFileHandler.Close()
 
+	attemptsFileHandler, err := os.Create(attemptsFilename)
+	if err != nil {
+		fmt.Printf("Error create attempts file [%s]: %v\n", attemptsFilename, err)
+		panic(err)
+	}
+	defer attemptsFileHandler.Close()
+
+	retryFileHandler, err := os.Create(retryFilename)
+	if err != nil {
+		fmt.Printf("Error create retry file [%s]: %v\n", retryFilename, err)
+		panic(err)
+	}
+	defer retryFileHandler.Close()
+
+	failureReasonFileHandler, err := os.Create(failureReasonFilename)
+	if err != nil {
+		fmt.Printf("Error create failure reason file [%s]: %v\n", failureReasonFilename, err)
+		panic(err)
+	}
+	defer failureReasonFileHandler.Close()
+
+	podToSchedule := readPodSchedulingAttempts(inputFilename)
+
+	fmt.Printf("len of podToSchedule %d", len(podToSchedule))
+	// output to files
+	attemptsFileHandler.WriteString("pod_name, attempt, start_time, end_time, result, backoff, failure_reason\n")
+	retryFileHandler.WriteString("pod_name, attempts, time_to_first_attempt, time_to_bind, total_backoff\n")
+	failureReasonCount := make(map[string]int)
+	for podName, scheduling := range podToSchedule {
+		if scheduling.duration > 0 {
+			scheduledFileHandler.WriteString(fmt.Sprintf("%s, %v, Start at %v\n", podName, scheduling.duration.Nanoseconds(), scheduling.startTime))
+			if scheduling.duration > latencyToWatch {
+				latencyScheduledFileHandler.WriteString(fmt.Sprintf("%s, %v, Start at %v\n", podName, scheduling.duration, scheduling.startTime))
+			}
+		} else {
+			nonScheduledFileHandler.WriteString(fmt.Sprintf("%s, Start at %v\n", podName, scheduling.startTime))
+		}
+
+		var totalBackoff time.Duration
+		for i, attempt := range scheduling.attempts {
+			result := "pending"
+			if attempt.isBound {
+				result = "bound"
+			} else if attempt.failureReason != "" {
+				result = "failed"
+				for _, reason := range strings.Split(attempt.failureReason, " | ") {
+					failureReasonCount[reason]++
+				}
+			}
+			totalBackoff += attempt.backoff
+			attemptsFileHandler.WriteString(fmt.Sprintf("%s, %d, %s, %s, %s, %d, %s\n", podName, i+1, attempt.startTime,
+				attempt.endTime, result, attempt.backoff.Nanoseconds(), attempt.failureReason))
+		}
+
+		// added to queue -> first attempt
+		timeToFirstAttempt := ""
+		if scheduling.addedTime != "" {
+			duration, err := log_util.GetTimeDiff(scheduling.addedTime, scheduling.startTime)
+			if err == nil {
+				timeToFirstAttempt = fmt.Sprintf("%d", duration.Nanoseconds())
+			}
+		}
+		timeToBind := ""
+		if scheduling.duration > 0 {
+			timeToBind = fmt.Sprintf("%d", scheduling.duration.Nanoseconds())
+		}
+		retryFileHandler.WriteString(fmt.Sprintf("%s, %d, %s, %s, %d\n", podName, len(scheduling.attempts),
+			timeToFirstAttempt, timeToBind, totalBackoff.Nanoseconds()))
+	}
+
+	// most common failure reasons first
+	reasons := make([]string, 0, len(failureReasonCount))
+	for reason := range failureReasonCount {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if failureReasonCount[reasons[i]] != failureReasonCount[reasons[j]] {
+			return failureReasonCount[reasons[i]] > failureReasonCount[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	failureReasonFileHandler.WriteString("reason, count\n")
+	for _, reason := range reasons {
+		failureReasonFileHandler.WriteString(fmt.Sprintf("%s, %d\n", reason, failureReasonCount[reason]))
+	}
+}
+
+// Read scheduling attempts of each pod from the extracted scheduling log
+func readPodSchedulingAttempts(inputFilename string) map[string]*schedulingTime {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
 	lineReader := bufio.NewReader(inputFileHandler)
 
 	podToSchedule := make(map[string]*schedulingTime, 0)
+	queueAddedTimes := make(map[string]string)
 	for {
 		line, err := lineReader.ReadString('\n')
 		if err != nil {
@@ -78,36 +211,63 @@ func ExtractScheduledAndNonScheduledPod(pathToFind string) {
 			break
 		}
 
-		isMatched, err := regexp.MatchString(logTrySchedulePod, line)
+		// pod is added again after a failed attempt, only the first add is kept
+		if podName := getPodFullNameFromQueueAddLog(line); podName != "" {
+			if _, isOK := queueAddedTimes[podName]; !isOK {
+				addedTime, err := log_util.GetTimeFromLog(line)
+				if err != nil {
+					fmt.Printf("Error getting time from log [%s]. error [%v]\n", line, err)
+					continue
+				}
+				queueAddedTimes[podName] = addedTime
+			}
+			continue
+		}
+
 		// I0709 01:14:22.399540       1 scheduling_queue.go:817] About to try and schedule pod system/kube-system/kubernetes-dashboard-79896fd99c-xrvq5
 		// I0709 01:24:21.904119       1 scheduling_queue.go:817] About to try and schedule pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l
-		if err == nil && isMatched {
+		if isTryScheduleLog(line) {
 			podName := getPodFullNameFromTryScheduleLog(line)
 			if podName == "" {
 				fmt.Printf("Failed to get pod name from try line [%s]\n", line)
 				break
 			}
 
-			_, isOK := podToSchedule[podName]
-			if isOK {
-				fmt.Printf("Encountered multiple try and schedule for pod. log [%s]\n", line)
-				continue
-			}
-
 			scheduleTime, err := log_util.GetTimeFromLog(line)
 			if err != nil {
 				fmt.Printf("Error getting time from log [%s]. error [%v]", line, err)
 				panic(err)
 			}
+
+			attempt := &schedulingAttempt{
+				startTime: scheduleTime,
+			}
+			record, isOK := podToSchedule[podName]
+			if isOK {
+				// retry, backoff starts from the end of previous attempt
+				lastAttempt := record.attempts[len(record.attempts)-1]
+				backoffStart := lastAttempt.endTime
+				if backoffStart == "" {
+					backoffStart = lastAttempt.startTime
+				}
+				attempt.backoff, err = log_util.GetTimeDiff(backoffStart, scheduleTime)
+				if err != nil {
+					fmt.Printf("Error getting backoff from [%s] to [%s]. error [%v]\n", backoffStart, scheduleTime, err)
+				}
+				record.attempts = append(record.attempts, attempt)
+				continue
+			}
+
 			podToSchedule[podName] = &schedulingTime{
+				addedTime: queueAddedTimes[podName],
 				startTime: scheduleTime,
+				attempts:  []*schedulingAttempt{attempt},
 			}
 			continue
 		}
 
-		isMatched, err = regexp.MatchString(longBoundPod, line)
 		// I0709 01:24:22.406258       1 scheduler.go:594] pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h is bound successfully on node hollow-node-n8jw4, 230 nodes evaluated, 230 nodes were found feasible
-		if err == nil && isMatched {
+		if isBoundLog(line) {
 			podName := getPodFullNameFromBoundLog(line)
 			if podName == "" {
 				fmt.Printf("Failed to get pod name from bound line [%s]", line)
@@ -131,26 +291,70 @@ func ExtractScheduledAndNonScheduledPod(pathToFind string) {
 				fmt.Printf("Error getting time difference from startTime [%s], endTime []%s. error [%v]", record.startTime, completeTime, err)
 				panic(err)
 			}
-			podToSchedule[podName].duration = duration
+			record.duration = duration
+			lastAttempt := record.attempts[len(record.attempts)-1]
+			lastAttempt.endTime = completeTime
+			lastAttempt.isBound = true
+			continue
 		}
-	}
 
-	fmt.Printf("len of podToSchedule %d", len(podToSchedule))
-	// output to files
-	for podName, scheduling := range podToSchedule {
-		if scheduling.duration > 0 {
-			scheduledFileHandler.WriteString(fmt.Sprintf("%s, %v, Start at %v\n", podName, scheduling.duration.Nanoseconds(), scheduling.startTime))
-			if scheduling.duration > latencyToWatch {
-				latencyScheduledFileHandler.WriteString(fmt.Sprintf("%s, %v, Start at %v\n", podName, scheduling.duration, scheduling.startTime))
+		if regexFailedSchedulePod.MatchString(line) {
+			podName := getPodFullNameFromFailureLog(line)
+			record, isOK := podToSchedule[podName]
+			if !isOK {
+				fmt.Printf("No matching try to schedule but got failure [%s]\n", line)
+				continue
+			}
+
+			failedTime, err := log_util.GetTimeFromLog(line)
+			if err != nil {
+				fmt.Printf("Error getting time from log [%s]. error [%v]\n", line, err)
+				continue
+			}
+			lastAttempt := record.attempts[len(record.attempts)-1]
+			if lastAttempt.endTime == "" {
+				lastAttempt.endTime = failedTime
+				lastAttempt.failureReason = getFailureReason(line, podName)
 			}
-		} else {
-			nonScheduledFileHandler.WriteString(fmt.Sprintf("%s, Start at %v\n", podName, scheduling.startTime))
 		}
 	}
+
+	return podToSchedule
+}
+
+// Legacy "About to try and schedule pod" or structured "Attempting to schedule pod" with pod.
+// Legacy "Attempting to schedule pod: <pod>" follows the try line and is not counted as another attempt.
+func isTryScheduleLog(line string) bool {
+	if strings.Contains(line, logTrySchedulePod) {
+		return true
+	}
+	if !strings.Contains(line, logStructuredTrySchedulePod) {
+		return false
+	}
+	klogLine, isOK := log_util.ParseKlogLine(line)
+	return isOK && log_util.IsStructuredKlogMessage(klogLine, logStructuredTrySchedulePod) && klogLine.Values["pod"] != ""
+}
+
+func isBoundLog(line string) bool {
+	return strings.Contains(line, longBoundPod) || strings.Contains(line, logStructuredBoundPod)
+}
+
+// Value of pod key if line is structured log, empty otherwise
+func getPodFullNameFromStructuredLog(line string) string {
+	klogLine, isOK := log_util.ParseKlogLine(line)
+	if !isOK {
+		return ""
+	}
+	return klogLine.Values["pod"]
 }
 
 // I0709 01:24:22.406258       1 scheduler.go:594] pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h is bound successfully on node hollow-node-n8jw4, 230 nodes evaluated, 230 nodes were found feasible
+// I1012 10:00:00.100000       1 schedule_one.go:252] "Successfully bound pod to node" pod="ns/name" node="n1" evaluatedNodes=500 feasibleNodes=500
 func getPodFullNameFromBoundLog(line string) string {
+	if podName := getPodFullNameFromStructuredLog(line); podName != "" {
+		return podName
+	}
+
 	strsByEmptySpace := strings.Split(line, " ")
 	if len(strsByEmptySpace) <= 11 {
 		return ""
@@ -159,8 +363,28 @@ func getPodFullNameFromBoundLog(line string) string {
 	return strsByEmptySpace[11]
 }
 
+// Pod name if line is adding the pod to the scheduling queue, empty otherwise
+// I0409 22:32:35.827433       1 scheduling_queue.go:210] adding pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258 to the scheduling queue.
+// I1012 10:00:00.000000       1 eventhandlers.go:118] "Add event for unscheduled pod" pod="ns/name"
+func getPodFullNameFromQueueAddLog(line string) string {
+	if strings.Contains(line, logStructuredAddPodToQueue) {
+		return getPodFullNameFromStructuredLog(line)
+	}
+
+	match := regexAddPodToQueue.FindStringSubmatch(line)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
 // I0709 01:24:21.904119       1 scheduling_queue.go:817] About to try and schedule pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l
+// I1012 10:00:00.000000       1 schedule_one.go:81] "Attempting to schedule pod" pod="ns/name"
 func getPodFullNameFromTryScheduleLog(line string) string {
+	if podName := getPodFullNameFromStructuredLog(line); podName != "" {
+		return podName
+	}
+
 	strsByEmptySpace := strings.Split(line, " ")
 	if len(strsByEmptySpace) <= 3 {
 		return ""
@@ -168,3 +392,72 @@ func getPodFullNameFromTryScheduleLog(line string) string {
 
 	return strings.TrimSpace(strsByEmptySpace[len(strsByEmptySpace)-1])
 }
+
+// First namespaced pod name in the failure line
+// E0709 01:24:22.406258       1 factory.go:585] Error scheduling system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h: 0/230 nodes are available: 230 Insufficient cpu.; retrying
+// E1012 10:00:00.000000       1 schedule_one.go:908] "Error scheduling pod; retrying" err="0/3 nodes are available: 3 Insufficient cpu." pod="ns/name"
+func getPodFullNameFromFailureLog(line string) string {
+	if podName := getPodFullNameFromStructuredLog(line); podName != "" {
+		return podName
+	}
+
+	index := strings.Index(line, "] ")
+	if index == -1 {
+		return ""
+	}
+
+	for _, field := range strings.Fields(line[index+2:]) {
+		field = strings.Trim(field, "\":,.")
+		slashIndex := strings.Index(field, "/")
+		if slashIndex <= 0 || slashIndex == len(field)-1 {
+			continue
+		}
+		// skip 0/230
+		if _, err := strconv.Atoi(field[:slashIndex]); err == nil {
+			continue
+		}
+		return field
+	}
+	return ""
+}
+
+// Failure reasons without node counts, separated by " | "
+// 0/230 nodes are available: 100 Insufficient cpu, 130 node(s) didn't match node selector.; retrying -> Insufficient cpu | node(s) didn't match node selector
+func getFailureReason(line, podName string) string {
+	// structured log has the reason in err
+	if klogLine, isOK := log_util.ParseKlogLine(line); isOK && klogLine.Values["err"] != "" {
+		line = klogLine.Values["err"]
+	}
+
+	const nodesAvailable = "nodes are available:"
+	index := strings.Index(line, nodesAvailable)
+	if index == -1 {
+		// no node level reasons, use the message after pod name
+		message := line
+		if podIndex := strings.Index(line, podName); podName != "" && podIndex != -1 {
+			message = line[podIndex+len(podName):]
+		} else if headerIndex := strings.Index(line, "] "); headerIndex != -1 {
+			message = line[headerIndex+2:]
+		}
+		return strings.ReplaceAll(strings.Trim(strings.TrimSpace(message), "\":;,. "), ",", ";")
+	}
+
+	message := line[index+len(nodesAvailable):]
+	if semicolonIndex := strings.Index(message, ";"); semicolonIndex != -1 {
+		message = message[:semicolonIndex]
+	}
+	reasons := make([]string, 0)
+	for _, item := range strings.Split(message, ",") {
+		item = strings.Trim(strings.TrimSpace(item), ".")
+		fields := strings.SplitN(item, " ", 2)
+		if len(fields) == 2 {
+			if _, err := strconv.Atoi(fields[0]); err == nil {
+				item = fields[1]
+			}
+		}
+		if item != "" {
+			reasons = append(reasons, item)
+		}
+	}
+	return strings.Join(reasons, " | ")
+}
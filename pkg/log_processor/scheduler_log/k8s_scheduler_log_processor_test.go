@@ -2,17 +2,99 @@ package scheduler_log
 
 import (
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_getPodFullNameFromBoundLog(t *testing.T) {
 	inputLine := "I0709 01:24:22.406258       1 scheduler.go:594] pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h is bound successfully on node hollow-node-n8jw4, 230 nodes evaluated, 230 nodes were found feasible"
 	podName := getPodFullNameFromBoundLog(inputLine)
 	assert.Equal(t, "system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h", podName)
+
+	inputLine = "I1012 10:00:00.100000       1 schedule_one.go:252] \"Successfully bound pod to node\" pod=\"4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h\" node=\"n1\" evaluatedNodes=500 feasibleNodes=500"
+	assert.True(t, isBoundLog(inputLine))
+	podName = getPodFullNameFromBoundLog(inputLine)
+	assert.Equal(t, "4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h", podName)
 }
 
 func Test_getPodFullNameFromTryScheduleLog(t *testing.T) {
 	inputLine := "I0709 01:24:21.904119       1 scheduling_queue.go:817] About to try and schedule pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l"
 	podName := getPodFullNameFromTryScheduleLog(inputLine)
 	assert.Equal(t, "system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l", podName)
+
+	inputLine = "{\"ts\":1634032800.000123,\"caller\":\"scheduler/schedule_one.go:81\",\"msg\":\"Attempting to schedule pod\",\"v\":3,\"pod\":{\"name\":\"saturation-deployment-0-5c568bc7fc-wgt2l\",\"namespace\":\"4m3obq-testns\"}}"
+	podName = getPodFullNameFromTryScheduleLog(inputLine)
+	assert.Equal(t, "4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l", podName)
+}
+
+func Test_getPodFullNameFromQueueAddLog(t *testing.T) {
+	inputLine := "I0409 22:32:35.827433       1 scheduling_queue.go:210] adding pod arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258 to the scheduling queue."
+	assert.Equal(t, "arktos/1ea47i-testns/saturation-deployment-0-c47675f5-xf258", getPodFullNameFromQueueAddLog(inputLine))
+
+	inputLine = "I1012 10:00:00.000000       1 eventhandlers.go:118] \"Add event for unscheduled pod\" pod=\"1ea47i-testns/saturation-deployment-0-c47675f5-xf258\""
+	assert.Equal(t, "1ea47i-testns/saturation-deployment-0-c47675f5-xf258", getPodFullNameFromQueueAddLog(inputLine))
+
+	inputLine = "I0709 01:24:21.904119       1 scheduling_queue.go:817] About to try and schedule pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l"
+	assert.Equal(t, "", getPodFullNameFromQueueAddLog(inputLine))
+}
+
+func Test_isTryScheduleLog(t *testing.T) {
+	assert.True(t, isTryScheduleLog("I0709 01:24:21.904119       1 scheduling_queue.go:817] About to try and schedule pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l"))
+	assert.True(t, isTryScheduleLog("I1012 10:00:00.000000       1 schedule_one.go:81] \"Attempting to schedule pod\" pod=\"4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l\""))
+	assert.False(t, isTryScheduleLog("I0709 01:24:21.904130       1 scheduler.go:458] Attempting to schedule pod: system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l"))
+}
+
+func Test_getPodFullNameFromFailureLog(t *testing.T) {
+	inputLine := "E0709 01:24:22.406258       1 factory.go:585] Error scheduling system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h: 0/230 nodes are available: 230 Insufficient cpu.; retrying"
+	podName := getPodFullNameFromFailureLog(inputLine)
+	assert.Equal(t, "system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h", podName)
+
+	inputLine = "I0709 01:24:22.406258       1 scheduler.go:572] 0/230 nodes are available: 230 Insufficient cpu. Failed to schedule pod \"system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h\""
+	podName = getPodFullNameFromFailureLog(inputLine)
+	assert.Equal(t, "system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h", podName)
+
+	inputLine = "E1012 10:00:00.000000       1 schedule_one.go:908] \"Error scheduling pod; retrying\" err=\"0/3 nodes are available: 3 Insufficient cpu.\" pod=\"4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h\""
+	podName = getPodFullNameFromFailureLog(inputLine)
+	assert.Equal(t, "4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h", podName)
+}
+
+func Test_getFailureReason(t *testing.T) {
+	podName := "system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h"
+	inputLine := "E0709 01:24:22.406258       1 factory.go:585] Error scheduling system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h: 0/230 nodes are available: 100 Insufficient cpu, 130 node(s) didn't match node selector.; retrying"
+	assert.Equal(t, "Insufficient cpu | node(s) didn't match node selector", getFailureReason(inputLine, podName))
+
+	inputLine = "I0709 01:24:22.406258       1 factory.go:462] Unable to schedule system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h: no nodes are registered to the cluster; waiting"
+	assert.Equal(t, "no nodes are registered to the cluster; waiting", getFailureReason(inputLine, podName))
+
+	inputLine = "E1012 10:00:00.000000       1 schedule_one.go:908] \"Error scheduling pod; retrying\" err=\"0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had taint {node.kubernetes.io/not-ready: }, that the pod didn't tolerate.\" pod=\"4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h\""
+	assert.Equal(t, "Insufficient cpu | node(s) had taint {node.kubernetes.io/not-ready: } | that the pod didn't tolerate", getFailureReason(inputLine, podName))
+}
+
+func Test_readPodSchedulingAttempts(t *testing.T) {
+	dir, err := os.MkdirTemp("", "scheduler")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	lines := []string{
+		"I0409 23:59:59.500000       1 scheduling_queue.go:210] adding pod t1/ns/p1 to the scheduling queue.",
+		"I0410 00:00:00.000000       1 scheduling_queue.go:819] About to try and schedule pod t1/ns/p1",
+		"I0410 00:00:00.100000       1 factory.go:462] Unable to schedule t1/ns/p1: no fit: 0/3 nodes are available: 3 Insufficient cpu.; waiting",
+		"I0410 00:00:00.200000       1 scheduling_queue.go:210] adding pod t1/ns/p1 to the scheduling queue.",
+		"I0410 00:00:01.100000       1 scheduling_queue.go:819] About to try and schedule pod t1/ns/p1",
+		"I0410 00:00:01.300000       1 scheduler.go:596] pod t1/ns/p1 is bound successfully on node n1, 3 nodes evaluated, 1 nodes were found feasible",
+	}
+	inputFilename := path.Join(dir, "scheduler.scheduling.pod.output")
+	assert.Nil(t, os.WriteFile(inputFilename, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	podToSchedule := readPodSchedulingAttempts(inputFilename)
+	scheduling := podToSchedule["t1/ns/p1"]
+	assert.NotNil(t, scheduling)
+	assert.Equal(t, "23:59:59.500000", scheduling.addedTime)
+	assert.Equal(t, "00:00:00.000000", scheduling.startTime)
+	assert.Equal(t, 1300*time.Millisecond, scheduling.duration)
+	assert.Equal(t, 2, len(scheduling.attempts))
+	assert.Equal(t, time.Second, scheduling.attempts[1].backoff)
 }
@@ -0,0 +1,271 @@
+package scheduler_log
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"tools/pkg/log_util"
+)
+
+// I0709 01:24:22.406258       1 scheduler.go:594] pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h is bound successfully on node hollow-node-n8jw4, 230 nodes evaluated, 230 nodes were found feasible
+var regexBoundPod = regexp.MustCompile(`pod (\S+) is bound successfully on node "?([^,"\s]+)"?, (\d+) nodes evaluated, (\d+) nodes were found feasible`)
+
+type boundPod struct {
+	podName        string
+	nodeName       string
+	boundTime      string
+	evaluatedNodes int
+	feasibleNodes  int
+}
+
+type feasibilityPerSecond struct {
+	second         string
+	pods           int
+	evaluatedNodes int
+	feasibleNodes  int
+}
+
+func ExtractNodePlacement(pathToFind string) {
+	inputFilename := path.Join(pathToFind, "scheduler.scheduling.pod.output")
+	podsPerNodeFilename := path.Join(pathToFind, "scheduler.pods.per.node.output")
+	placementSkewFilename := path.Join(pathToFind, "scheduler.placement.skew.output")
+	placementSummaryFilename := path.Join(pathToFind, "scheduler.placement.summary.output")
+	feasibilityFilename := path.Join(pathToFind, "scheduler.feasibility.output")
+	feasibilityTimelineFilename := path.Join(pathToFind, "scheduler.feasibility.timeline.output")
+
+	boundPods := readBoundPods(inputFilename)
+
+	podsPerNodeFileHandler, err := os.Create(podsPerNodeFilename)
+	if err != nil {
+		fmt.Printf("Error create pods per node file [%s]: %v\n", podsPerNodeFilename, err)
+		panic(err)
+	}
+	defer podsPerNodeFileHandler.Close()
+
+	placementSkewFileHandler, err := os.Create(placementSkewFilename)
+	if err != nil {
+		fmt.Printf("Error create placement skew file [%s]: %v\n", placementSkewFilename, err)
+		panic(err)
+	}
+	defer placementSkewFileHandler.Close()
+
+	placementSummaryFileHandler, err := os.Create(placementSummaryFilename)
+	if err != nil {
+		fmt.Printf("Error create placement summary file [%s]: %v\n", placementSummaryFilename, err)
+		panic(err)
+	}
+	defer placementSummaryFileHandler.Close()
+
+	feasibilityFileHandler, err := os.Create(feasibilityFilename)
+	if err != nil {
+		fmt.Printf("Error create feasibility file [%s]: %v\n", feasibilityFilename, err)
+		panic(err)
+	}
+	defer feasibilityFileHandler.Close()
+
+	feasibilityTimelineFileHandler, err := os.Create(feasibilityTimelineFilename)
+	if err != nil {
+		fmt.Printf("Error create feasibility timeline file [%s]: %v\n", feasibilityTimelineFilename, err)
+		panic(err)
+	}
+	defer feasibilityTimelineFileHandler.Close()
+
+	// pods per node, most loaded node first
+	podsPerNode := make(map[string]int)
+	for _, pod := range boundPods {
+		podsPerNode[pod.nodeName]++
+	}
+	nodes := make([]string, 0, len(podsPerNode))
+	for node := range podsPerNode {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if podsPerNode[nodes[i]] != podsPerNode[nodes[j]] {
+			return podsPerNode[nodes[i]] > podsPerNode[nodes[j]]
+		}
+		return nodes[i] < nodes[j]
+	})
+	podsPerNodeFileHandler.WriteString("node, pods\n")
+	for _, node := range nodes {
+		podsPerNodeFileHandler.WriteString(fmt.Sprintf("%s, %d\n", node, podsPerNode[node]))
+	}
+
+	// placement skew histogram, number of nodes having the same number of pods
+	nodeCountByPods := make(map[int]int)
+	for _, pods := range podsPerNode {
+		nodeCountByPods[pods]++
+	}
+	minPods, maxPods, meanPods, stdDevPods := getPlacementStats(podsPerNode)
+	placementSkewFileHandler.WriteString("pods_per_node, nodes\n")
+	for _, pods := range getSortedKeys(nodeCountByPods) {
+		placementSkewFileHandler.WriteString(fmt.Sprintf("%d, %d\n", pods, nodeCountByPods[pods]))
+	}
+	placementSummaryFileHandler.WriteString("nodes, pods, min_pods, max_pods, mean_pods, stddev_pods\n")
+	placementSummaryFileHandler.WriteString(fmt.Sprintf("%d, %d, %d, %d, %.2f, %.2f\n",
+		len(podsPerNode), len(boundPods), minPods, maxPods, meanPods, stdDevPods))
+
+	// distribution of nodes evaluated and feasible
+	evaluatedCount := make(map[int]int)
+	feasibleCount := make(map[int]int)
+	for _, pod := range boundPods {
+		evaluatedCount[pod.evaluatedNodes]++
+		feasibleCount[pod.feasibleNodes]++
+	}
+	nodeCounts := getSortedKeys(evaluatedCount)
+	for _, nodeCount := range getSortedKeys(feasibleCount) {
+		if _, isOK := evaluatedCount[nodeCount]; !isOK {
+			nodeCounts = append(nodeCounts, nodeCount)
+		}
+	}
+	sort.Ints(nodeCounts)
+	feasibilityFileHandler.WriteString("node_count, pods_evaluated, pods_feasible\n")
+	for _, nodeCount := range nodeCounts {
+		feasibilityFileHandler.WriteString(fmt.Sprintf("%d, %d, %d\n", nodeCount, evaluatedCount[nodeCount], feasibleCount[nodeCount]))
+	}
+
+	// feasible/evaluated ratio per second
+	feasibilityTimelineFileHandler.WriteString("time, pods, avg_evaluated, avg_feasible, feasible_ratio\n")
+	for _, perSecond := range getFeasibilityPerSecond(boundPods) {
+		ratio := 0.0
+		if perSecond.evaluatedNodes > 0 {
+			ratio = float64(perSecond.feasibleNodes) / float64(perSecond.evaluatedNodes)
+		}
+		feasibilityTimelineFileHandler.WriteString(fmt.Sprintf("%s, %d, %.2f, %.2f, %.4f\n", perSecond.second, perSecond.pods,
+			float64(perSecond.evaluatedNodes)/float64(perSecond.pods), float64(perSecond.feasibleNodes)/float64(perSecond.pods), ratio))
+	}
+	fmt.Printf("Total bound pods %d, nodes %d\n", len(boundPods), len(podsPerNode))
+}
+
+// Bound pods in log order
+func readBoundPods(inputFilename string) []*boundPod {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFilename, err)
+		panic(err)
+	}
+	defer inputFileHandler.Close()
+
+	boundPods := make([]*boundPod, 0)
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("Error read file by line: %v\n", err)
+			break
+		}
+
+		pod, isOK := parseBoundLog(line)
+		if isOK {
+			boundPods = append(boundPods, pod)
+		}
+	}
+	return boundPods
+}
+
+// Bound line in either legacy or structured form
+func parseBoundLog(line string) (*boundPod, bool) {
+	if strings.Contains(line, logStructuredBoundPod) {
+		return parseStructuredBoundLog(line)
+	}
+
+	matches := regexBoundPod.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, false
+	}
+
+	boundTime, err := log_util.GetTimeFromLog(line)
+	if err != nil {
+		fmt.Printf("Error getting time from log [%s]. error [%v]\n", line, err)
+		return nil, false
+	}
+	evaluatedNodes, _ := strconv.Atoi(matches[3])
+	feasibleNodes, _ := strconv.Atoi(matches[4])
+	return &boundPod{
+		podName:        matches[1],
+		nodeName:       matches[2],
+		boundTime:      boundTime,
+		evaluatedNodes: evaluatedNodes,
+		feasibleNodes:  feasibleNodes,
+	}, true
+}
+
+// I1012 10:00:00.100000       1 schedule_one.go:252] "Successfully bound pod to node" pod="ns/name" node="n1" evaluatedNodes=500 feasibleNodes=500
+func parseStructuredBoundLog(line string) (*boundPod, bool) {
+	klogLine, isOK := log_util.ParseKlogLine(line)
+	if !isOK || !log_util.IsStructuredKlogMessage(klogLine, logStructuredBoundPod) || klogLine.Values["pod"] == "" {
+		return nil, false
+	}
+
+	evaluatedNodes, _ := strconv.Atoi(klogLine.Values["evaluatedNodes"])
+	feasibleNodes, _ := strconv.Atoi(klogLine.Values["feasibleNodes"])
+	return &boundPod{
+		podName:        klogLine.Values["pod"],
+		nodeName:       klogLine.Values["node"],
+		boundTime:      klogLine.Time,
+		evaluatedNodes: evaluatedNodes,
+		feasibleNodes:  feasibleNodes,
+	}, true
+}
+
+// min, max, mean and standard deviation of pods per node
+func getPlacementStats(podsPerNode map[string]int) (int, int, float64, float64) {
+	if len(podsPerNode) == 0 {
+		return 0, 0, 0, 0
+	}
+
+	minPods := math.MaxInt32
+	maxPods := 0
+	total := 0
+	for _, pods := range podsPerNode {
+		if pods < minPods {
+			minPods = pods
+		}
+		if pods > maxPods {
+			maxPods = pods
+		}
+		total += pods
+	}
+	mean := float64(total) / float64(len(podsPerNode))
+
+	variance := 0.0
+	for _, pods := range podsPerNode {
+		variance += (float64(pods) - mean) * (float64(pods) - mean)
+	}
+	return minPods, maxPods, mean, math.Sqrt(variance / float64(len(podsPerNode)))
+}
+
+// Bound pods grouped by second of bound time, in log order
+func getFeasibilityPerSecond(boundPods []*boundPod) []*feasibilityPerSecond {
+	result := make([]*feasibilityPerSecond, 0)
+	for _, pod := range boundPods {
+		// 01:24:22.406258 -> 01:24:22
+		second := pod.boundTime
+		if index := strings.Index(second, "."); index != -1 {
+			second = second[:index]
+		}
+
+		if len(result) == 0 || result[len(result)-1].second != second {
+			result = append(result, &feasibilityPerSecond{second: second})
+		}
+		perSecond := result[len(result)-1]
+		perSecond.pods++
+		perSecond.evaluatedNodes += pod.evaluatedNodes
+		perSecond.feasibleNodes += pod.feasibleNodes
+	}
+	return result
+}
+
+func getSortedKeys(countMap map[int]int) []int {
+	keys := make([]int, 0, len(countMap))
+	for key := range countMap {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,53 @@
+package scheduler_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_parseBoundLog(t *testing.T) {
+	inputLine := "I0709 01:24:22.406258       1 scheduler.go:594] pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h is bound successfully on node hollow-node-n8jw4, 230 nodes evaluated, 115 nodes were found feasible"
+	pod, isOK := parseBoundLog(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h", pod.podName)
+	assert.Equal(t, "hollow-node-n8jw4", pod.nodeName)
+	assert.Equal(t, "01:24:22.406258", pod.boundTime)
+	assert.Equal(t, 230, pod.evaluatedNodes)
+	assert.Equal(t, 115, pod.feasibleNodes)
+
+	inputLine = "I1012 10:00:00.100000       1 schedule_one.go:252] \"Successfully bound pod to node\" pod=\"4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h\" node=\"hollow-node-n8jw4\" evaluatedNodes=500 feasibleNodes=100"
+	pod, isOK = parseBoundLog(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h", pod.podName)
+	assert.Equal(t, "hollow-node-n8jw4", pod.nodeName)
+	assert.Equal(t, "10:00:00.100000", pod.boundTime)
+	assert.Equal(t, 500, pod.evaluatedNodes)
+	assert.Equal(t, 100, pod.feasibleNodes)
+
+	inputLine = "I0709 01:24:21.904119       1 scheduling_queue.go:817] About to try and schedule pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-wgt2l"
+	_, isOK = parseBoundLog(inputLine)
+	assert.False(t, isOK)
+}
+
+func Test_getPlacementStats(t *testing.T) {
+	minPods, maxPods, mean, stdDev := getPlacementStats(map[string]int{"node-1": 2, "node-2": 4, "node-3": 6})
+	assert.Equal(t, 2, minPods)
+	assert.Equal(t, 6, maxPods)
+	assert.Equal(t, 4.0, mean)
+	assert.InDelta(t, 1.633, stdDev, 0.001)
+}
+
+func Test_getFeasibilityPerSecond(t *testing.T) {
+	boundPods := []*boundPod{
+		{boundTime: "01:24:22.406258", evaluatedNodes: 500, feasibleNodes: 250},
+		{boundTime: "01:24:22.906258", evaluatedNodes: 500, feasibleNodes: 150},
+		{boundTime: "01:24:23.006258", evaluatedNodes: 100, feasibleNodes: 100},
+	}
+	result := getFeasibilityPerSecond(boundPods)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "01:24:22", result[0].second)
+	assert.Equal(t, 2, result[0].pods)
+	assert.Equal(t, 1000, result[0].evaluatedNodes)
+	assert.Equal(t, 400, result[0].feasibleNodes)
+	assert.Equal(t, "01:24:23", result[1].second)
+}
@@ -0,0 +1,192 @@
+package scheduler_log
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"time"
+	"tools/pkg/log_util"
+)
+
+type throughputPerSecond struct {
+	second     int // seconds since the first attempt
+	attempted  int // pods attempted for the first time
+	bound      int
+	rollingAvg float64
+	backlog    int // attempted but not yet bound
+}
+
+// Pods bound per second over time, from the scheduling log extracted by ExtractPodSchedulingLog.
+// Sustained throughput is the highest rolling average over rollingWindow seconds.
+func ExtractSchedulerThroughput(pathToFind string, rollingWindow int) {
+	inputFilename := path.Join(pathToFind, "scheduler.scheduling.pod.output")
+	throughputFilename := path.Join(pathToFind, "scheduler.throughput.output")
+	throughputSummaryFilename := path.Join(pathToFind, "scheduler.throughput.summary.output")
+
+	podToSchedule := readPodSchedulingAttempts(inputFilename)
+	if len(podToSchedule) == 0 {
+		fmt.Printf("No pod scheduling found in [%s]\n", inputFilename)
+		return
+	}
+	firstLogTime, err := readFirstLogTime(inputFilename)
+	if err != nil {
+		fmt.Printf("Error getting first log time of [%s]: %v\n", inputFilename, err)
+		return
+	}
+
+	throughputFileHandler, err := os.Create(throughputFilename)
+	if err != nil {
+		fmt.Printf("Error create throughput file [%s]: %v\n", throughputFilename, err)
+		panic(err)
+	}
+	defer throughputFileHandler.Close()
+
+	throughputSummaryFileHandler, err := os.Create(throughputSummaryFilename)
+	if err != nil {
+		fmt.Printf("Error create throughput summary file [%s]: %v\n", throughputSummaryFilename, err)
+		panic(err)
+	}
+	defer throughputSummaryFileHandler.Close()
+
+	attemptTimes := make([]time.Time, 0, len(podToSchedule))
+	boundTimes := make([]time.Time, 0, len(podToSchedule))
+	for podName, scheduling := range podToSchedule {
+		attemptTime, err := log_util.ParseLogTime(scheduling.startTime)
+		if err != nil {
+			fmt.Printf("Error parsing attempt time [%s] of pod [%s]: %v\n", scheduling.startTime, podName, err)
+			continue
+		}
+		attemptTimes = append(attemptTimes, attemptTime)
+
+		lastAttempt := scheduling.attempts[len(scheduling.attempts)-1]
+		if !lastAttempt.isBound {
+			continue
+		}
+		boundTime, err := log_util.ParseLogTime(lastAttempt.endTime)
+		if err != nil {
+			fmt.Printf("Error parsing bound time [%s] of pod [%s]: %v\n", lastAttempt.endTime, podName, err)
+			continue
+		}
+		boundTimes = append(boundTimes, boundTime)
+	}
+
+	startTime, timeline := getThroughputTimeline(firstLogTime, attemptTimes, boundTimes, rollingWindow)
+
+	throughputFileHandler.WriteString(fmt.Sprintf("time, bound, rolling_avg_%ds, attempted, backlog\n", rollingWindow))
+	peak := 0
+	peakTime := ""
+	sustained := 0.0
+	sustainedTime := ""
+	for _, perSecond := range timeline {
+		timeOfSecond := startTime.Add(time.Duration(perSecond.second) * time.Second).Format("15:04:05")
+		throughputFileHandler.WriteString(fmt.Sprintf("%s, %d, %.2f, %d, %d\n", timeOfSecond, perSecond.bound,
+			perSecond.rollingAvg, perSecond.attempted, perSecond.backlog))
+
+		if perSecond.bound > peak {
+			peak = perSecond.bound
+			peakTime = timeOfSecond
+		}
+		if perSecond.second+1 >= rollingWindow && perSecond.rollingAvg > sustained {
+			sustained = perSecond.rollingAvg
+			sustainedTime = timeOfSecond
+		}
+	}
+
+	average := 0.0
+	if len(timeline) > 0 {
+		average = float64(len(boundTimes)) / float64(len(timeline))
+	}
+	throughputSummaryFileHandler.WriteString("attempted_pods, bound_pods, seconds, avg_throughput, peak_throughput, peak_time, sustained_throughput, sustained_end_time\n")
+	throughputSummaryFileHandler.WriteString(fmt.Sprintf("%d, %d, %d, %.2f, %d, %s, %.2f, %s\n", len(attemptTimes), len(boundTimes),
+		len(timeline), average, peak, peakTime, sustained, sustainedTime))
+	fmt.Printf("Bound %d pods in %d seconds, peak %d pods/s, sustained %.2f pods/s\n", len(boundTimes), len(timeline), peak, sustained)
+}
+
+// Per second timeline from the second of the first log line to the last bound or attempt, including seconds without
+// any pod bound. Log time has no date, time earlier than the first log line is taken as the next day.
+func getThroughputTimeline(firstLogTime time.Time, attemptTimes, boundTimes []time.Time, rollingWindow int) (time.Time, []*throughputPerSecond) {
+	if len(attemptTimes) == 0 {
+		return time.Time{}, nil
+	}
+
+	startTime := firstLogTime.Truncate(time.Second)
+
+	attemptedPerSecond := getCountPerSecond(startTime, attemptTimes)
+	boundPerSecond := getCountPerSecond(startTime, boundTimes)
+	seconds := len(attemptedPerSecond)
+	if len(boundPerSecond) > seconds {
+		seconds = len(boundPerSecond)
+	}
+
+	if rollingWindow < 1 {
+		rollingWindow = 1
+	}
+	timeline := make([]*throughputPerSecond, seconds)
+	backlog := 0
+	boundInWindow := 0
+	for i := 0; i < seconds; i++ {
+		perSecond := &throughputPerSecond{second: i}
+		if i < len(attemptedPerSecond) {
+			perSecond.attempted = attemptedPerSecond[i]
+		}
+		if i < len(boundPerSecond) {
+			perSecond.bound = boundPerSecond[i]
+		}
+
+		backlog += perSecond.attempted - perSecond.bound
+		perSecond.backlog = backlog
+
+		boundInWindow += perSecond.bound
+		if i >= rollingWindow {
+			boundInWindow -= timeline[i-rollingWindow].bound
+		}
+		windowSize := rollingWindow
+		if i+1 < rollingWindow {
+			windowSize = i + 1
+		}
+		perSecond.rollingAvg = float64(boundInWindow) / float64(windowSize)
+		timeline[i] = perSecond
+	}
+	return startTime, timeline
+}
+
+func getCountPerSecond(startTime time.Time, times []time.Time) []int {
+	countPerSecond := make([]int, 0)
+	for _, t := range times {
+		if t.Before(startTime) {
+			t = t.Add(24 * time.Hour)
+		}
+		second := int(t.Sub(startTime) / time.Second)
+		for len(countPerSecond) <= second {
+			countPerSecond = append(countPerSecond, 0)
+		}
+		countPerSecond[second]++
+	}
+	return countPerSecond
+}
+
+// Time of the first line with a log time, in log order
+func readFirstLogTime(inputFilename string) (time.Time, error) {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer inputFileHandler.Close()
+
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			return time.Time{}, fmt.Errorf("No log time found: %v", err)
+		}
+
+		logTime, err := log_util.GetTimeFromLog(line)
+		if err != nil {
+			continue
+		}
+		if parsedTime, err := log_util.ParseLogTime(logTime); err == nil {
+			return parsedTime, nil
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package scheduler_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"tools/pkg/log_util"
+)
+
+func Test_getThroughputTimeline(t *testing.T) {
+	attemptTimes := getLogTimes(t, "01:24:20.100000", "01:24:20.200000", "01:24:20.300000", "01:24:21.100000")
+	boundTimes := getLogTimes(t, "01:24:20.500000", "01:24:21.500000", "01:24:23.100000")
+
+	startTime, timeline := getThroughputTimeline(attemptTimes[0], attemptTimes, boundTimes, 2)
+	assert.Equal(t, "01:24:20", startTime.Format("15:04:05"))
+	assert.Equal(t, 4, len(timeline))
+
+	assert.Equal(t, 3, timeline[0].attempted)
+	assert.Equal(t, 1, timeline[0].bound)
+	assert.Equal(t, 2, timeline[0].backlog)
+	assert.Equal(t, 1.0, timeline[0].rollingAvg)
+
+	assert.Equal(t, 1, timeline[1].attempted)
+	assert.Equal(t, 1, timeline[1].bound)
+	assert.Equal(t, 2, timeline[1].backlog)
+	assert.Equal(t, 1.0, timeline[1].rollingAvg)
+
+	assert.Equal(t, 0, timeline[2].bound)
+	assert.Equal(t, 2, timeline[2].backlog)
+	assert.Equal(t, 0.5, timeline[2].rollingAvg)
+
+	assert.Equal(t, 1, timeline[3].bound)
+	assert.Equal(t, 1, timeline[3].backlog)
+	assert.Equal(t, 0.5, timeline[3].rollingAvg)
+}
+
+func Test_getThroughputTimeline_midnight(t *testing.T) {
+	// attempts are in log order, 00:00:00.500000 is after midnight
+	attemptTimes := getLogTimes(t, "23:59:59.100000", "00:00:00.500000")
+	boundTimes := getLogTimes(t, "23:59:59.800000", "00:00:01.200000")
+
+	startTime, timeline := getThroughputTimeline(attemptTimes[0], attemptTimes, boundTimes, 1)
+	assert.Equal(t, "23:59:59", startTime.Format("15:04:05"))
+	assert.Equal(t, 3, len(timeline))
+	assert.Equal(t, []int{1, 1, 0}, []int{timeline[0].attempted, timeline[1].attempted, timeline[2].attempted})
+	assert.Equal(t, []int{1, 0, 1}, []int{timeline[0].bound, timeline[1].bound, timeline[2].bound})
+	assert.Equal(t, 0, timeline[2].backlog)
+}
+
+func Test_getCountPerSecond(t *testing.T) {
+	startTime := getLogTimes(t, "23:59:59.000000")[0]
+	countPerSecond := getCountPerSecond(startTime, getLogTimes(t, "23:59:59.100000", "23:59:59.900000", "00:00:01.000000"))
+	assert.Equal(t, []int{2, 0, 1}, countPerSecond)
+}
+
+func getLogTimes(t *testing.T, logTimes ...string) []time.Time {
+	result := make([]time.Time, len(logTimes))
+	for i, logTime := range logTimes {
+		parsedTime, err := log_util.ParseLogTime(logTime)
+		assert.Nil(t, err)
+		result[i] = parsedTime
+	}
+	return result
+}
@@ -0,0 +1,264 @@
+package trace_log
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Error categories in .errortrace
+const (
+	traceErrorOrphaned             = "orphaned"              // step or end without matching start
+	traceErrorOverwritten          = "overwritten"           // pending trace replaced by a new start with the same key
+	traceErrorDuplicated           = "duplicated"            // trace logged again, or end after trace completed
+	traceErrorInconsistentDuration = "inconsistent_duration" // total time at end differs from start
+)
+
+// Lines of a trace are logged together, steps logged later than this from the start are not matched to it
+const traceMatchWindow = time.Minute
+
+// Number of lines between removing completed traces that are out of match window
+const completedTracePruneLines = 10000
+
+// Traces are assembled by source file, apiserver instance and trace id since trace ids are reused over time
+// and by different apiservers.
+type traceKey struct {
+	source   string
+	instance string
+	traceId  string
+}
+
+type pendingTrace struct {
+	trace       *Trace
+	logTime     time.Time // log time of trace start
+	isDuplicate bool      // same trace was completed from the same key
+}
+
+type traceAssembler struct {
+	onTrace         func(trace *Trace, isCompleted bool)
+	onError         func(key traceKey, category, detail string)
+	traces          map[traceKey]*pendingTrace
+	completedTraces map[traceKey]*pendingTrace // recently completed, to find duplicates
+	lastLogTimes    map[string]time.Time       // by source, for steps without log time
+	lineCount       int
+}
+
+// kube-apiserver.log-20201002-1601632508.gz:I1002 09:51:44.416187       1 trace.go:81] Trace[452806332]: ...
+var regexTraceLogHeader = regexp.MustCompile(`^(?:(.*):)?[IWEF](\d{4} \d{2}:\d{2}:\d{2}\.\d{6})\s`)
+
+// kube-apiserver.log-20201002-1601632508.gz:Trace[1826955112]: [546.880485ms] [546.880485ms] END
+var regexTraceStepSource = regexp.MustCompile(`^(?:(.*):)?Trace\[\d+\]:`)
+
+const traceLogTimeLayout = "0102 15:04:05.000000"
+
+func newTraceAssembler(onTrace func(trace *Trace, isCompleted bool), onError func(key traceKey, category, detail string)) *traceAssembler {
+	return &traceAssembler{
+		onTrace:         onTrace,
+		onError:         onError,
+		traces:          make(map[traceKey]*pendingTrace),
+		completedTraces: make(map[traceKey]*pendingTrace),
+		lastLogTimes:    make(map[string]time.Time),
+	}
+}
+
+func (assembler *traceAssembler) addLine(line string) {
+	assembler.lineCount++
+	if assembler.lineCount%completedTracePruneLines == 0 {
+		assembler.pruneCompletedTraces()
+	}
+
+	step := ParseStep(line)
+	source := getTraceSource(line)
+	logTime, hasLogTime := getTraceLogTime(line)
+	if hasLogTime {
+		assembler.lastLogTimes[source] = logTime
+	} else {
+		logTime = assembler.lastLogTimes[source]
+	}
+	key := traceKey{source: source, instance: getApiserverInstance(source), traceId: step.traceId}
+
+	if step.isStart {
+		assembler.addTraceStart(key, step, logTime)
+		return
+	}
+
+	pendingKey, pending, isOK := assembler.findPendingTrace(key, logTime)
+	if !isOK {
+		if _, isCompleted := assembler.completedTraces[key]; isCompleted {
+			assembler.reportError(key, traceErrorDuplicated, "Trace step after trace end")
+		} else {
+			assembler.reportError(key, traceErrorOrphaned, "Trace step does not have matching start")
+		}
+		return
+	}
+
+	trace := pending.trace
+	if trace.wasCompleted {
+		assembler.reportError(pendingKey, traceErrorDuplicated, "Duplicated trace end")
+		return
+	}
+	if !step.isEnd {
+		trace.steps = append(trace.steps, TraceStep{
+			traceId:      step.traceId,
+			stepDuration: step.stepDuration,
+			stepMessage:  step.stepMessage,
+			fields:       step.fields,
+			depth:        step.depth,
+			stepTime:     step.stepTime,
+			isNested:     step.isNested,
+		})
+		return
+	}
+
+	trace.wasCompleted = true
+	if !isSameDuration(trace.totalDuration, step.totalDuration) {
+		assembler.reportError(pendingKey, traceErrorInconsistentDuration,
+			fmt.Sprintf("Inconsistent total duration %s/%s", trace.totalDuration, step.totalDuration))
+		return
+	}
+	trace.steps = append(trace.steps, TraceStep{
+		traceId:      step.traceId,
+		stepMessage:  step.stepMessage,
+		stepDuration: step.stepDuration,
+		isEnd:        true,
+	})
+	trace.stepTree = buildStepTree(trace.steps)
+
+	delete(assembler.traces, pendingKey)
+	assembler.completedTraces[pendingKey] = pending
+	if pending.isDuplicate {
+		assembler.reportError(pendingKey, traceErrorDuplicated, fmt.Sprintf("Trace started at %s is logged again", trace.startTime))
+		return
+	}
+	assembler.onTrace(trace, true)
+}
+
+func (assembler *traceAssembler) addTraceStart(key traceKey, step TraceStep, logTime time.Time) {
+	if pending, isOK := assembler.traces[key]; isOK {
+		assembler.reportError(key, traceErrorOverwritten,
+			fmt.Sprintf("Trace started at %s is replaced by trace started at %s", pending.trace.startTime, step.startTime))
+		assembler.flushTrace(pending)
+	}
+
+	completed, isDuplicate := assembler.completedTraces[key]
+	isDuplicate = isDuplicate && completed.trace.startTime == step.startTime && isWithinTraceMatchWindow(completed.logTime, logTime)
+	assembler.traces[key] = &pendingTrace{
+		trace: &Trace{
+			traceId:       step.traceId,
+			source:        key.source,
+			instance:      key.instance,
+			totalDuration: step.totalDuration,
+			startTime:     step.startTime,
+			fields:        step.fields,
+			steps: []TraceStep{{
+				traceId:     step.traceId,
+				startTime:   step.startTime,
+				stepMessage: step.stepMessage,
+				isStart:     true,
+				fields:      step.fields,
+			}},
+		},
+		logTime:     logTime,
+		isDuplicate: isDuplicate,
+	}
+}
+
+// Trace with the same key, or with the same instance and trace id from another source for traces across
+// rotated files. Trace must be started within match window.
+func (assembler *traceAssembler) findPendingTrace(key traceKey, logTime time.Time) (traceKey, *pendingTrace, bool) {
+	if pending, isOK := assembler.traces[key]; isOK {
+		return key, pending, isWithinTraceMatchWindow(pending.logTime, logTime)
+	}
+	for pendingKey, pending := range assembler.traces {
+		if pendingKey.instance == key.instance && pendingKey.traceId == key.traceId &&
+			isWithinTraceMatchWindow(pending.logTime, logTime) {
+			return pendingKey, pending, true
+		}
+	}
+	return key, nil, false
+}
+
+// Traces without end are reported as incomplete
+func (assembler *traceAssembler) flush() {
+	for key, pending := range assembler.traces {
+		if pending.isDuplicate {
+			assembler.reportError(key, traceErrorDuplicated, fmt.Sprintf("Trace started at %s is logged again", pending.trace.startTime))
+		} else {
+			assembler.flushTrace(pending)
+		}
+		delete(assembler.traces, key)
+	}
+}
+
+func (assembler *traceAssembler) flushTrace(pending *pendingTrace) {
+	if pending.isDuplicate {
+		return
+	}
+	pending.trace.wasCompleted = true
+	pending.trace.stepTree = buildStepTree(pending.trace.steps)
+	assembler.onTrace(pending.trace, false)
+}
+
+func (assembler *traceAssembler) pruneCompletedTraces() {
+	for key, completed := range assembler.completedTraces {
+		if !isWithinTraceMatchWindow(completed.logTime, assembler.lastLogTimes[key.source]) {
+			delete(assembler.completedTraces, key)
+		}
+	}
+}
+
+func (assembler *traceAssembler) reportError(key traceKey, category, detail string) {
+	if assembler.onError != nil {
+		assembler.onError(key, category, detail)
+	}
+}
+
+// Log file name prefixed by grep, empty if the line has no prefix
+func getTraceSource(line string) string {
+	if matches := regexTraceLogHeader.FindStringSubmatch(line); matches != nil {
+		return matches[1]
+	}
+	if matches := regexTraceStepSource.FindStringSubmatch(line); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
+
+// Log time in klog header. Trace steps have no header.
+func getTraceLogTime(line string) (time.Time, bool) {
+	matches := regexTraceLogHeader.FindStringSubmatch(line)
+	if matches == nil {
+		return time.Time{}, false
+	}
+	logTime, err := time.Parse(traceLogTimeLayout, matches[2])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return logTime, true
+}
+
+// Directory of the log file when logs of apiservers are in different directories, e.g. master-0/kube-apiserver.log,
+// otherwise log file name without rotation suffix, e.g. kube-apiserver.log-20201002-1601632508.gz -> kube-apiserver
+func getApiserverInstance(source string) string {
+	if source == "" {
+		return ""
+	}
+	if dir := path.Dir(source); dir != "." {
+		return dir
+	}
+	if index := strings.Index(source, ".log"); index > 0 {
+		return source[:index]
+	}
+	return source
+}
+
+// Log time is unknown if zero
+func isWithinTraceMatchWindow(startLogTime, logTime time.Time) bool {
+	if startLogTime.IsZero() || logTime.IsZero() {
+		return true
+	}
+	diff := logTime.Sub(startLogTime)
+	return diff <= traceMatchWindow && diff >= -traceMatchWindow
+}
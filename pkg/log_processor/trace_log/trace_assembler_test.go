@@ -0,0 +1,118 @@
+package trace_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type assembledTraces struct {
+	traces    []*Trace
+	completed []bool
+	errors    []string
+}
+
+func assembleTraces(lines ...string) *assembledTraces {
+	result := &assembledTraces{}
+	assembler := newTraceAssembler(func(trace *Trace, isCompleted bool) {
+		result.traces = append(result.traces, trace)
+		result.completed = append(result.completed, isCompleted)
+	}, func(key traceKey, category, detail string) {
+		result.errors = append(result.errors, key.instance+"/"+key.traceId+" "+category)
+	})
+	for _, line := range lines {
+		assembler.addLine(line)
+	}
+	assembler.flush()
+	return result
+}
+
+func Test_traceAssembler_interleavedSources(t *testing.T) {
+	result := assembleTraces(
+		"master-0/kube-apiserver.log:I1002 09:51:44.416187       1 trace.go:81] Trace[1]: \"Get\" (started: 2020-10-02 09:51:44.000000000 +0000 UTC m=+45.462536308) (total time: 400ms):",
+		"master-1/kube-apiserver.log:I1002 09:51:44.500000       1 trace.go:81] Trace[1]: \"List\" (started: 2020-10-02 09:51:44.300000000 +0000 UTC m=+45.462536308) (total time: 200ms):",
+		"master-0/kube-apiserver.log:Trace[1]: [300ms] [300ms] About to write a response",
+		"master-1/kube-apiserver.log:Trace[1]: [200ms] [200ms] END",
+		"master-0/kube-apiserver.log:Trace[1]: [400ms] [100ms] END",
+	)
+	assert.Nil(t, result.errors)
+	assert.Equal(t, 2, len(result.traces))
+	assert.Equal(t, []bool{true, true}, result.completed)
+
+	assert.Equal(t, "master-1", result.traces[0].instance)
+	assert.Equal(t, "\"List\"", result.traces[0].steps[0].stepMessage)
+	assert.Equal(t, 2, len(result.traces[0].steps))
+
+	assert.Equal(t, "master-0", result.traces[1].instance)
+	assert.Equal(t, "master-0/kube-apiserver.log", result.traces[1].source)
+	assert.Equal(t, 3, len(result.traces[1].steps))
+}
+
+func Test_traceAssembler_rotatedFile(t *testing.T) {
+	result := assembleTraces(
+		"kube-apiserver.log-20201002-1601632508.gz:I1002 09:51:44.416187       1 trace.go:81] Trace[1]: \"Get\" (started: 2020-10-02 09:51:44.000000000 +0000 UTC m=+45.462536308) (total time: 400ms):",
+		"kube-apiserver.log-20201002-1601632999.gz:Trace[1]: [400ms] [400ms] END",
+	)
+	assert.Nil(t, result.errors)
+	assert.Equal(t, []bool{true}, result.completed)
+	assert.Equal(t, "kube-apiserver", result.traces[0].instance)
+}
+
+func Test_traceAssembler_errors(t *testing.T) {
+	result := assembleTraces(
+		// orphaned
+		"Trace[1]: [400ms] [400ms] END",
+		// overwritten
+		"I1002 09:51:44.416187       1 trace.go:81] Trace[2]: \"Get\" (started: 2020-10-02 09:51:44.000000000 +0000 UTC m=+45.462536308) (total time: 400ms):",
+		"I1002 09:51:44.500000       1 trace.go:81] Trace[2]: \"List\" (started: 2020-10-02 09:51:44.300000000 +0000 UTC m=+45.462536308) (total time: 200ms):",
+		"Trace[2]: [200ms] [200ms] END",
+		// duplicated
+		"I1002 09:51:45.000000       1 trace.go:81] Trace[3]: \"Get\" (started: 2020-10-02 09:51:44.900000000 +0000 UTC m=+45.462536308) (total time: 100ms):",
+		"Trace[3]: [100ms] [100ms] END",
+		"I1002 09:51:45.000000       1 trace.go:81] Trace[3]: \"Get\" (started: 2020-10-02 09:51:44.900000000 +0000 UTC m=+45.462536308) (total time: 100ms):",
+		"Trace[3]: [100ms] [100ms] END",
+		"Trace[3]: [100ms] [100ms] END",
+		// step out of match window
+		"I1002 09:53:00.000000       1 trace.go:81] Trace[4]: \"Get\" (started: 2020-10-02 09:52:59.900000000 +0000 UTC m=+45.462536308) (total time: 100ms):",
+		"I1002 09:55:00.000000       1 trace.go:81] Trace[5]: \"Get\" (started: 2020-10-02 09:54:59.900000000 +0000 UTC m=+45.462536308) (total time: 100ms):",
+		"Trace[4]: [100ms] [100ms] END",
+		"Trace[5]: [100ms] [100ms] END",
+	)
+	assert.Equal(t, []string{
+		"/1 orphaned",
+		"/2 overwritten",
+		"/3 duplicated",
+		"/3 duplicated",
+		"/4 orphaned",
+	}, result.errors)
+
+	assert.Equal(t, 5, len(result.traces))
+	assert.Equal(t, []bool{false, true, true, true, false}, result.completed)
+	assert.Equal(t, "\"Get\"", result.traces[0].steps[0].stepMessage)
+	assert.Equal(t, "\"List\"", result.traces[1].steps[0].stepMessage)
+	assert.Equal(t, "3", result.traces[2].traceId)
+	assert.Equal(t, "5", result.traces[3].traceId)
+	assert.Equal(t, "4", result.traces[4].traceId)
+}
+
+func Test_getTraceSource(t *testing.T) {
+	assert.Equal(t, "kube-apiserver.log-20201002-1601632508.gz", getTraceSource("kube-apiserver.log-20201002-1601632508.gz:I1002 09:51:44.416187       1 trace.go:81] Trace[452806332]: \"Get\" (started: 2020-10-02 09:51:41.129206438 +0000 UTC m=+45.462536308) (total time: 3.28696424s):"))
+	assert.Equal(t, "kube-apiserver.log-20201002-1601632508.gz", getTraceSource("kube-apiserver.log-20201002-1601632508.gz:Trace[1826955112]: [546.880485ms] [546.880485ms] END"))
+	assert.Equal(t, "", getTraceSource("I1003 02:20:09.944334       1 trace.go:81] Trace[1282699261]: \"Get\" (started: 2020-10-03 02:20:09.944256833 +0000 UTC m=+8097.689220689) (total time: 56.192µs):"))
+	assert.Equal(t, "", getTraceSource("Trace[1282699261]: [56.192µs] [1.714µs] END"))
+}
+
+func Test_getTraceLogTime(t *testing.T) {
+	logTime, isOK := getTraceLogTime("kube-apiserver.log-20201002-1601632508.gz:I1002 09:51:44.416187       1 trace.go:81] Trace[452806332]: \"Get\"")
+	assert.True(t, isOK)
+	assert.Equal(t, "1002 09:51:44.416187", logTime.Format(traceLogTimeLayout))
+
+	_, isOK = getTraceLogTime("Trace[1282699261]: [56.192µs] [1.714µs] END")
+	assert.False(t, isOK)
+}
+
+func Test_getApiserverInstance(t *testing.T) {
+	assert.Equal(t, "", getApiserverInstance(""))
+	assert.Equal(t, "master-0", getApiserverInstance("master-0/kube-apiserver.log-20201002-1601632508.gz"))
+	assert.Equal(t, "kube-apiserver", getApiserverInstance("kube-apiserver.log-20201002-1601632508.gz"))
+	assert.Equal(t, "kube-apiserver-master-1", getApiserverInstance("kube-apiserver-master-1.log"))
+}
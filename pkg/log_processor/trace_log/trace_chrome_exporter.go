@@ -0,0 +1,193 @@
+package trace_log
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Chrome Trace Event Format, can be opened by Perfetto UI or chrome://tracing
+type chromeTraceEvent struct {
+	Name      string            `json:"name"`
+	Category  string            `json:"cat,omitempty"`
+	Phase     string            `json:"ph"`
+	Timestamp int64             `json:"ts"` // micro seconds
+	Duration  int64             `json:"dur"`
+	ProcessId int               `json:"pid"`
+	ThreadId  int               `json:"tid"`
+	Args      map[string]string `json:"args,omitempty"`
+}
+
+type chromeTraceFile struct {
+	TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string             `json:"displayTimeUnit"`
+}
+
+type traceSpan struct {
+	trace     *Trace
+	startTime time.Time
+	duration  time.Duration
+	completed bool
+}
+
+const chromeTraceProcessId = 1
+
+// Export traces in apiserver.Trace as duration events. Overlapping traces are put into different lanes (tid),
+// steps are nested under their trace.
+func ExportChromeTrace(pathToFind string) {
+	inputFile := "apiserver.Trace"
+	inputFilename := path.Join(pathToFind, inputFile)
+	outputFilename := path.Join(pathToFind, inputFile+".chrome.json")
+
+	spans := readTraceSpans(inputFilename)
+	lanes := assignTraceLanes(spans)
+
+	events := make([]chromeTraceEvent, 0)
+	events = append(events, chromeTraceEvent{
+		Name:      "process_name",
+		Phase:     "M",
+		ProcessId: chromeTraceProcessId,
+		Args:      map[string]string{"name": "kube-apiserver"},
+	})
+	for i, span := range spans {
+		events = append(events, getChromeTraceEvents(span, lanes[i])...)
+	}
+
+	outputFileHandler, err := os.Create(outputFilename)
+	if err != nil {
+		fmt.Printf("Error create chrome trace file [%s]: %v\n", outputFilename, err)
+		panic(err)
+	}
+	defer outputFileHandler.Close()
+
+	encoder := json.NewEncoder(outputFileHandler)
+	err = encoder.Encode(chromeTraceFile{
+		TraceEvents:     events,
+		DisplayTimeUnit: "ms",
+	})
+	if err != nil {
+		fmt.Printf("Error writing chrome trace file [%s]: %v\n", outputFilename, err)
+		panic(err)
+	}
+	fmt.Printf("Exported %d traces, %d events, %d lanes\n", len(spans), len(events), getLaneCount(lanes))
+}
+
+// Traces sorted by start time
+func readTraceSpans(inputFilename string) []*traceSpan {
+	spans := make([]*traceSpan, 0)
+	scanTraces(inputFilename, func(trace *Trace, isCompleted bool) {
+		startTime, err := getTraceStartTime(trace.startTime)
+		if err != nil {
+			fmt.Printf("Error parsing start time of trace [%s]: %v\n", trace.traceId, err)
+			return
+		}
+		duration, err := time.ParseDuration(trace.totalDuration)
+		if err != nil {
+			fmt.Printf("Error parsing total duration of trace [%s]: %v\n", trace.traceId, err)
+			return
+		}
+		spans = append(spans, &traceSpan{
+			trace:     trace,
+			startTime: startTime,
+			duration:  duration,
+			completed: isCompleted,
+		})
+	}, nil)
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].startTime.Before(spans[j].startTime)
+	})
+	return spans
+}
+
+// 2020-10-03 02:20:09.944256833 or 2021-10-12 10:00:00.000, in UTC
+func getTraceStartTime(startTime string) (time.Time, error) {
+	return time.Parse("2006-01-02 15:04:05.999999999", startTime)
+}
+
+// Greedy lane assignment on spans sorted by start time, span goes to the first lane that has ended.
+func assignTraceLanes(spans []*traceSpan) []int {
+	lanes := make([]int, len(spans))
+	laneEndTimes := make([]time.Time, 0)
+	for i, span := range spans {
+		lane := -1
+		for j, endTime := range laneEndTimes {
+			if !endTime.After(span.startTime) {
+				lane = j
+				break
+			}
+		}
+		if lane == -1 {
+			lane = len(laneEndTimes)
+			laneEndTimes = append(laneEndTimes, time.Time{})
+		}
+		laneEndTimes[lane] = span.startTime.Add(span.duration)
+		lanes[i] = lane
+	}
+	return lanes
+}
+
+func getLaneCount(lanes []int) int {
+	count := 0
+	for _, lane := range lanes {
+		if lane+1 > count {
+			count = lane + 1
+		}
+	}
+	return count
+}
+
+func getChromeTraceEvents(span *traceSpan, lane int) []chromeTraceEvent {
+	trace := span.trace
+	args := map[string]string{
+		"trace_id":   trace.traceId,
+		"total_time": trace.totalDuration,
+		"completed":  fmt.Sprintf("%t", span.completed),
+	}
+	for key, value := range trace.fields {
+		args[key] = value
+	}
+
+	events := []chromeTraceEvent{{
+		Name:      getTraceName(trace),
+		Category:  "trace",
+		Phase:     "X",
+		Timestamp: getMicroSeconds(span.startTime),
+		Duration:  span.duration.Microseconds(),
+		ProcessId: chromeTraceProcessId,
+		ThreadId:  lane,
+		Args:      args,
+	}}
+
+	return append(events, getStepEvents(getTimedSteps(span), lane)...)
+}
+
+func getStepEvents(steps []*timedStep, lane int) []chromeTraceEvent {
+	events := make([]chromeTraceEvent, 0)
+	for _, step := range steps {
+		category := "step"
+		if step.step.isNested {
+			category = "nested"
+		}
+		events = append(events, chromeTraceEvent{
+			Name:      strings.Trim(step.step.stepMessage, "\""),
+			Category:  category,
+			Phase:     "X",
+			Timestamp: getMicroSeconds(step.startTime),
+			Duration:  step.endTime.Sub(step.startTime).Microseconds(),
+			ProcessId: chromeTraceProcessId,
+			ThreadId:  lane,
+			Args:      step.step.fields,
+		})
+		events = append(events, getStepEvents(step.children, lane)...)
+	}
+	return events
+}
+
+func getMicroSeconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Microsecond)
+}
@@ -0,0 +1,69 @@
+package trace_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_assignTraceLanes(t *testing.T) {
+	startTime, err := getTraceStartTime("2021-10-12 10:00:00.000")
+	assert.Nil(t, err)
+	spans := []*traceSpan{
+		{startTime: startTime, duration: time.Second},
+		{startTime: startTime.Add(500 * time.Millisecond), duration: time.Second},
+		{startTime: startTime.Add(time.Second), duration: time.Second},
+		{startTime: startTime.Add(1200 * time.Millisecond), duration: time.Second},
+	}
+	assert.Equal(t, []int{0, 1, 0, 2}, assignTraceLanes(spans))
+}
+
+func Test_getTraceStartTime(t *testing.T) {
+	startTime, err := getTraceStartTime("2020-10-03 02:20:09.944256833")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1601691609944256), getMicroSeconds(startTime))
+
+	startTime, err = getTraceStartTime("2021-10-12 10:00:00.000")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1634032800000000), getMicroSeconds(startTime))
+}
+
+func Test_getChromeTraceEvents(t *testing.T) {
+	lines := []string{
+		"I0304 23:50:17.055000       1 trace.go:205] Trace[1904426385]: \"Update\" url:/api/v1/namespaces/default/pods/p1,client:127.0.0.1 (04-Mar-2021 23:50:16.452) (total time: 603ms):",
+		"Trace[1904426385]: [\"GuaranteedUpdate etcd3\" type:*core.Pod 601ms (23:50:16.452)",
+		"Trace[1904426385]:  ---\"Transaction committed\" 600ms (23:50:17.052)]",
+		"Trace[1904426385]: ---\"Object stored in database\" 1ms (23:50:17.054)",
+		"Trace[1904426385]: [603ms] [1ms] END",
+	}
+	trace := &Trace{traceId: "1904426385", totalDuration: "603ms", startTime: "2021-03-04 23:50:16.452"}
+	for _, line := range lines {
+		trace.steps = append(trace.steps, ParseStep(line))
+	}
+	trace.fields = trace.steps[0].fields
+	trace.stepTree = buildStepTree(trace.steps)
+	startTime, _ := getTraceStartTime(trace.startTime)
+
+	events := getChromeTraceEvents(&traceSpan{trace: trace, startTime: startTime, duration: 603 * time.Millisecond, completed: true}, 2)
+	assert.Equal(t, 4, len(events))
+	traceStart := getMicroSeconds(startTime)
+
+	assert.Equal(t, "Update", events[0].Name)
+	assert.Equal(t, traceStart, events[0].Timestamp)
+	assert.Equal(t, int64(603000), events[0].Duration)
+	assert.Equal(t, 2, events[0].ThreadId)
+	assert.Equal(t, "127.0.0.1", events[0].Args["client"])
+
+	assert.Equal(t, "GuaranteedUpdate etcd3", events[1].Name)
+	assert.Equal(t, "nested", events[1].Category)
+	assert.Equal(t, traceStart, events[1].Timestamp)
+	assert.Equal(t, int64(601000), events[1].Duration)
+
+	assert.Equal(t, "Transaction committed", events[2].Name)
+	assert.Equal(t, traceStart, events[2].Timestamp)
+	assert.Equal(t, int64(600000), events[2].Duration)
+
+	assert.Equal(t, "Object stored in database", events[3].Name)
+	assert.Equal(t, traceStart+601000, events[3].Timestamp)
+	assert.Equal(t, int64(1000), events[3].Duration)
+}
@@ -0,0 +1,238 @@
+package trace_log
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"tools/pkg/log_processor/etcd_log"
+	"tools/pkg/log_util"
+)
+
+// Apiserver keys in trace do not have the storage prefix, e.g. key=/configmaps/test-7tbsnb-18/big-deployment-0
+const etcdStoragePrefix = "/registry"
+
+type traceEtcdCorrelation struct {
+	span         *traceSpan
+	operation    string
+	keys         []string
+	etcdRequests int
+	etcdTime     time.Duration
+}
+
+type traceEtcdOperationStats struct {
+	operation     string
+	traces        int
+	matchedTraces int
+	total         time.Duration
+	etcdTime      time.Duration
+}
+
+// Match slow traces in apiserver.Trace to the etcd range requests in etcd.to.execute.range.log on the same key, or on
+// the prefix range of the key, within the trace window. etcd time is the time covered by the matched requests, the rest of the trace is apiserver side
+// time, e.g. decoding, conversion and serialization.
+func CorrelateTraceWithEtcd(pathToFind string, slowThreshold time.Duration) {
+	inputFile := "apiserver.Trace"
+	inputFilename := path.Join(pathToFind, inputFile)
+	etcdFilename := path.Join(pathToFind, "etcd.to.execute.range.log")
+	correlationFilename := path.Join(pathToFind, inputFile+".etcd.correlation")
+	summaryFilename := path.Join(pathToFind, inputFile+".etcd.summary")
+
+	spans := readTraceSpans(inputFilename)
+	requests := etcd_log.ReadEtcdRangeRequests(etcdFilename)
+
+	correlationFileHandler, err := os.Create(correlationFilename)
+	if err != nil {
+		fmt.Printf("Error create etcd correlation file [%s]: %v\n", correlationFilename, err)
+		panic(err)
+	}
+	defer correlationFileHandler.Close()
+
+	summaryFileHandler, err := os.Create(summaryFilename)
+	if err != nil {
+		fmt.Printf("Error create etcd summary file [%s]: %v\n", summaryFilename, err)
+		panic(err)
+	}
+	defer summaryFileHandler.Close()
+
+	operations := make(map[string]*traceEtcdOperationStats)
+	slowTraces := 0
+	correlationFileHandler.WriteString("trace_id, start_time, operation, keys, total_ms, etcd_requests, etcd_ms, apiserver_ms, etcd_share\n")
+	for _, span := range spans {
+		if span.duration < slowThreshold {
+			continue
+		}
+		slowTraces++
+		correlation := getTraceEtcdCorrelation(span, requests)
+		addTraceEtcdOperationStats(operations, correlation)
+		if len(correlation.keys) == 0 {
+			continue
+		}
+
+		correlationFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %s, %.3f, %d, %.3f, %.3f, %.4f\n", span.trace.traceId,
+			span.trace.startTime, correlation.operation, strings.Join(correlation.keys, " "),
+			log_util.GetDurationInMilliSecond(span.duration), correlation.etcdRequests,
+			log_util.GetDurationInMilliSecond(correlation.etcdTime),
+			log_util.GetDurationInMilliSecond(span.duration-correlation.etcdTime),
+			getDurationShare(correlation.etcdTime, span.duration)))
+	}
+
+	summaryFileHandler.WriteString("operation, traces, matched_traces, total_ms, etcd_ms, apiserver_ms, etcd_share\n")
+	for _, operation := range getSortedTraceEtcdOperationStats(operations) {
+		summaryFileHandler.WriteString(fmt.Sprintf("%s, %d, %d, %.3f, %.3f, %.3f, %.4f\n", operation.operation,
+			operation.traces, operation.matchedTraces, log_util.GetDurationInMilliSecond(operation.total),
+			log_util.GetDurationInMilliSecond(operation.etcdTime),
+			log_util.GetDurationInMilliSecond(operation.total-operation.etcdTime),
+			getDurationShare(operation.etcdTime, operation.total)))
+	}
+	fmt.Printf("Traces %d, slow traces %d, etcd range requests %d\n", len(spans), slowTraces, len(requests))
+}
+
+// requests are sorted by log time
+func getTraceEtcdCorrelation(span *traceSpan, requests []*etcd_log.EtcdRangeRequest) *traceEtcdCorrelation {
+	correlation := &traceEtcdCorrelation{
+		span:      span,
+		operation: getMessageTemplate(getTraceName(span.trace)),
+		keys:      getTraceEtcdKeys(span.trace),
+	}
+	if len(correlation.keys) == 0 {
+		return correlation
+	}
+
+	traceEndTime := span.startTime.Add(span.duration)
+	// request is logged when completed, and the trace waits for its etcd requests to complete
+	first := sort.Search(len(requests), func(i int) bool {
+		return !requests[i].LogTime.Before(span.startTime)
+	})
+	intervals := make([][2]time.Time, 0)
+	for i := first; i < len(requests) && !requests[i].LogTime.After(traceEndTime); i++ {
+		request := requests[i]
+		if !isEtcdKeyMatched(correlation.keys, request) {
+			continue
+		}
+		correlation.etcdRequests++
+		intervals = append(intervals, [2]time.Time{request.LogTime.Add(-request.Duration), request.LogTime})
+	}
+	correlation.etcdTime = getCoveredDuration(intervals, span.startTime, traceEndTime)
+	return correlation
+}
+
+// Keys from trace message and fields, and from fields of nested etcd3 traces, with storage prefix
+func getTraceEtcdKeys(trace *Trace) []string {
+	keys := make([]string, 0)
+	addKey := func(key string) {
+		if key == "" {
+			return
+		}
+		if !strings.HasPrefix(key, etcdStoragePrefix+"/") {
+			key = etcdStoragePrefix + key
+		}
+		for _, existingKey := range keys {
+			if existingKey == key {
+				return
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	if matches := regexTraceKey.FindStringSubmatch(getTraceName(trace)); matches != nil {
+		addKey(matches[1])
+	}
+	addKey(trace.fields["key"])
+	for _, step := range trace.steps {
+		addKey(step.fields["key"])
+	}
+	return keys
+}
+
+// Request of the exact key, or prefix range request of the key, e.g. key /registry/pods/ns matches
+// key:"/registry/pods/ns/" range_end:"/registry/pods/ns0" and its next pages, but not /registry/pods/ns-1
+func isEtcdKeyMatched(keys []string, request *etcd_log.EtcdRangeRequest) bool {
+	prefix, isPrefixRange := getEtcdRangePrefix(request)
+	for _, key := range keys {
+		if request.Key == key {
+			return true
+		}
+		if isPrefixRange && strings.TrimRight(prefix, "/") == strings.TrimRight(key, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Prefix of range request if range_end is the prefix with last byte plus one, e.g. range_end /registry/pods0 is
+// prefix /registry/pods/. Key of the next page starts with the prefix, e.g. /registry/pods/ns/p1\000
+func getEtcdRangePrefix(request *etcd_log.EtcdRangeRequest) (string, bool) {
+	rangeEnd := request.RangeEnd
+	if rangeEnd == "" || rangeEnd[len(rangeEnd)-1] == 0 {
+		return "", false
+	}
+	prefix := rangeEnd[:len(rangeEnd)-1] + string(rangeEnd[len(rangeEnd)-1]-1)
+	if !strings.HasPrefix(request.Key, prefix) {
+		return "", false
+	}
+	return prefix, true
+}
+
+// Total time covered by the intervals within start and end
+func getCoveredDuration(intervals [][2]time.Time, startTime, endTime time.Time) time.Duration {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i][0].Before(intervals[j][0])
+	})
+
+	var covered time.Duration
+	lastEndTime := startTime
+	for _, interval := range intervals {
+		intervalStartTime, intervalEndTime := interval[0], interval[1]
+		if intervalStartTime.Before(lastEndTime) {
+			intervalStartTime = lastEndTime
+		}
+		if intervalEndTime.After(endTime) {
+			intervalEndTime = endTime
+		}
+		if intervalEndTime.After(intervalStartTime) {
+			covered += intervalEndTime.Sub(intervalStartTime)
+			lastEndTime = intervalEndTime
+		}
+	}
+	return covered
+}
+
+func addTraceEtcdOperationStats(operations map[string]*traceEtcdOperationStats, correlation *traceEtcdCorrelation) {
+	operation, isOK := operations[correlation.operation]
+	if !isOK {
+		operation = &traceEtcdOperationStats{operation: correlation.operation}
+		operations[correlation.operation] = operation
+	}
+	operation.traces++
+	operation.total += correlation.span.duration
+	if correlation.etcdRequests > 0 {
+		operation.matchedTraces++
+		operation.etcdTime += correlation.etcdTime
+	}
+}
+
+// Operations with the largest total time first
+func getSortedTraceEtcdOperationStats(operations map[string]*traceEtcdOperationStats) []*traceEtcdOperationStats {
+	result := make([]*traceEtcdOperationStats, 0, len(operations))
+	for _, operation := range operations {
+		result = append(result, operation)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].total != result[j].total {
+			return result[i].total > result[j].total
+		}
+		return result[i].operation < result[j].operation
+	})
+	return result
+}
+
+// Message of trace start without quotes, trace id if trace has no start
+func getTraceName(trace *Trace) string {
+	if len(trace.steps) > 0 && trace.steps[0].isStart {
+		return strings.Trim(trace.steps[0].stepMessage, "\"")
+	}
+	return trace.traceId
+}
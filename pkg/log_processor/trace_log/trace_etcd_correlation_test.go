@@ -0,0 +1,93 @@
+package trace_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"tools/pkg/log_processor/etcd_log"
+)
+
+func Test_getTraceEtcdCorrelation(t *testing.T) {
+	trace := &Trace{traceId: "452806332", totalDuration: "3.28696424s", startTime: "2020-10-02 09:51:41.129206"}
+	trace.steps = append(trace.steps, ParseStep("I1002 09:51:44.416187       1 trace.go:81] Trace[452806332]: \"*****ETCD3 GetToList: key=/configmaps/test-7tbsnb-18/big-deployment-0, resourceVersion=, limit: 500, continue: \" (started: 2020-10-02 09:51:41.129206438 +0000 UTC m=+45.462536308) (total time: 3.28696424s):"))
+	startTime, _ := getTraceStartTime(trace.startTime)
+	span := &traceSpan{trace: trace, startTime: startTime, duration: 3286964240 * time.Nanosecond}
+
+	lines := []string{
+		// before trace
+		"etcd.log:2020-10-02 09:51:41.000000 I | etcdserver: read-only range request \"key:\\\"/registry/configmaps/test-7tbsnb-18/big-deployment-0\\\" \" with result \"range_response_count:1 size:100\" took (100ms) to execute",
+		"etcd.log:2020-10-02 09:51:42.129206 W | etcdserver: read-only range request \"key:\\\"/registry/configmaps/test-7tbsnb-18/big-deployment-0\\\" \" with result \"range_response_count:1 size:100\" took too long (1s) to execute",
+		// overlapping the previous request
+		"etcd.log:2020-10-02 09:51:42.629206 W | etcdserver: read-only range request \"key:\\\"/registry/configmaps/test-7tbsnb-18/big-deployment-0\\\" \" with result \"range_response_count:1 size:100\" took too long (1s) to execute",
+		// other key
+		"etcd.log:2020-10-02 09:51:43.000000 W | etcdserver: read-only range request \"key:\\\"/registry/pods/test-7tbsnb-18/p1\\\" \" with result \"range_response_count:1 size:100\" took too long (500ms) to execute",
+		// after trace
+		"etcd.log:2020-10-02 09:51:45.000000 W | etcdserver: read-only range request \"key:\\\"/registry/configmaps/test-7tbsnb-18/big-deployment-0\\\" \" with result \"range_response_count:1 size:100\" took too long (1s) to execute",
+	}
+	requests := make([]*etcd_log.EtcdRangeRequest, 0)
+	for _, line := range lines {
+		request, isOK := etcd_log.ParseEtcdRangeLine(line)
+		assert.True(t, isOK)
+		requests = append(requests, request)
+	}
+
+	correlation := getTraceEtcdCorrelation(span, requests)
+	assert.Equal(t, "*****ETCD3 GetToList: key=<key>, resourceVersion=, limit: <n>, continue: ", correlation.operation)
+	assert.Equal(t, []string{"/registry/configmaps/test-7tbsnb-18/big-deployment-0"}, correlation.keys)
+	assert.Equal(t, 2, correlation.etcdRequests)
+	assert.Equal(t, 1500*time.Millisecond, correlation.etcdTime)
+}
+
+func Test_isEtcdKeyMatched(t *testing.T) {
+	getRequest := func(key, rangeEnd string) *etcd_log.EtcdRangeRequest {
+		return &etcd_log.EtcdRangeRequest{Key: key, RangeEnd: rangeEnd}
+	}
+
+	keys := []string{"/registry/configmaps/test-7tbsnb-18/big-deployment-0"}
+	assert.True(t, isEtcdKeyMatched(keys, getRequest("/registry/configmaps/test-7tbsnb-18/big-deployment-0", "")))
+	assert.False(t, isEtcdKeyMatched(keys, getRequest("/registry/configmaps/test-7tbsnb-18/big-deployment-0-x", "")))
+	assert.False(t, isEtcdKeyMatched(keys, getRequest("/registry/configmaps/test-7tbsnb-18/", "/registry/configmaps/test-7tbsnb-180")))
+
+	keys = []string{"/registry/pods/test-7tbsnb-18"}
+	assert.True(t, isEtcdKeyMatched(keys, getRequest("/registry/pods/test-7tbsnb-18/", "/registry/pods/test-7tbsnb-180")))
+	// next page
+	assert.True(t, isEtcdKeyMatched(keys, getRequest("/registry/pods/test-7tbsnb-18/p1\\000", "/registry/pods/test-7tbsnb-180")))
+	assert.False(t, isEtcdKeyMatched(keys, getRequest("/registry/pods/test-7tbsnb-18-x/", "/registry/pods/test-7tbsnb-18-x0")))
+	// not a prefix range
+	assert.False(t, isEtcdKeyMatched(keys, getRequest("/registry/pods/test-7tbsnb-18/p1", "/registry/pods/test-7tbsnb-18/p5")))
+}
+
+func Test_getTraceEtcdKeys(t *testing.T) {
+	lines := []string{
+		"I0304 23:50:17.055000       1 trace.go:205] Trace[1904426385]: \"Update\" url:/api/v1/namespaces/default/pods/p1,client:127.0.0.1 (04-Mar-2021 23:50:16.452) (total time: 603ms):",
+		"Trace[1904426385]: [\"GuaranteedUpdate etcd3\" key:/pods/default/p1,type:*core.Pod 601ms (23:50:16.452)",
+		"Trace[1904426385]:  ---\"Transaction committed\" 600ms (23:50:17.052)]",
+		"Trace[1904426385]: [603ms] [1ms] END",
+	}
+	trace := &Trace{}
+	for _, line := range lines {
+		trace.steps = append(trace.steps, ParseStep(line))
+	}
+	trace.fields = trace.steps[0].fields
+	assert.Equal(t, []string{"/registry/pods/default/p1"}, getTraceEtcdKeys(trace))
+
+	trace = &Trace{}
+	trace.steps = append(trace.steps, ParseStep("I1003 02:20:09.944334       1 trace.go:81] Trace[1282699261]: \"getClientAndClusterIdFromKey: key=/registry/leases/kube-node-lease/hollow-node-jghlp\" (started: 2020-10-03 02:20:09.944256833 +0000 UTC m=+8097.689220689) (total time: 56.192µs):"))
+	assert.Equal(t, []string{"/registry/leases/kube-node-lease/hollow-node-jghlp"}, getTraceEtcdKeys(trace))
+}
+
+func Test_getCoveredDuration(t *testing.T) {
+	startTime, _ := getTraceStartTime("2021-10-12 10:00:00.000")
+	getInterval := func(start, end time.Duration) [2]time.Time {
+		return [2]time.Time{startTime.Add(start), startTime.Add(end)}
+	}
+
+	assert.Equal(t, time.Duration(0), getCoveredDuration(nil, startTime, startTime.Add(time.Second)))
+	intervals := [][2]time.Time{
+		getInterval(800*time.Millisecond, 1200*time.Millisecond),
+		getInterval(-100*time.Millisecond, 200*time.Millisecond),
+		getInterval(100*time.Millisecond, 300*time.Millisecond),
+		getInterval(500*time.Millisecond, 600*time.Millisecond),
+	}
+	assert.Equal(t, 600*time.Millisecond, getCoveredDuration(intervals, startTime, startTime.Add(time.Second)))
+}
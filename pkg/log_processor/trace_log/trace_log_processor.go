@@ -6,15 +6,21 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
 
 type Trace struct {
 	traceId       string
+	source        string	// log file prefixed by grep
+	instance      string	// apiserver instance, see getApiserverInstance
 	totalDuration string
 	startTime     string
+	fields        map[string]string	// utiltrace 1.19+ fields, e.g. url, user-agent, client
 	steps         []TraceStep
+	stepTree      []*TraceStepNode	// steps excluding start and end, nested by depth
 	wasCompleted  bool
 }
 
@@ -26,6 +32,10 @@ type TraceStep struct {
 	startTime string
 	isStart bool
 	isEnd bool
+	fields map[string]string
+	depth int	// indentation of utiltrace 1.19+ step, 0 for top level steps
+	stepTime string	// utiltrace 1.19+ step time, start time of nested trace
+	isNested bool	// start of nested trace, stepDuration is the total time of nested trace
 }
 
 func ExtractTraceLog(pathToFind string) {
@@ -33,18 +43,12 @@ func ExtractTraceLog(pathToFind string) {
 	inputFilename := path.Join(pathToFind, inputFile)
 	outputFilename := path.Join(pathToFind, inputFile + ".compacted")
 	errFilename := path.Join(pathToFind, inputFile + ".errortrace")
+	treeFilename := path.Join(pathToFind, inputFile + ".tree")
 
-	Trace_Parser(inputFilename, outputFilename, errFilename)
+	Trace_Parser(inputFilename, outputFilename, errFilename, treeFilename)
 }
 
-func Trace_Parser(inputFileName, outputFileName, nonMatchingFilename string) {
-	inputfileHandler, err := os.Open(inputFileName)
-	if err != nil {
-		fmt.Printf("Error open input file [%s]: %v\n", inputFileName, err)
-		panic(err)
-	}
-	defer inputfileHandler.Close()
-
+func Trace_Parser(inputFileName, outputFileName, nonMatchingFilename, treeFileName string) {
 	outputFileHandler, err := os.Create(outputFileName)
 	if err != nil {
 		fmt.Printf("Error open output file [%s]: %v\n", outputFileName, err)
@@ -52,8 +56,6 @@ func Trace_Parser(inputFileName, outputFileName, nonMatchingFilename string) {
 	}
 	defer outputFileHandler.Close()
 
-	lineReader := bufio.NewReader(inputfileHandler)
-	lineCount := 0
 	traceCount := 0
 	completedTrace := 0
 	incompleteTrace :=0
@@ -66,91 +68,58 @@ func Trace_Parser(inputFileName, outputFileName, nonMatchingFilename string) {
 		panic(err)
 	}
 	defer otherFileHandler.Close()
+	otherFileHandler.WriteString("source, instance, trace_id, error, detail\n")
+	errorCounts := make(map[string]int)
+
+	treeFileHandler, err := os.Create(treeFileName)
+	if err != nil {
+		fmt.Printf("Error open tree file [%s]: %v\n", treeFileName, err)
+		panic(err)
+	}
+	defer treeFileHandler.Close()
+
+	lineCount := scanTraces(inputFileName, func(trace *Trace, isCompleted bool) {
+		outputFileHandler.WriteString(getTraceOutput(trace))
+		treeFileHandler.WriteString(getTraceTreeOutput(trace))
+		traceCount++
+		if isCompleted {
+			completedTrace++
+		} else {
+			incompleteTrace++
+		}
+	}, func(key traceKey, category, detail string) {
+		otherFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %s, %s\n", key.source, key.instance, key.traceId, category, detail))
+		errorCounts[category]++
+	})
+	fmt.Printf("Total line %d, traces %d, completed trace %d, incomplete trace %d\n", lineCount, traceCount, completedTrace, incompleteTrace)
+	for _, category := range []string{traceErrorOrphaned, traceErrorOverwritten, traceErrorDuplicated, traceErrorInconsistentDuration} {
+		fmt.Printf("Trace error %s: %d\n", category, errorCounts[category])
+	}
+}
 
-	traces := make(map[string]*Trace)
+// Read traces from trace log. onTrace is called when a trace ends, and for the traces without end at the end of file.
+// onError is called for lines that cannot be assembled into a trace. Returns number of lines read.
+func scanTraces(inputFileName string, onTrace func(trace *Trace, isCompleted bool), onError func(key traceKey, category, detail string)) int {
+	inputfileHandler, err := os.Open(inputFileName)
+	if err != nil {
+		fmt.Printf("Error open input file [%s]: %v\n", inputFileName, err)
+		panic(err)
+	}
+	defer inputfileHandler.Close()
+
+	lineReader := bufio.NewReader(inputfileHandler)
+	assembler := newTraceAssembler(onTrace, onError)
 	for {
 		line, err := lineReader.ReadString('\n')
 		if err != nil {
 			fmt.Printf("Error read file by line: %v\n", err)
 			break
 		}
-
-		lineCount++
-		step := ParseStep(line)
-		hasError := false
-		errMsg := ""
-		if step.isStart {
-			traceStart := TraceStep{
-				traceId: step.traceId,
-				startTime: step.startTime,
-				stepMessage: step.stepMessage,
-				isStart: true,
-			}
-
-			traces[step.traceId] = &Trace{
-				traceId:       step.traceId,
-				totalDuration: step.totalDuration,
-				startTime:     step.startTime,
-				steps:         []TraceStep{traceStart},
-			}
-		} else {
-			currentTrace, isOK := traces[step.traceId]
-			if isOK {
-				if currentTrace.wasCompleted {
-					hasError = true
-					errMsg = "Duplicated trace end"
-				} else {
-					if !step.isEnd {
-						traceStep := TraceStep{
-							traceId: step.traceId,
-							stepDuration: step.stepDuration,
-							stepMessage: step.stepMessage,
-						}
-						currentTrace.steps = append(currentTrace.steps, traceStep)
-					} else {
-						currentTrace.wasCompleted = true
-						if currentTrace.totalDuration != step.totalDuration {
-							hasError = true
-							errMsg = fmt.Sprintf("Inconsistent total duration %s/%s", currentTrace.totalDuration, step.totalDuration)
-						} else {
-							traceEnd := TraceStep{
-								traceId: step.traceId,
-								stepMessage: step.stepMessage,
-								stepDuration: step.stepDuration,
-								isEnd: true,
-							}
-
-							currentTrace.steps = append(currentTrace.steps, traceEnd)
-							// output current trace
-							outputLine = getTraceOutput(currentTrace)
-							outputFileHandler.WriteString(outputLine)
-
-							// remove trace from map
-							delete(traces, step.traceId)
-							traceCount++
-							completedTrace++
-						}
-					}
-				}
-			} else {
-				hasError = true
-				errMsg = "Trace end does not have matching start"
-			}
-		}
-
-		if hasError {
-			otherFileHandler.WriteString(fmt.Sprintf("%s, %s\n", step.traceId, errMsg))
-		}
+		assembler.addLine(line)
 	}
 
-	for _, v := range traces {
-		v.wasCompleted = true
-		outputLine = getTraceOutput(v)
-		outputFileHandler.WriteString(outputLine)
-		traceCount++
-		incompleteTrace++
-	}
-	fmt.Printf("Total line %d, traces %d, completed trace %d, incomplete trace %d\n", lineCount, traceCount, completedTrace, incompleteTrace)
+	assembler.flush()
+	return assembler.lineCount
 }
 
 func ParseStep(line string) TraceStep {
@@ -160,6 +129,13 @@ func ParseStep(line string) TraceStep {
 		}
 	}()
 
+	if isUtilTraceStart(line) {
+		return parseUtilTraceStart(line)
+	}
+	if step, isOK := parseUtilTraceStep(line); isOK {
+		return step
+	}
+
 	fields := strings.Split(line, " ")
 	fieldCount := len(fields)
 	step := TraceStep{}
@@ -200,6 +176,118 @@ func ParseStep(line string) TraceStep {
 	return step
 }
 
+// utiltrace in 1.19+ has no started time
+// I1012 10:00:01.200000       1 trace.go:205] Trace[1234567890]: "List" url:/api/v1/pods,user-agent:kubectl/v1.22.0,client:10.0.0.1 (12-Oct-2021 10:00:00.000) (total time: 1.2s):
+var regexUtilTraceStart = regexp.MustCompile(`Trace\[(\d+)\]: ("(?:[^"\\]|\\.)*")\s*(.*?)\s*\((\d{2}-\w{3}-\d{4} \d{2}:\d{2}:\d{2}\.\d{3})\) \(total time: ([^)]+)\):\s*$`)
+
+// Step and nested trace, indented by depth. Nested trace ends with "]".
+// Trace[1234567890]: ---"Listing from storage done" 1.1s (10:00:01.100)
+// Trace[1904426385]: ["GuaranteedUpdate etcd3" type:*core.Pod 601ms (23:50:16.452)
+// Trace[1904426385]:  ---"Transaction committed" 600ms (23:50:17.054)]
+var regexUtilTraceStep = regexp.MustCompile(`^( *)(---|\[)("(?:[^"\\]|\\.)*")\s*(.*?)\s*(\S+) \((\d{2}:\d{2}:\d{2}\.\d{3})\)\]*\s*$`)
+
+const utilTraceStartTimeLayout = "02-Jan-2006 15:04:05.000"
+const traceStartTimeLayout = "2006-01-02 15:04:05.000"
+
+func isUtilTraceStart(line string) bool {
+	return strings.Contains(line, "(total time:") && !strings.Contains(line, "(started:")
+}
+
+func parseUtilTraceStart(line string) TraceStep {
+	step := TraceStep{isStart: true}
+	matches := regexUtilTraceStart.FindStringSubmatch(line)
+	if matches == nil {
+		fmt.Printf("Error parsing trace start line [%s]\n", line)
+		return step
+	}
+
+	step.traceId = matches[1]
+	step.stepMessage = matches[2]
+	step.fields = parseTraceFields(matches[3])
+	step.totalDuration = matches[5]
+	// same layout as started time of old format, 2021-10-12 10:00:00.000
+	startTime, err := time.Parse(utilTraceStartTimeLayout, matches[4])
+	if err != nil {
+		fmt.Printf("Error parsing trace start time [%s]: %v\n", matches[4], err)
+		step.startTime = matches[4]
+	} else {
+		step.startTime = startTime.Format(traceStartTimeLayout)
+	}
+	return step
+}
+
+func parseUtilTraceStep(line string) (TraceStep, bool) {
+	index := strings.Index(line, "]: ")
+	if index == -1 {
+		return TraceStep{}, false
+	}
+	matches := regexUtilTraceStep.FindStringSubmatch(strings.TrimRight(line[index+3:], "\r\n"))
+	if matches == nil {
+		return TraceStep{}, false
+	}
+
+	traceId, err := getTraceId(line[:index+2])
+	if err != nil {
+		fmt.Printf("Error parsing line [%s]. Error [%v]\n", line, err)
+	}
+	return TraceStep{
+		traceId:      traceId,
+		depth:        len(matches[1]),
+		isNested:     matches[2] == "[",
+		stepMessage:  matches[3],
+		fields:       parseTraceFields(matches[4]),
+		stepDuration: matches[5],
+		stepTime:     matches[6],
+	}, true
+}
+
+// url:/api/v1/pods,user-agent:kubectl/v1.22.0 (linux/amd64),client:10.0.0.1
+// Comma without following key is part of the value.
+func parseTraceFields(fieldsValue string) map[string]string {
+	fields := make(map[string]string)
+	lastKey := ""
+	for _, field := range strings.Split(fieldsValue, ",") {
+		index := strings.Index(field, ":")
+		if index <= 0 || strings.Contains(field[:index], " ") {
+			if lastKey != "" {
+				fields[lastKey] += "," + field
+			}
+			continue
+		}
+		lastKey = field[:index]
+		fields[lastKey] = field[index+1:]
+	}
+	return fields
+}
+
+// utiltrace 1.19+ logs total time in ms at start and the exact time at end
+func isSameDuration(duration1, duration2 string) bool {
+	if duration1 == duration2 {
+		return true
+	}
+	value1, err1 := time.ParseDuration(duration1)
+	value2, err2 := time.ParseDuration(duration2)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	diff := value1 - value2
+	return diff < time.Millisecond && diff > -time.Millisecond
+}
+
+func getTraceFieldsOutput(fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = key + ":" + fields[key]
+	}
+	return strings.Join(values, ",")
+}
+
 func getTraceOutput(trace *Trace) string {
 	message := fmt.Sprintf("%s, %t, %s, %s, ", trace.traceId, trace.wasCompleted, getDurationInMicroSecond(trace.totalDuration), trace.startTime)
 	for i:=0; i<len(trace.steps); i++ {
@@ -60,4 +60,54 @@ func Test_getTraceId(t *testing.T) {
 	traceId, err = getTraceId("Trace[1282699261]:")
 	assert.Nil(t, err)
 	assert.Equal(t, "1282699261", traceId)
-}
\ No newline at end of file
+}
+
+func Test_ParseStep_UtilTrace(t *testing.T) {
+	// I1012 10:00:01.200000       1 trace.go:205] Trace[1234567890]: "List" url:/api/v1/pods,user-agent:kubectl/v1.22.0 (linux/amd64) kubernetes/c2b5237,client:10.0.0.1 (12-Oct-2021 10:00:00.000) (total time: 1200ms):
+	step := ParseStep("I1012 10:00:01.200000       1 trace.go:205] Trace[1234567890]: \"List\" url:/api/v1/pods,user-agent:kubectl/v1.22.0 (linux/amd64) kubernetes/c2b5237,client:10.0.0.1 (12-Oct-2021 10:00:00.000) (total time: 1200ms):")
+	assert.True(t, step.isStart)
+	assert.Equal(t, "1234567890", step.traceId)
+	assert.Equal(t, "\"List\"", step.stepMessage)
+	assert.Equal(t, "2021-10-12 10:00:00.000", step.startTime)
+	assert.Equal(t, "1200ms", step.totalDuration)
+	assert.Equal(t, map[string]string{"url": "/api/v1/pods", "user-agent": "kubectl/v1.22.0 (linux/amd64) kubernetes/c2b5237", "client": "10.0.0.1"}, step.fields)
+
+	// Trace[1234567890]: ---"Writing http response done" count:500 99ms (10:00:01.200)
+	step = ParseStep("Trace[1234567890]: ---\"Writing http response done\" count:500 99ms (10:00:01.200)")
+	assert.False(t, step.isStart)
+	assert.False(t, step.isEnd)
+	assert.False(t, step.isNested)
+	assert.Equal(t, "1234567890", step.traceId)
+	assert.Equal(t, "\"Writing http response done\"", step.stepMessage)
+	assert.Equal(t, "99ms", step.stepDuration)
+	assert.Equal(t, "10:00:01.200", step.stepTime)
+	assert.Equal(t, 0, step.depth)
+	assert.Equal(t, map[string]string{"count": "500"}, step.fields)
+
+	// Trace[1904426385]: ["GuaranteedUpdate etcd3" type:*core.Pod 601ms (23:50:16.452)
+	step = ParseStep("Trace[1904426385]: [\"GuaranteedUpdate etcd3\" type:*core.Pod 601ms (23:50:16.452)")
+	assert.True(t, step.isNested)
+	assert.Equal(t, "\"GuaranteedUpdate etcd3\"", step.stepMessage)
+	assert.Equal(t, "601ms", step.stepDuration)
+	assert.Equal(t, map[string]string{"type": "*core.Pod"}, step.fields)
+
+	// Trace[1904426385]:  ---"Transaction committed" 600ms (23:50:17.054)]
+	step = ParseStep("Trace[1904426385]:  ---\"Transaction committed\" 600ms (23:50:17.054)]")
+	assert.False(t, step.isNested)
+	assert.Equal(t, 1, step.depth)
+	assert.Equal(t, "\"Transaction committed\"", step.stepMessage)
+	assert.Equal(t, "600ms", step.stepDuration)
+	assert.Equal(t, "23:50:17.054", step.stepTime)
+
+	// Trace[1234567890]: [1.2000123s] [1.2000123s] END
+	step = ParseStep("Trace[1234567890]: [1.2000123s] [1.2000123s] END")
+	assert.True(t, step.isEnd)
+	assert.Equal(t, "1.2000123s", step.totalDuration)
+}
+
+func Test_isSameDuration(t *testing.T) {
+	assert.True(t, isSameDuration("56.192µs", "56.192µs"))
+	assert.True(t, isSameDuration("1200ms", "1.2000123s"))
+	assert.False(t, isSameDuration("1200ms", "1.21s"))
+	assert.False(t, isSameDuration("invalid", "1.2s"))
+}
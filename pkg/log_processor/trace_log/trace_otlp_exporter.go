@@ -0,0 +1,236 @@
+package trace_log
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLP/JSON trace export request, ids are hex encoded
+type otlpTraceRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+)
+
+const otlpServiceName = "kube-apiserver"
+const otlpScopeName = "tools/trace_log"
+const otlpTracesPerRequest = 1000
+
+// getClientAndClusterIdFromKey: key=/registry/leases/kube-node-lease/hollow-node-jghlp
+var regexTraceKey = regexp.MustCompile(`key=([^,\s"]+)`)
+
+// Export traces in apiserver.Trace as OTLP/JSON spans. Trace is the root span and steps are child spans.
+// Spans are posted to the OTLP HTTP endpoint, e.g. http://localhost:4318/v1/traces, or written to
+// apiserver.Trace.otlp.json with one export request per line if endpoint is empty.
+func ExportOtlpTrace(pathToFind, endpoint string) error {
+	inputFile := "apiserver.Trace"
+	inputFilename := path.Join(pathToFind, inputFile)
+	outputFilename := path.Join(pathToFind, inputFile+".otlp.json")
+
+	spans := readTraceSpans(inputFilename)
+
+	var outputFileHandler *os.File
+	if endpoint == "" {
+		var err error
+		outputFileHandler, err = os.Create(outputFilename)
+		if err != nil {
+			fmt.Printf("Error create otlp file [%s]: %v\n", outputFilename, err)
+			panic(err)
+		}
+		defer outputFileHandler.Close()
+	}
+
+	spanCount := 0
+	for start := 0; start < len(spans); start += otlpTracesPerRequest {
+		end := start + otlpTracesPerRequest
+		if end > len(spans) {
+			end = len(spans)
+		}
+		otlpSpans := make([]otlpSpan, 0)
+		for _, span := range spans[start:end] {
+			otlpSpans = append(otlpSpans, getOtlpSpans(span)...)
+		}
+		spanCount += len(otlpSpans)
+
+		body, err := json.Marshal(getOtlpTraceRequest(otlpSpans))
+		if err != nil {
+			return err
+		}
+		if outputFileHandler != nil {
+			outputFileHandler.Write(append(body, '\n'))
+			continue
+		}
+		err = postOtlpTraceRequest(endpoint, body)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Exported %d traces, %d spans\n", len(spans), spanCount)
+	return nil
+}
+
+func getOtlpTraceRequest(spans []otlpSpan) otlpTraceRequest {
+	return otlpTraceRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{getOtlpAttribute("service.name", otlpServiceName)},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: spans,
+			}},
+		}},
+	}
+}
+
+func postOtlpTraceRequest(endpoint string, body []byte) error {
+	client := http.Client{Timeout: 30 * time.Second}
+	response, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return errors.New(fmt.Sprintf("Failed posting spans to [%s]: %s", endpoint, response.Status))
+	}
+	return nil
+}
+
+// Root span of the trace followed by step spans. Ids are derived from trace id and start time so that
+// exporting the same log again gives the same ids.
+func getOtlpSpans(span *traceSpan) []otlpSpan {
+	trace := span.trace
+	traceId := getOtlpId(16, trace.traceId, trace.startTime)
+	rootSpanId := getOtlpId(8, trace.traceId, trace.startTime, "root")
+
+	attributes := []otlpAttribute{
+		getOtlpAttribute("trace_id", trace.traceId),
+		getOtlpAttribute("total_time", trace.totalDuration),
+		getOtlpAttribute("completed", strconv.FormatBool(span.completed)),
+	}
+	if matches := regexTraceKey.FindStringSubmatch(getTraceName(trace)); matches != nil {
+		attributes = append(attributes, getOtlpAttribute("key", matches[1]))
+	}
+	attributes = append(attributes, getOtlpAttributes(trace.fields)...)
+
+	result := []otlpSpan{{
+		TraceId:           traceId,
+		SpanId:            rootSpanId,
+		Name:              getTraceName(trace),
+		Kind:              otlpSpanKindServer,
+		StartTimeUnixNano: getUnixNano(span.startTime),
+		EndTimeUnixNano:   getUnixNano(span.startTime.Add(span.duration)),
+		Attributes:        attributes,
+	}}
+	return append(result, getOtlpStepSpans(getTimedSteps(span), traceId, rootSpanId)...)
+}
+
+func getOtlpStepSpans(steps []*timedStep, traceId, parentSpanId string) []otlpSpan {
+	result := make([]otlpSpan, 0)
+	for i, step := range steps {
+		spanId := getOtlpId(8, parentSpanId, strconv.Itoa(i))
+		result = append(result, otlpSpan{
+			TraceId:           traceId,
+			SpanId:            spanId,
+			ParentSpanId:      parentSpanId,
+			Name:              strings.Trim(step.step.stepMessage, "\""),
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: getUnixNano(step.startTime),
+			EndTimeUnixNano:   getUnixNano(step.endTime),
+			Attributes:        getOtlpAttributes(step.step.fields),
+		})
+		result = append(result, getOtlpStepSpans(step.children, traceId, spanId)...)
+	}
+	return result
+}
+
+// Attributes sorted by key
+func getOtlpAttributes(fields map[string]string) []otlpAttribute {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]otlpAttribute, 0, len(fields))
+	for _, key := range keys {
+		result = append(result, getOtlpAttribute(key, fields[key]))
+	}
+	return result
+}
+
+func getOtlpAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{
+		Key:   key,
+		Value: otlpValue{StringValue: value},
+	}
+}
+
+// Hex encoded id of size bytes, hashed from values
+func getOtlpId(size int, values ...string) string {
+	hash := fnv.New128a()
+	hash.Write([]byte(strings.Join(values, "/")))
+	return hex.EncodeToString(hash.Sum(nil)[:size])
+}
+
+func getUnixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
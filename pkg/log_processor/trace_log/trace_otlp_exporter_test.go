@@ -0,0 +1,99 @@
+package trace_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_getOtlpSpans(t *testing.T) {
+	lines := []string{
+		"I0304 23:50:17.055000       1 trace.go:205] Trace[1904426385]: \"Update\" url:/api/v1/namespaces/default/pods/p1,client:127.0.0.1 (04-Mar-2021 23:50:16.452) (total time: 603ms):",
+		"Trace[1904426385]: [\"GuaranteedUpdate etcd3\" type:*core.Pod 601ms (23:50:16.452)",
+		"Trace[1904426385]:  ---\"Transaction committed\" 600ms (23:50:17.052)]",
+		"Trace[1904426385]: ---\"Object stored in database\" 1ms (23:50:17.054)",
+		"Trace[1904426385]: [603ms] [1ms] END",
+	}
+	trace := &Trace{traceId: "1904426385", totalDuration: "603ms", startTime: "2021-03-04 23:50:16.452"}
+	for _, line := range lines {
+		trace.steps = append(trace.steps, ParseStep(line))
+	}
+	trace.fields = trace.steps[0].fields
+	trace.stepTree = buildStepTree(trace.steps)
+	startTime, _ := getTraceStartTime(trace.startTime)
+
+	spans := getOtlpSpans(&traceSpan{trace: trace, startTime: startTime, duration: 603 * time.Millisecond, completed: true})
+	assert.Equal(t, 4, len(spans))
+	for _, span := range spans {
+		assert.Equal(t, spans[0].TraceId, span.TraceId)
+	}
+
+	assert.Equal(t, "Update", spans[0].Name)
+	assert.Equal(t, otlpSpanKindServer, spans[0].Kind)
+	assert.Equal(t, "", spans[0].ParentSpanId)
+	assert.Equal(t, "1614901816452000000", spans[0].StartTimeUnixNano)
+	assert.Equal(t, "1614901817055000000", spans[0].EndTimeUnixNano)
+	assert.Equal(t, []otlpAttribute{
+		getOtlpAttribute("trace_id", "1904426385"),
+		getOtlpAttribute("total_time", "603ms"),
+		getOtlpAttribute("completed", "true"),
+		getOtlpAttribute("client", "127.0.0.1"),
+		getOtlpAttribute("url", "/api/v1/namespaces/default/pods/p1"),
+	}, spans[0].Attributes)
+
+	assert.Equal(t, "GuaranteedUpdate etcd3", spans[1].Name)
+	assert.Equal(t, otlpSpanKindInternal, spans[1].Kind)
+	assert.Equal(t, spans[0].SpanId, spans[1].ParentSpanId)
+	assert.Equal(t, "1614901816452000000", spans[1].StartTimeUnixNano)
+	assert.Equal(t, "1614901817053000000", spans[1].EndTimeUnixNano)
+	assert.Equal(t, []otlpAttribute{getOtlpAttribute("type", "*core.Pod")}, spans[1].Attributes)
+
+	assert.Equal(t, "Transaction committed", spans[2].Name)
+	assert.Equal(t, spans[1].SpanId, spans[2].ParentSpanId)
+	assert.Equal(t, "1614901816452000000", spans[2].StartTimeUnixNano)
+	assert.Equal(t, "1614901817052000000", spans[2].EndTimeUnixNano)
+
+	assert.Equal(t, "Object stored in database", spans[3].Name)
+	assert.Equal(t, spans[0].SpanId, spans[3].ParentSpanId)
+	assert.Equal(t, "1614901817053000000", spans[3].StartTimeUnixNano)
+	assert.Equal(t, "1614901817054000000", spans[3].EndTimeUnixNano)
+}
+
+func Test_getOtlpSpans_key(t *testing.T) {
+	trace := &Trace{traceId: "1282699261", totalDuration: "56.192µs", startTime: "2020-10-03 02:20:09.944256833"}
+	trace.steps = append(trace.steps, ParseStep("I1003 02:20:09.944334       1 trace.go:81] Trace[1282699261]: \"getClientAndClusterIdFromKey: key=/registry/leases/kube-node-lease/hollow-node-jghlp\" (started: 2020-10-03 02:20:09.944256833 +0000 UTC m=+8097.689220689) (total time: 56.192µs):"))
+	startTime, _ := getTraceStartTime(trace.startTime)
+
+	spans := getOtlpSpans(&traceSpan{trace: trace, startTime: startTime, duration: 56192 * time.Nanosecond})
+	assert.Equal(t, 1, len(spans))
+	assert.Contains(t, spans[0].Attributes, getOtlpAttribute("key", "/registry/leases/kube-node-lease/hollow-node-jghlp"))
+	assert.Contains(t, spans[0].Attributes, getOtlpAttribute("completed", "false"))
+}
+
+func Test_getOtlpId(t *testing.T) {
+	traceId := getOtlpId(16, "1904426385", "2021-03-04 23:50:16.452")
+	assert.Equal(t, 32, len(traceId))
+	assert.Equal(t, traceId, getOtlpId(16, "1904426385", "2021-03-04 23:50:16.452"))
+	assert.NotEqual(t, traceId, getOtlpId(16, "1904426385", "2021-03-04 23:50:16.453"))
+	assert.Equal(t, 16, len(getOtlpId(8, "1904426385")))
+}
+
+func Test_postOtlpTraceRequest(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bytes, _ := ioutil.ReadAll(r.Body)
+		body = string(bytes)
+		if strings.Contains(body, "bad") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	assert.Nil(t, postOtlpTraceRequest(server.URL, []byte("{}")))
+	assert.Equal(t, "{}", body)
+	assert.NotNil(t, postOtlpTraceRequest(server.URL, []byte("bad")))
+}
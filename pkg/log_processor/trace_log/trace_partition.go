@@ -0,0 +1,283 @@
+package trace_log
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"tools/pkg/log_util"
+)
+
+// Arktos apiserver traces the routing of keys to etcd clusters (partitions)
+// I1003 02:20:09.944334       1 trace.go:81] Trace[1282699261]: "getClientAndClusterIdFromKey: key=/registry/leases/kube-node-lease/hollow-node-jghlp" (started: 2020-10-03 02:20:09.944256833 +0000 UTC m=+8097.689220689) (total time: 56.192µs):
+// Trace[1282699261]: [54.478µs] [54.478µs] Returning from getClientAndClusterIdFromKey
+const routingTraceName = "getClientAndClusterIdFromKey"
+
+// Cluster id in the steps of routing trace, e.g. "Returning from getClientAndClusterIdFromKey. clusterId 1"
+var regexRoutingClusterId = regexp.MustCompile(`(?i)cluster\s*id\W{0,3}(\d+)`)
+
+// Partition of the routing traces without cluster id, and of the keys that are not seen in routing traces. Arktos
+// builds that log only "Returning from getClientAndClusterIdFromKey" have unknown partition for all requests, so
+// partition balance can only be read from the builds that log the cluster id.
+const unknownPartition = "unknown"
+
+// List traces, e.g. "*****ETCD3 GetToList: key=/pods/tenant-1/ns-1, ..." or "List" url:/api/v1/pods
+var regexListTrace = regexp.MustCompile(`GetToList|\bList\b`)
+
+// Keys of system tenant have no tenant, e.g. /registry/leases/kube-node-lease/hollow-node-jghlp
+const systemTenant = "system"
+
+type partitionStats struct {
+	partition       string
+	routingRequests int
+	latency         *log_util.QuantileSketch // traces other than routing traces
+}
+
+type tenantPartitionStats struct {
+	partitionStats
+	tenant string
+}
+
+// Routing requests and trace latency by etcd partition and by tenant in apiserver.Trace of Arktos.
+// Partition of a key is learned from routing traces, otherwise from the partition of its tenant.
+func ExtractTracePartitions(pathToFind string) {
+	inputFile := "apiserver.Trace"
+	inputFilename := path.Join(pathToFind, inputFile)
+	partitionFilename := path.Join(pathToFind, inputFile+".partition.output")
+	tenantFilename := path.Join(pathToFind, inputFile+".partition.tenant.output")
+	summaryFilename := path.Join(pathToFind, inputFile+".partition.summary")
+
+	spans := readTraceSpans(inputFilename)
+
+	// routing decisions
+	keyPartitions := make(map[string]string)
+	tenantPartitions := make(map[string]string)
+	partitions := make(map[string]*partitionStats)
+	tenants := make(map[string]*tenantPartitionStats)
+	for _, span := range spans {
+		key, partition, isRouting := getRoutingDecision(span.trace)
+		if !isRouting {
+			continue
+		}
+		tenant := getTenantFromKey(key, false)
+		keyPartitions[key] = partition
+		if partition != unknownPartition {
+			tenantPartitions[tenant] = partition
+		}
+		getPartitionStats(partitions, partition).routingRequests++
+		getTenantPartitionStats(tenants, tenant, partition).routingRequests++
+	}
+
+	// latency of the other traces on routed keys
+	unroutedTraces := 0
+	for _, span := range spans {
+		if strings.HasPrefix(getTraceName(span.trace), routingTraceName) {
+			continue
+		}
+		keys := getTraceEtcdKeys(span.trace)
+		if len(keys) == 0 {
+			unroutedTraces++
+			continue
+		}
+		tenant := getTenantFromKey(keys[0], isListTrace(span.trace))
+		partition, isOK := keyPartitions[keys[0]]
+		if !isOK {
+			partition, isOK = tenantPartitions[tenant]
+		}
+		if !isOK {
+			partition = unknownPartition
+		}
+
+		stats := getPartitionStats(partitions, partition)
+		stats.latency.Add(span.duration)
+		tenantStats := getTenantPartitionStats(tenants, tenant, partition)
+		tenantStats.latency.Add(span.duration)
+	}
+
+	partitionFileHandler, err := os.Create(partitionFilename)
+	if err != nil {
+		fmt.Printf("Error create partition file [%s]: %v\n", partitionFilename, err)
+		panic(err)
+	}
+	defer partitionFileHandler.Close()
+
+	tenantFileHandler, err := os.Create(tenantFilename)
+	if err != nil {
+		fmt.Printf("Error create tenant file [%s]: %v\n", tenantFilename, err)
+		panic(err)
+	}
+	defer tenantFileHandler.Close()
+
+	summaryFileHandler, err := os.Create(summaryFilename)
+	if err != nil {
+		fmt.Printf("Error create partition summary file [%s]: %v\n", summaryFilename, err)
+		panic(err)
+	}
+	defer summaryFileHandler.Close()
+
+	sortedPartitions := getSortedPartitionStats(partitions)
+	totalRoutingRequests := 0
+	for _, stats := range sortedPartitions {
+		totalRoutingRequests += stats.routingRequests
+	}
+	partitionFileHandler.WriteString("partition, routing_requests, share_of_routing_requests, traces, p50_ms, p90_ms, p99_ms, max_ms\n")
+	for _, stats := range sortedPartitions {
+		partitionFileHandler.WriteString(fmt.Sprintf("%s, %d, %.4f, %s\n", stats.partition, stats.routingRequests,
+			getCountShare(stats.routingRequests, totalRoutingRequests), log_util.GetSketchStatsOutput(stats.latency)))
+	}
+
+	tenantFileHandler.WriteString("tenant, partition, routing_requests, traces, p50_ms, p90_ms, p99_ms, max_ms\n")
+	for _, stats := range getSortedTenantPartitionStats(tenants) {
+		tenantFileHandler.WriteString(fmt.Sprintf("%s, %s, %d, %s\n", stats.tenant, stats.partition, stats.routingRequests,
+			log_util.GetSketchStatsOutput(stats.latency)))
+	}
+
+	// latency sketches by partition, and by tenant and partition
+	partitionSketches := make(map[string]*log_util.QuantileSketch, len(partitions))
+	for partition, stats := range partitions {
+		partitionSketches[partition] = stats.latency
+	}
+	tenantSketches := make(map[string]*log_util.QuantileSketch, len(tenants))
+	for _, stats := range tenants {
+		tenantSketches[stats.tenant+", "+stats.partition] = stats.latency
+	}
+	log_util.WriteQuantileSketches(partitionFilename+log_util.QuantileSketchFileSuffix, partitionSketches)
+	log_util.WriteQuantileSketches(tenantFilename+log_util.QuantileSketchFileSuffix, tenantSketches)
+
+	summaryFileHandler.WriteString("partitions, routing_requests, min_requests, max_requests, max_to_mean_requests, unknown_partition_requests\n")
+	summaryFileHandler.WriteString(getPartitionBalanceOutput(sortedPartitions) + "\n")
+	if unknown, isOK := partitions[unknownPartition]; isOK {
+		fmt.Printf("Partition is unknown for %d routing requests and %d traces, cluster id is not logged\n",
+			unknown.routingRequests, unknown.latency.Count())
+	}
+	fmt.Printf("Traces %d, routing requests %d, partitions %d, tenants %d, traces without key %d\n", len(spans),
+		totalRoutingRequests, len(partitions), len(tenants), unroutedTraces)
+}
+
+// Key and partition of getClientAndClusterIdFromKey trace. Partition is unknown if cluster id is not logged.
+func getRoutingDecision(trace *Trace) (string, string, bool) {
+	traceName := getTraceName(trace)
+	if !strings.HasPrefix(traceName, routingTraceName) {
+		return "", "", false
+	}
+	matches := regexTraceKey.FindStringSubmatch(traceName)
+	if matches == nil {
+		return "", "", false
+	}
+
+	partition := unknownPartition
+	for _, step := range trace.steps[1:] {
+		if clusterIdMatches := regexRoutingClusterId.FindStringSubmatch(step.stepMessage); clusterIdMatches != nil {
+			partition = clusterIdMatches[1]
+			break
+		}
+	}
+	return matches[1], partition, true
+}
+
+func isListTrace(trace *Trace) bool {
+	return regexListTrace.MatchString(getTraceName(trace))
+}
+
+// /registry/pods/<tenant>/<namespace>/<name>. Keys with namespace and name only, or name only, belong to system tenant.
+// List key has no name, /registry/pods/<tenant>/<namespace>, which has the same parts as a key of system tenant. It is
+// taken as list if the trace is a list or the key ends with separator as list prefix in etcd does.
+// API group is skipped, e.g. /registry/apiregistration.k8s.io/apiservices/v1.
+func getTenantFromKey(key string, isList bool) string {
+	isList = isList || strings.HasSuffix(key, "/")
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(key, etcdStoragePrefix), "/"), "/")
+	if len(parts) > 0 && strings.Contains(parts[0], ".") {
+		parts = parts[1:]
+	}
+	// resource, tenant, namespace, name
+	if len(parts) >= 4 || (isList && len(parts) == 3) {
+		return parts[1]
+	}
+	return systemTenant
+}
+
+func getPartitionStats(partitions map[string]*partitionStats, partition string) *partitionStats {
+	stats, isOK := partitions[partition]
+	if !isOK {
+		stats = &partitionStats{partition: partition, latency: log_util.NewDefaultQuantileSketch()}
+		partitions[partition] = stats
+	}
+	return stats
+}
+
+func getTenantPartitionStats(tenants map[string]*tenantPartitionStats, tenant, partition string) *tenantPartitionStats {
+	tenantKey := tenant + "/" + partition
+	stats, isOK := tenants[tenantKey]
+	if !isOK {
+		stats = &tenantPartitionStats{
+			partitionStats: partitionStats{partition: partition, latency: log_util.NewDefaultQuantileSketch()},
+			tenant:         tenant,
+		}
+		tenants[tenantKey] = stats
+	}
+	return stats
+}
+
+func getSortedPartitionStats(partitions map[string]*partitionStats) []*partitionStats {
+	result := make([]*partitionStats, 0, len(partitions))
+	for _, stats := range partitions {
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].partition < result[j].partition
+	})
+	return result
+}
+
+func getSortedTenantPartitionStats(tenants map[string]*tenantPartitionStats) []*tenantPartitionStats {
+	result := make([]*tenantPartitionStats, 0, len(tenants))
+	for _, stats := range tenants {
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].tenant != result[j].tenant {
+			return result[i].tenant < result[j].tenant
+		}
+		return result[i].partition < result[j].partition
+	})
+	return result
+}
+
+// Routing requests of known partitions, max to mean is 1 when partitions are balanced. Routing requests of unknown
+// partition are counted separately.
+func getPartitionBalanceOutput(partitions []*partitionStats) string {
+	count := 0
+	total := 0
+	min := 0
+	max := 0
+	unknown := 0
+	for _, stats := range partitions {
+		if stats.partition == unknownPartition {
+			unknown = stats.routingRequests
+			continue
+		}
+		if count == 0 || stats.routingRequests < min {
+			min = stats.routingRequests
+		}
+		if stats.routingRequests > max {
+			max = stats.routingRequests
+		}
+		count++
+		total += stats.routingRequests
+	}
+
+	maxToMean := 0.0
+	if total > 0 {
+		maxToMean = float64(max) * float64(count) / float64(total)
+	}
+	return fmt.Sprintf("%d, %d, %d, %d, %.4f, %d", count, total, min, max, maxToMean, unknown)
+}
+
+func getCountShare(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
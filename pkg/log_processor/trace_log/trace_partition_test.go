@@ -0,0 +1,65 @@
+package trace_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_getRoutingDecision(t *testing.T) {
+	trace := &Trace{traceId: "1282699261", source: "master-2/kube-apiserver.log", instance: "master-2"}
+	trace.steps = append(trace.steps,
+		ParseStep("I1003 02:20:09.944334       1 trace.go:81] Trace[1282699261]: \"getClientAndClusterIdFromKey: key=/registry/pods/tenant-1/ns-1/p1\" (started: 2020-10-03 02:20:09.944256833 +0000 UTC m=+8097.689220689) (total time: 56.192µs):"),
+		ParseStep("Trace[1282699261]: [54.478µs] [54.478µs] Returning from getClientAndClusterIdFromKey. clusterId 2"),
+		ParseStep("Trace[1282699261]: [56.192µs] [1.714µs] END"))
+	key, partition, isRouting := getRoutingDecision(trace)
+	assert.True(t, isRouting)
+	assert.Equal(t, "/registry/pods/tenant-1/ns-1/p1", key)
+	assert.Equal(t, "2", partition)
+
+	// cluster id is not logged, apiserver instance is not the partition
+	trace.steps[1] = ParseStep("Trace[1282699261]: [54.478µs] [54.478µs] Returning from getClientAndClusterIdFromKey")
+	_, partition, isRouting = getRoutingDecision(trace)
+	assert.True(t, isRouting)
+	assert.Equal(t, unknownPartition, partition)
+
+	trace = &Trace{traceId: "452806332"}
+	trace.steps = append(trace.steps, ParseStep("I1002 09:51:44.416187       1 trace.go:81] Trace[452806332]: \"*****ETCD3 GetToList: key=/configmaps/test-7tbsnb-18/big-deployment-0, resourceVersion=, limit: 500, continue: \" (started: 2020-10-02 09:51:41.129206438 +0000 UTC m=+45.462536308) (total time: 3.28696424s):"))
+	_, _, isRouting = getRoutingDecision(trace)
+	assert.False(t, isRouting)
+}
+
+func Test_getTenantFromKey(t *testing.T) {
+	assert.Equal(t, "tenant-1", getTenantFromKey("/registry/pods/tenant-1/ns-1/p1", false))
+	assert.Equal(t, systemTenant, getTenantFromKey("/registry/leases/kube-node-lease/hollow-node-jghlp", false))
+	assert.Equal(t, systemTenant, getTenantFromKey("/registry/minions/hollow-node-jghlp", false))
+	assert.Equal(t, "tenant-1", getTenantFromKey("/registry/apiregistration.k8s.io/apiservices/tenant-1/ns-1/a1", false))
+
+	// list
+	assert.Equal(t, "tenant-1", getTenantFromKey("/registry/pods/tenant-1/ns-1", true))
+	assert.Equal(t, "tenant-1", getTenantFromKey("/registry/pods/tenant-1/ns-1/", false))
+	assert.Equal(t, systemTenant, getTenantFromKey("/registry/minions/", true))
+}
+
+func Test_isListTrace(t *testing.T) {
+	trace := &Trace{traceId: "452806332"}
+	trace.steps = append(trace.steps, ParseStep("I1002 09:51:44.416187       1 trace.go:81] Trace[452806332]: \"*****ETCD3 GetToList: key=/configmaps/test-7tbsnb-18/big-deployment-0, resourceVersion=, limit: 500, continue: \" (started: 2020-10-02 09:51:41.129206438 +0000 UTC m=+45.462536308) (total time: 3.28696424s):"))
+	assert.True(t, isListTrace(trace))
+
+	trace = &Trace{traceId: "1"}
+	trace.steps = append(trace.steps, ParseStep("I1002 09:51:44.500000       1 trace.go:81] Trace[1]: \"List\" (started: 2020-10-02 09:51:44.300000000 +0000 UTC m=+45.462536308) (total time: 200ms):"))
+	assert.True(t, isListTrace(trace))
+
+	trace = &Trace{traceId: "1282699261"}
+	trace.steps = append(trace.steps, ParseStep("I1003 02:20:09.944334       1 trace.go:81] Trace[1282699261]: \"getClientAndClusterIdFromKey: key=/registry/leases/kube-node-lease/hollow-node-jghlp\" (started: 2020-10-03 02:20:09.944256833 +0000 UTC m=+8097.689220689) (total time: 56.192µs):"))
+	assert.False(t, isListTrace(trace))
+}
+
+func Test_getPartitionBalanceOutput(t *testing.T) {
+	partitions := []*partitionStats{
+		{partition: "0", routingRequests: 100},
+		{partition: "1", routingRequests: 300},
+		{partition: unknownPartition, routingRequests: 1000},
+	}
+	assert.Equal(t, "2, 400, 100, 300, 1.5000, 1000", getPartitionBalanceOutput(partitions))
+	assert.Equal(t, "0, 0, 0, 0, 0.0000, 0", getPartitionBalanceOutput(nil))
+}
@@ -0,0 +1,233 @@
+package trace_log
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"time"
+	"tools/pkg/log_util"
+)
+
+const topStepCount = 20
+
+type traceStepStats struct {
+	operation string
+	step      string
+	latency   *log_util.QuantileSketch
+	total     time.Duration
+}
+
+type traceOperationStats struct {
+	operation string
+	latency   *log_util.QuantileSketch
+	total     time.Duration
+	steps     map[string]*traceStepStats
+}
+
+// Normalize keys, names and numbers in trace and step messages
+var messageTemplateReplacements = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`key=[^,\s"]+`), "key=<key>"},
+	{regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`), "<uid>"},
+	{regexp.MustCompile(`(^|[\s=:"(])/[^,\s"]+`), "${1}<path>"},
+	{regexp.MustCompile(`\b[\w.-]+(/[\w.-]+)+`), "<name>"},
+	{regexp.MustCompile(`\b\d+(\.\d+)?(ns|µs|us|ms|s|m|h)?\b`), "<n>"},
+}
+
+// Step duration statistics by trace operation and step message template.
+// Steps of nested traces are named by their path, e.g. "GuaranteedUpdate etcd3" > "Transaction committed", and are also
+// counted in the time of the nested trace.
+func ExtractTraceStats(pathToFind string) {
+	inputFile := "apiserver.Trace"
+	inputFilename := path.Join(pathToFind, inputFile)
+	operationStatsFilename := path.Join(pathToFind, inputFile+".operation.stats")
+	stepStatsFilename := path.Join(pathToFind, inputFile+".step.stats")
+	topStepsFilename := path.Join(pathToFind, inputFile+".top.steps")
+
+	operations := make(map[string]*traceOperationStats)
+	incompleteTrace := 0
+	scanTraces(inputFilename, func(trace *Trace, isCompleted bool) {
+		if !isCompleted {
+			incompleteTrace++
+			return
+		}
+		addTraceStats(operations, trace)
+	}, nil)
+
+	operationStatsFileHandler, err := os.Create(operationStatsFilename)
+	if err != nil {
+		fmt.Printf("Error create operation stats file [%s]: %v\n", operationStatsFilename, err)
+		panic(err)
+	}
+	defer operationStatsFileHandler.Close()
+
+	stepStatsFileHandler, err := os.Create(stepStatsFilename)
+	if err != nil {
+		fmt.Printf("Error create step stats file [%s]: %v\n", stepStatsFilename, err)
+		panic(err)
+	}
+	defer stepStatsFileHandler.Close()
+
+	topStepsFileHandler, err := os.Create(topStepsFilename)
+	if err != nil {
+		fmt.Printf("Error create top steps file [%s]: %v\n", topStepsFilename, err)
+		panic(err)
+	}
+	defer topStepsFileHandler.Close()
+
+	sortedOperations := getSortedOperationStats(operations)
+	var totalTraceTime time.Duration
+	allSteps := make([]*traceStepStats, 0)
+	operationStatsFileHandler.WriteString("operation, count, p50_ms, p90_ms, p99_ms, max_ms, total_ms\n")
+	stepStatsFileHandler.WriteString("operation, step, count, p50_ms, p90_ms, p99_ms, max_ms, total_ms, share_of_operation_time\n")
+	for _, operation := range sortedOperations {
+		totalTraceTime += operation.total
+		operationStatsFileHandler.WriteString(fmt.Sprintf("%s, %s, %.3f\n", operation.operation,
+			log_util.GetSketchStatsOutput(operation.latency), log_util.GetDurationInMilliSecond(operation.total)))
+
+		for _, step := range getSortedStepStats(operation.steps) {
+			allSteps = append(allSteps, step)
+			stepStatsFileHandler.WriteString(fmt.Sprintf("%s, %s, %s, %.3f, %.4f\n", operation.operation, step.step,
+				log_util.GetSketchStatsOutput(step.latency), log_util.GetDurationInMilliSecond(step.total),
+				getDurationShare(step.total, operation.total)))
+		}
+	}
+
+	// steps taking the largest share of all trace time
+	sort.SliceStable(allSteps, func(i, j int) bool {
+		return allSteps[i].total > allSteps[j].total
+	})
+	topStepsFileHandler.WriteString("operation, step, count, total_ms, share_of_trace_time\n")
+	for i := 0; i < len(allSteps) && i < topStepCount; i++ {
+		step := allSteps[i]
+		topStepsFileHandler.WriteString(fmt.Sprintf("%s, %s, %d, %.3f, %.4f\n", step.operation, step.step, step.latency.Count(),
+			log_util.GetDurationInMilliSecond(step.total), getDurationShare(step.total, totalTraceTime)))
+	}
+
+	// latency sketches by operation, and by operation and step
+	operationSketches := make(map[string]*log_util.QuantileSketch, len(operations))
+	stepSketches := make(map[string]*log_util.QuantileSketch, len(allSteps))
+	for _, operation := range sortedOperations {
+		operationSketches[operation.operation] = operation.latency
+		for _, step := range operation.steps {
+			stepSketches[operation.operation+", "+step.step] = step.latency
+		}
+	}
+	log_util.WriteQuantileSketches(operationStatsFilename+log_util.QuantileSketchFileSuffix, operationSketches)
+	log_util.WriteQuantileSketches(stepStatsFilename+log_util.QuantileSketchFileSuffix, stepSketches)
+	fmt.Printf("Trace operations %d, steps %d, incomplete trace %d\n", len(operations), len(allSteps), incompleteTrace)
+}
+
+func addTraceStats(operations map[string]*traceOperationStats, trace *Trace) {
+	if len(trace.steps) == 0 || !trace.steps[0].isStart {
+		return
+	}
+	totalDuration, err := time.ParseDuration(trace.totalDuration)
+	if err != nil {
+		fmt.Printf("Error getting total duration of trace [%s]: %v\n", trace.traceId, err)
+		return
+	}
+
+	operationName := getMessageTemplate(trace.steps[0].stepMessage)
+	operation, isOK := operations[operationName]
+	if !isOK {
+		operation = &traceOperationStats{
+			operation: operationName,
+			latency:   log_util.NewDefaultQuantileSketch(),
+			steps:     make(map[string]*traceStepStats),
+		}
+		operations[operationName] = operation
+	}
+	operation.latency.Add(totalDuration)
+	operation.total += totalDuration
+
+	for _, node := range trace.stepTree {
+		addStepNodeStats(operation, node, "")
+	}
+	lastStep := trace.steps[len(trace.steps)-1]
+	if lastStep.isEnd {
+		addStepStats(operation, lastStep.stepMessage, lastStep.stepDuration)
+	}
+}
+
+func addStepNodeStats(operation *traceOperationStats, node *TraceStepNode, parentName string) {
+	stepName := getMessageTemplate(node.step.stepMessage)
+	if parentName != "" {
+		stepName = parentName + " > " + stepName
+	}
+	addStepStats(operation, stepName, node.step.stepDuration)
+
+	for _, child := range node.children {
+		addStepNodeStats(operation, child, stepName)
+	}
+}
+
+func addStepStats(operation *traceOperationStats, stepName, stepDuration string) {
+	duration, err := time.ParseDuration(stepDuration)
+	if err != nil {
+		fmt.Printf("Error getting duration [%s] of step [%s]: %v\n", stepDuration, stepName, err)
+		return
+	}
+
+	step, isOK := operation.steps[stepName]
+	if !isOK {
+		step = &traceStepStats{
+			operation: operation.operation,
+			step:      stepName,
+			latency:   log_util.NewDefaultQuantileSketch(),
+		}
+		operation.steps[stepName] = step
+	}
+	step.latency.Add(duration)
+	step.total += duration
+}
+
+// "*****ETCD3 GetToList: key=/configmaps/test-7tbsnb-18/big-deployment-0, resourceVersion=, limit: 500, continue: "
+// -> "*****ETCD3 GetToList: key=<key>, resourceVersion=, limit: <n>, continue: "
+func getMessageTemplate(message string) string {
+	for _, replacement := range messageTemplateReplacements {
+		message = replacement.regex.ReplaceAllString(message, replacement.replacement)
+	}
+	return message
+}
+
+// Operations with the largest total time first
+func getSortedOperationStats(operations map[string]*traceOperationStats) []*traceOperationStats {
+	result := make([]*traceOperationStats, 0, len(operations))
+	for _, operation := range operations {
+		result = append(result, operation)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].total != result[j].total {
+			return result[i].total > result[j].total
+		}
+		return result[i].operation < result[j].operation
+	})
+	return result
+}
+
+// Steps with the largest total time first
+func getSortedStepStats(steps map[string]*traceStepStats) []*traceStepStats {
+	result := make([]*traceStepStats, 0, len(steps))
+	for _, step := range steps {
+		result = append(result, step)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].total != result[j].total {
+			return result[i].total > result[j].total
+		}
+		return result[i].step < result[j].step
+	})
+	return result
+}
+
+func getDurationShare(duration, total time.Duration) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(duration) / float64(total)
+}
@@ -0,0 +1,52 @@
+package trace_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_getMessageTemplate(t *testing.T) {
+	assert.Equal(t, "\"*****ETCD3 GetToList: key=<key>, resourceVersion=, limit: <n>, continue: \"",
+		getMessageTemplate("\"*****ETCD3 GetToList: key=/configmaps/test-7tbsnb-18/big-deployment-0, resourceVersion=, limit: 500, continue: \""))
+	assert.Equal(t, "\"getClientAndClusterIdFromKey: key=<key>\"",
+		getMessageTemplate("\"getClientAndClusterIdFromKey: key=/registry/leases/kube-node-lease/hollow-node-jghlp\""))
+	assert.Equal(t, "\"Get\" url:<path>", getMessageTemplate("\"Get\" url:/api/v1/namespaces/default/pods/p1"))
+	assert.Equal(t, "Getting pod <name> took <n>", getMessageTemplate("Getting pod ns-1/pod-2 took 1.5ms"))
+	assert.Equal(t, "\"GuaranteedUpdate etcd3\"", getMessageTemplate("\"GuaranteedUpdate etcd3\""))
+	assert.Equal(t, "Object <uid> stored", getMessageTemplate("Object 60f0ef4c-683d-4f4a-9278-c9cd19021e4d stored"))
+}
+
+func Test_addTraceStats(t *testing.T) {
+	lines := []string{
+		"I0304 23:50:17.055000       1 trace.go:205] Trace[1904426385]: \"Update\" url:/api/v1/namespaces/default/pods/p1,client:127.0.0.1 (04-Mar-2021 23:50:16.452) (total time: 602ms):",
+		"Trace[1904426385]: [\"GuaranteedUpdate etcd3\" type:*core.Pod 601ms (23:50:16.452)",
+		"Trace[1904426385]:  ---\"Transaction committed\" 600ms (23:50:17.054)]",
+		"Trace[1904426385]: [602.1ms] [1ms] END",
+	}
+	trace := &Trace{traceId: "1904426385", totalDuration: "602ms"}
+	for _, line := range lines {
+		trace.steps = append(trace.steps, ParseStep(line))
+	}
+	trace.stepTree = buildStepTree(trace.steps)
+
+	operations := make(map[string]*traceOperationStats)
+	addTraceStats(operations, trace)
+	addTraceStats(operations, trace)
+
+	operation := operations["\"Update\""]
+	assert.NotNil(t, operation)
+	assert.Equal(t, 2, operation.latency.Count())
+	assert.Equal(t, 1204*time.Millisecond, operation.total)
+	assert.Equal(t, 3, len(operation.steps))
+
+	step := operation.steps["\"GuaranteedUpdate etcd3\" > \"Transaction committed\""]
+	assert.NotNil(t, step)
+	assert.Equal(t, 1200*time.Millisecond, step.total)
+	assert.Equal(t, 2*time.Millisecond, operation.steps["END"].total)
+
+	sortedSteps := getSortedStepStats(operation.steps)
+	assert.Equal(t, "\"GuaranteedUpdate etcd3\"", sortedSteps[0].step)
+	assert.Equal(t, "END", sortedSteps[2].step)
+	assert.InDelta(t, 0.9983, getDurationShare(sortedSteps[0].total, operation.total), 0.0001)
+}
@@ -0,0 +1,155 @@
+package trace_log
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TraceStepNode struct {
+	step     TraceStep
+	children []*TraceStepNode
+}
+
+// Step with start and end time, for exporters
+type timedStep struct {
+	step      TraceStep
+	startTime time.Time
+	endTime   time.Time
+	children  []*timedStep
+}
+
+const stepTimeLayout = "15:04:05.000"
+
+// Rebuild nested steps of utiltrace 1.19+ from step depth. Trace start and end are not included.
+// Step is a child of the closest nested trace before it with smaller depth. Old format steps are all top level.
+func buildStepTree(steps []TraceStep) []*TraceStepNode {
+	roots := make([]*TraceStepNode, 0)
+	nestedTraces := make([]*TraceStepNode, 0)
+	for _, step := range steps {
+		if step.isStart || step.isEnd {
+			continue
+		}
+
+		node := &TraceStepNode{step: step}
+		for len(nestedTraces) > 0 && nestedTraces[len(nestedTraces)-1].step.depth >= step.depth {
+			nestedTraces = nestedTraces[:len(nestedTraces)-1]
+		}
+		if len(nestedTraces) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := nestedTraces[len(nestedTraces)-1]
+			parent.children = append(parent.children, node)
+		}
+
+		if step.isNested {
+			nestedTraces = append(nestedTraces, node)
+		}
+	}
+	return roots
+}
+
+// Trace name, total duration, start time and fields, followed by steps indented by nesting level
+func getTraceTreeOutput(trace *Trace) string {
+	// Trace[1904426385] "Update" 602ms, 2021-03-04 23:50:16.452, client:127.0.0.1,url:/api/v1/namespaces/default/pods/p1
+	//   ["GuaranteedUpdate etcd3" 601ms type:*core.Pod
+	//     ---"Transaction committed" 600ms
+	//   ---"Object stored in database" 601ms
+	traceName := ""
+	if len(trace.steps) > 0 && trace.steps[0].isStart {
+		traceName = trace.steps[0].stepMessage
+	}
+	output := fmt.Sprintf("Trace[%s] %s %s, %s", trace.traceId, traceName, trace.totalDuration, trace.startTime)
+	if len(trace.fields) > 0 {
+		output += ", " + getTraceFieldsOutput(trace.fields)
+	}
+	output += "\n"
+
+	for _, node := range trace.stepTree {
+		output += getStepNodeOutput(node, 1)
+	}
+	return output
+}
+
+func getStepNodeOutput(node *TraceStepNode, level int) string {
+	prefix := "---"
+	if node.step.isNested {
+		prefix = "["
+	}
+	output := fmt.Sprintf("%s%s%s %s", strings.Repeat("  ", level), prefix, node.step.stepMessage, node.step.stepDuration)
+	if len(node.step.fields) > 0 {
+		output += " " + getTraceFieldsOutput(node.step.fields)
+	}
+	output += "\n"
+
+	for _, child := range node.children {
+		output += getStepNodeOutput(child, level+1)
+	}
+	return output
+}
+
+// Start and end time of the steps of a trace
+func getTimedSteps(span *traceSpan) []*timedStep {
+	return getTimedStepNodes(span.trace.stepTree, span.startTime, span.startTime, span.startTime.Add(span.duration))
+}
+
+// Steps are sequential, step duration is the time since the previous step.
+// utiltrace 1.19+ step time is used when logged, since steps under threshold are not logged.
+// Steps are kept within the parent.
+func getTimedStepNodes(nodes []*TraceStepNode, parentStartTime, traceStartTime, parentEndTime time.Time) []*timedStep {
+	result := make([]*timedStep, 0, len(nodes))
+	lastStepTime := parentStartTime
+	for _, node := range nodes {
+		duration, err := time.ParseDuration(node.step.stepDuration)
+		if err != nil {
+			fmt.Printf("Error parsing duration of step [%s]: %v\n", node.step.stepMessage, err)
+			continue
+		}
+
+		startTime := lastStepTime
+		if stepTime, isOK := getStepTime(traceStartTime, node.step.stepTime); isOK {
+			if node.step.isNested {
+				// start time of nested trace
+				startTime = stepTime
+			} else {
+				// end time of step
+				startTime = stepTime.Add(-duration)
+			}
+		}
+		if startTime.Before(parentStartTime) {
+			startTime = parentStartTime
+		}
+		endTime := startTime.Add(duration)
+		if endTime.After(parentEndTime) {
+			endTime = parentEndTime
+		}
+
+		result = append(result, &timedStep{
+			step:      node.step,
+			startTime: startTime,
+			endTime:   endTime,
+			children:  getTimedStepNodes(node.children, startTime, traceStartTime, endTime),
+		})
+		lastStepTime = endTime
+	}
+	return result
+}
+
+// Step time has time of day only, 10:00:01.100. Date is taken from trace start time.
+func getStepTime(traceStartTime time.Time, stepTime string) (time.Time, bool) {
+	if stepTime == "" {
+		return time.Time{}, false
+	}
+	timeOfDay, err := time.Parse(stepTimeLayout, stepTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	result := time.Date(traceStartTime.Year(), traceStartTime.Month(), traceStartTime.Day(), timeOfDay.Hour(),
+		timeOfDay.Minute(), timeOfDay.Second(), timeOfDay.Nanosecond(), traceStartTime.Location())
+	// trace crossing midnight
+	if result.Before(traceStartTime.Add(-time.Hour)) {
+		result = result.AddDate(0, 0, 1)
+	}
+	return result, true
+}
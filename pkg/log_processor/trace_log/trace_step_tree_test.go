@@ -0,0 +1,51 @@
+package trace_log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_buildStepTree(t *testing.T) {
+	lines := []string{
+		"I0304 23:50:17.055000       1 trace.go:205] Trace[1904426385]: \"Update\" url:/api/v1/namespaces/default/pods/p1,client:127.0.0.1 (04-Mar-2021 23:50:16.452) (total time: 602ms):",
+		"Trace[1904426385]: [\"GuaranteedUpdate etcd3\" type:*core.Pod 601ms (23:50:16.452)",
+		"Trace[1904426385]:  ---\"About to Encode\" 1ms (23:50:16.453)",
+		"Trace[1904426385]:  ---\"Transaction committed\" 600ms (23:50:17.054)]",
+		"Trace[1904426385]: ---\"Object stored in database\" 601ms (23:50:17.054)",
+		"Trace[1904426385]: [602.1ms] [1ms] END",
+	}
+	trace := &Trace{traceId: "1904426385", totalDuration: "602ms", startTime: "2021-03-04 23:50:16.452"}
+	for _, line := range lines {
+		step := ParseStep(line)
+		if step.isStart {
+			trace.fields = step.fields
+		}
+		trace.steps = append(trace.steps, step)
+	}
+
+	trace.stepTree = buildStepTree(trace.steps)
+	assert.Equal(t, 2, len(trace.stepTree))
+	assert.Equal(t, "\"GuaranteedUpdate etcd3\"", trace.stepTree[0].step.stepMessage)
+	assert.Equal(t, 2, len(trace.stepTree[0].children))
+	assert.Equal(t, "\"Transaction committed\"", trace.stepTree[0].children[1].step.stepMessage)
+	assert.Equal(t, "\"Object stored in database\"", trace.stepTree[1].step.stepMessage)
+	assert.Equal(t, 0, len(trace.stepTree[1].children))
+
+	expected := "Trace[1904426385] \"Update\" 602ms, 2021-03-04 23:50:16.452, client:127.0.0.1,url:/api/v1/namespaces/default/pods/p1\n" +
+		"  [\"GuaranteedUpdate etcd3\" 601ms type:*core.Pod\n" +
+		"    ---\"About to Encode\" 1ms\n" +
+		"    ---\"Transaction committed\" 600ms\n" +
+		"  ---\"Object stored in database\" 601ms\n"
+	assert.Equal(t, expected, getTraceTreeOutput(trace))
+}
+
+func Test_getStepTime(t *testing.T) {
+	traceStartTime, _ := getTraceStartTime("2021-10-12 23:59:59.900")
+	stepTime, isOK := getStepTime(traceStartTime, "00:00:00.100")
+	assert.True(t, isOK)
+	assert.Equal(t, 200*time.Millisecond, stepTime.Sub(traceStartTime))
+
+	_, isOK = getStepTime(traceStartTime, "")
+	assert.False(t, isOK)
+}
@@ -0,0 +1,34 @@
+package log_util
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+func SortDurations(durations []time.Duration) {
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+}
+
+// Get percentile (0-100) from durations sorted in ascending order, using nearest rank
+func GetDurationPercentile(sortedDurations []time.Duration, percentile float64) time.Duration {
+	if len(sortedDurations) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(percentile / 100 * float64(len(sortedDurations))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sortedDurations) {
+		rank = len(sortedDurations)
+	}
+	return sortedDurations[rank-1]
+}
+
+// Duration in milliseconds, for outputs that are read by human
+func GetDurationInMilliSecond(duration time.Duration) float64 {
+	return float64(duration.Nanoseconds()) / float64(time.Millisecond)
+}
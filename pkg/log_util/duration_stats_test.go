@@ -0,0 +1,25 @@
+package log_util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_GetDurationPercentile(t *testing.T) {
+	durations := make([]time.Duration, 0)
+	for i := 100; i > 0; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	SortDurations(durations)
+
+	assert.Equal(t, time.Millisecond, GetDurationPercentile(durations, 0))
+	assert.Equal(t, 50*time.Millisecond, GetDurationPercentile(durations, 50))
+	assert.Equal(t, 99*time.Millisecond, GetDurationPercentile(durations, 99))
+	assert.Equal(t, 100*time.Millisecond, GetDurationPercentile(durations, 100))
+	assert.Equal(t, time.Duration(0), GetDurationPercentile([]time.Duration{}, 99))
+}
+
+func Test_GetDurationInMilliSecond(t *testing.T) {
+	assert.Equal(t, 1.5, GetDurationInMilliSecond(1500*time.Microsecond))
+}
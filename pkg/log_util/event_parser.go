@@ -0,0 +1,108 @@
+package log_util
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kubernetes event recorded by event.go of any component, with the involved object.
+// Tenant is only set in Arktos logs.
+type KubeEvent struct {
+	LogTime   string // 15:04:05.000000
+	Kind      string
+	Tenant    string
+	Namespace string
+	Name      string
+	UID       string
+	Type      string // Normal or Warning
+	Reason    string
+	Message   string
+}
+
+// I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:"ReplicaSet", Namespace:"0pd8pj-testns", Name:"saturation-deployment-0-c47675f5", UID:"60f0ef4c-683d-4f4a-9278-c9cd19021e4d", APIVersion:"apps/v1", ResourceVersion:"9989", FieldPath:"", Tenant:"arktos"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: saturation-deployment-0-c47675f5-scn2w
+var regexLegacyEvent = regexp.MustCompile(`Event\(&?v1\.ObjectReference\{(.*)\}\): type: '([^']*)' reason: '([^']*)' ?(.*)$`)
+
+// Kind:"ReplicaSet", Namespace:"ns"
+var regexObjectReferenceField = regexp.MustCompile(`(\w+):("(?:[^"\\]|\\.)*"|[^,]*)`)
+
+// Parse event line in legacy or structured format. Returns false if the line is not an event. Log file prefixed by
+// grep is skipped, e.g. kube-controller-manager.log:I0409 23:36:27.556371 ...
+//
+// Legacy:
+// I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:"ReplicaSet", Namespace:"0pd8pj-testns", Name:"saturation-deployment-0-c47675f5", UID:"60f0ef4c-683d-4f4a-9278-c9cd19021e4d", APIVersion:"apps/v1", ResourceVersion:"9989", FieldPath:"", Tenant:"arktos"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: saturation-deployment-0-c47675f5-scn2w
+// Structured:
+// I1012 10:00:00.000000       1 event.go:294] "Event occurred" object="ns/saturation-deployment-0-c47675f5" kind="ReplicaSet" apiVersion="apps/v1" type="Normal" reason="SuccessfulCreate" message="Created pod: saturation-deployment-0-c47675f5-scn2w"
+func ParseEventLine(line string) (*KubeEvent, bool) {
+	_, line = SplitLogSource(strings.TrimSpace(line))
+	klogLine, isOK := ParseKlogLine(line)
+	if !isOK {
+		return nil, false
+	}
+
+	if IsStructuredKlogMessage(klogLine, "Event occurred") {
+		event := &KubeEvent{
+			LogTime: klogLine.Time,
+			Kind:    klogLine.Values["kind"],
+			Type:    klogLine.Values["type"],
+			Reason:  klogLine.Values["reason"],
+			Message: klogLine.Values["message"],
+		}
+		event.Tenant, event.Namespace, event.Name = splitEventObject(klogLine.Values["object"])
+		return event, event.Reason != ""
+	}
+
+	matches := regexLegacyEvent.FindStringSubmatch(klogLine.Message)
+	if matches == nil {
+		return nil, false
+	}
+	event := &KubeEvent{
+		LogTime: klogLine.Time,
+		Type:    matches[2],
+		Reason:  matches[3],
+		Message: matches[4],
+	}
+	for _, field := range regexObjectReferenceField.FindAllStringSubmatch(matches[1], -1) {
+		value := field[2]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		switch field[1] {
+		case "Kind":
+			event.Kind = value
+		case "Tenant":
+			event.Tenant = value
+		case "Namespace":
+			event.Namespace = value
+		case "Name":
+			event.Name = value
+		case "UID":
+			event.UID = value
+		}
+	}
+	return event, true
+}
+
+// Object of the event as tenant/namespace/name, namespace/name or name, same as the object of structured event
+func (e *KubeEvent) GetObjectKey() string {
+	fields := make([]string, 0, 3)
+	for _, field := range []string{e.Tenant, e.Namespace, e.Name} {
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return strings.Join(fields, "/")
+}
+
+// tenant/namespace/name, namespace/name or name
+func splitEventObject(object string) (string, string, string) {
+	fields := strings.Split(object, "/")
+	switch len(fields) {
+	case 1:
+		return "", "", fields[0]
+	case 2:
+		return "", fields[0], fields[1]
+	default:
+		return fields[0], fields[1], strings.Join(fields[2:], "/")
+	}
+}
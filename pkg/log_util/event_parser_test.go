@@ -0,0 +1,61 @@
+package log_util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_ParseEventLine(t *testing.T) {
+	inputLine := "I0409 23:36:27.556371       1 event.go:259] Event(v1.ObjectReference{Kind:\"ReplicaSet\", Namespace:\"0pd8pj-testns\", Name:\"saturation-deployment-0-c47675f5\", UID:\"60f0ef4c-683d-4f4a-9278-c9cd19021e4d\", APIVersion:\"apps/v1\", ResourceVersion:\"9989\", FieldPath:\"\", Tenant:\"arktos\"}): type: 'Normal' reason: 'SuccessfulCreate' Created pod: saturation-deployment-0-c47675f5-scn2w"
+	event, isOK := ParseEventLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, &KubeEvent{
+		LogTime:   "23:36:27.556371",
+		Kind:      "ReplicaSet",
+		Tenant:    "arktos",
+		Namespace: "0pd8pj-testns",
+		Name:      "saturation-deployment-0-c47675f5",
+		UID:       "60f0ef4c-683d-4f4a-9278-c9cd19021e4d",
+		Type:      "Normal",
+		Reason:    "SuccessfulCreate",
+		Message:   "Created pod: saturation-deployment-0-c47675f5-scn2w",
+	}, event)
+	assert.Equal(t, "arktos/0pd8pj-testns/saturation-deployment-0-c47675f5", event.GetObjectKey())
+
+	event, isOK = ParseEventLine("kube-controller-manager.log:" + inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "23:36:27.556371", event.LogTime)
+	assert.Equal(t, "SuccessfulCreate", event.Reason)
+
+	inputLine = "I0409 23:36:28.000000       1 event.go:278] Event(&v1.ObjectReference{Kind:\"Pod\", Namespace:\"ns\", Name:\"p1\", UID:\"\", APIVersion:\"v1\", ResourceVersion:\"\", FieldPath:\"\"}): type: 'Warning' reason: 'FailedScheduling' 0/500 nodes are available: 500 Insufficient cpu, 3 node(s) had taints."
+	event, isOK = ParseEventLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "Pod", event.Kind)
+	assert.Equal(t, "", event.Tenant)
+	assert.Equal(t, "ns/p1", event.GetObjectKey())
+	assert.Equal(t, "Warning", event.Type)
+	assert.Equal(t, "FailedScheduling", event.Reason)
+	assert.Equal(t, "0/500 nodes are available: 500 Insufficient cpu, 3 node(s) had taints.", event.Message)
+
+	inputLine = "I1012 10:00:00.000000       1 event.go:294] \"Event occurred\" object=\"ns/saturation-deployment-0-c47675f5\" kind=\"ReplicaSet\" apiVersion=\"apps/v1\" type=\"Normal\" reason=\"SuccessfulCreate\" message=\"Created pod: saturation-deployment-0-c47675f5-scn2w\""
+	event, isOK = ParseEventLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, &KubeEvent{
+		LogTime:   "10:00:00.000000",
+		Kind:      "ReplicaSet",
+		Namespace: "ns",
+		Name:      "saturation-deployment-0-c47675f5",
+		Type:      "Normal",
+		Reason:    "SuccessfulCreate",
+		Message:   "Created pod: saturation-deployment-0-c47675f5-scn2w",
+	}, event)
+
+	inputLine = "{\"ts\":1634032800.000123,\"caller\":\"record/event.go:294\",\"msg\":\"Event occurred\",\"object\":{\"name\":\"n1\"},\"kind\":\"Node\",\"type\":\"Normal\",\"reason\":\"RegisteredNode\",\"message\":\"Node n1 event: Registered Node n1 in Controller\"}"
+	event, isOK = ParseEventLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "n1", event.GetObjectKey())
+	assert.Equal(t, "RegisteredNode", event.Reason)
+
+	_, isOK = ParseEventLine("I0409 23:36:27.556371       1 replica_set.go:658] Finished syncing ReplicaSet \"ns/rs\" (1.23ms)")
+	assert.False(t, isOK)
+}
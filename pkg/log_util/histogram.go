@@ -0,0 +1,242 @@
+package log_util
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Duration histogram with inclusive upper bounds in ascending order. The last bucket counts durations above the last bound.
+// Exact histograms keep the durations for exact quantiles, streaming histograms estimate quantiles from the buckets.
+type Histogram struct {
+	bounds  []time.Duration
+	counts  []int // len(bounds) + 1
+	count   int
+	sum     time.Duration
+	min     time.Duration
+	max     time.Duration
+	exact   bool
+	samples []time.Duration
+	sorted  bool
+}
+
+// start, start+width, ..., count bounds
+func GetLinearBounds(start, width time.Duration, count int) []time.Duration {
+	bounds := make([]time.Duration, 0, count)
+	for i := 0; i < count; i++ {
+		bounds = append(bounds, start+time.Duration(i)*width)
+	}
+	return bounds
+}
+
+// start, start*factor, start*factor^2, ..., count bounds
+func GetExponentialBounds(start time.Duration, factor float64, count int) []time.Duration {
+	bounds := make([]time.Duration, 0, count)
+	bound := float64(start)
+	for i := 0; i < count; i++ {
+		bounds = append(bounds, time.Duration(math.Round(bound)))
+		bound *= factor
+	}
+	return bounds
+}
+
+// Bounds must be ascending, e.g. from GetLinearBounds, GetExponentialBounds or an explicit list
+func NewHistogram(bounds []time.Duration, exact bool) *Histogram {
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			panic(fmt.Sprintf("Histogram bounds are not ascending: %v", bounds))
+		}
+	}
+	return &Histogram{
+		bounds: append([]time.Duration{}, bounds...),
+		counts: make([]int, len(bounds)+1),
+		exact:  exact,
+	}
+}
+
+// Returns true if the duration is above the last bound
+func (h *Histogram) Add(duration time.Duration) bool {
+	if h.count == 0 || duration < h.min {
+		h.min = duration
+	}
+	if h.count == 0 || duration > h.max {
+		h.max = duration
+	}
+	h.count++
+	h.sum += duration
+	if h.exact {
+		h.samples = append(h.samples, duration)
+		h.sorted = false
+	}
+
+	index := h.getBucketIndex(duration)
+	h.counts[index]++
+	return index == len(h.bounds)
+}
+
+// Merge histogram with the same bounds, e.g. the histograms of multiple log files.
+// The result is exact only if both histograms are exact.
+func (h *Histogram) Merge(other *Histogram) error {
+	if len(h.bounds) != len(other.bounds) {
+		return fmt.Errorf("Histogram bounds do not match. %v, %v", h.bounds, other.bounds)
+	}
+	for i := range h.bounds {
+		if h.bounds[i] != other.bounds[i] {
+			return fmt.Errorf("Histogram bounds do not match. %v, %v", h.bounds, other.bounds)
+		}
+	}
+	if other.count == 0 {
+		return nil
+	}
+
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if h.count == 0 || other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+	for i := range h.counts {
+		h.counts[i] += other.counts[i]
+	}
+	if h.exact && other.exact {
+		h.samples = append(h.samples, other.samples...)
+		h.sorted = false
+	} else {
+		h.exact = false
+		h.samples = nil
+	}
+	return nil
+}
+
+func (h *Histogram) Count() int {
+	return h.count
+}
+
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// Count of durations above the bound, bound should be one of the bounds of the histogram
+func (h *Histogram) CountAbove(bound time.Duration) int {
+	count := 0
+	for i := h.getBucketIndex(bound) + 1; i < len(h.counts); i++ {
+		count += h.counts[i]
+	}
+	return count
+}
+
+// Percentile (0-100) by nearest rank. Streaming histograms interpolate linearly within the bucket of the rank,
+// the first bucket starts from min and the last bucket ends at max.
+func (h *Histogram) Quantile(percentile float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	if h.exact {
+		if !h.sorted {
+			SortDurations(h.samples)
+			h.sorted = true
+		}
+		return GetDurationPercentile(h.samples, percentile)
+	}
+
+	rank := int(math.Ceil(percentile / 100 * float64(h.count)))
+	if rank < 1 {
+		rank = 1
+	}
+	cumulative := 0
+	for i, count := range h.counts {
+		if count == 0 || cumulative+count < rank {
+			cumulative += count
+			continue
+		}
+		lower := h.min
+		if i > 0 && h.bounds[i-1] > lower {
+			lower = h.bounds[i-1]
+		}
+		upper := h.max
+		if i < len(h.bounds) && h.bounds[i] < upper {
+			upper = h.bounds[i]
+		}
+		return lower + time.Duration(float64(upper-lower)*float64(rank-cumulative)/float64(count))
+	}
+	return h.max
+}
+
+// <=32ms, <=50ms, ..., >2s
+func (h *Histogram) GetBucketLabels() []string {
+	labels := make([]string, 0, len(h.counts))
+	for _, bound := range h.bounds {
+		labels = append(labels, "<="+bound.String())
+	}
+	if len(h.bounds) == 0 {
+		return append(labels, "all")
+	}
+	return append(labels, ">"+h.bounds[len(h.bounds)-1].String())
+}
+
+// Bucket labels, separated by ", "
+func (h *Histogram) GetCsvHeader() string {
+	return strings.Join(h.GetBucketLabels(), ", ")
+}
+
+// Bucket counts, separated by ", "
+func (h *Histogram) GetCsvLine() string {
+	counts := make([]string, 0, len(h.counts))
+	for _, count := range h.counts {
+		counts = append(counts, fmt.Sprintf("%d", count))
+	}
+	return strings.Join(counts, ", ")
+}
+
+type histogramBucketJson struct {
+	Bucket string `json:"bucket"`
+	Count  int    `json:"count"`
+}
+
+type histogramJson struct {
+	Count   int                   `json:"count"`
+	MinMs   float64               `json:"min_ms"`
+	MeanMs  float64               `json:"mean_ms"`
+	MaxMs   float64               `json:"max_ms"`
+	P50Ms   float64               `json:"p50_ms"`
+	P90Ms   float64               `json:"p90_ms"`
+	P99Ms   float64               `json:"p99_ms"`
+	Exact   bool                  `json:"exact"`
+	Buckets []histogramBucketJson `json:"buckets"`
+}
+
+func (h *Histogram) MarshalJSON() ([]byte, error) {
+	output := histogramJson{
+		Count:  h.count,
+		MinMs:  GetDurationInMilliSecond(h.min),
+		MeanMs: GetDurationInMilliSecond(h.Mean()),
+		MaxMs:  GetDurationInMilliSecond(h.max),
+		P50Ms:  GetDurationInMilliSecond(h.Quantile(50)),
+		P90Ms:  GetDurationInMilliSecond(h.Quantile(90)),
+		P99Ms:  GetDurationInMilliSecond(h.Quantile(99)),
+		Exact:  h.exact,
+	}
+	for i, label := range h.GetBucketLabels() {
+		output.Buckets = append(output.Buckets, histogramBucketJson{Bucket: label, Count: h.counts[i]})
+	}
+	return json.Marshal(output)
+}
+
+// Index of the first bound not less than the duration, len(bounds) if above the last bound
+func (h *Histogram) getBucketIndex(duration time.Duration) int {
+	return sort.Search(len(h.bounds), func(i int) bool {
+		return duration <= h.bounds[i]
+	})
+}
@@ -0,0 +1,78 @@
+package log_util
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_GetBounds(t *testing.T) {
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond},
+		GetLinearBounds(10*time.Millisecond, 10*time.Millisecond, 3))
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 8 * time.Millisecond},
+		GetExponentialBounds(time.Millisecond, 2, 4))
+}
+
+func Test_Histogram(t *testing.T) {
+	histogram := NewHistogram([]time.Duration{10 * time.Millisecond, 100 * time.Millisecond}, true)
+	assert.False(t, histogram.Add(10*time.Millisecond))
+	assert.False(t, histogram.Add(50*time.Millisecond))
+	assert.True(t, histogram.Add(time.Second))
+
+	assert.Equal(t, 3, histogram.Count())
+	assert.Equal(t, "<=10ms, <=100ms, >100ms", histogram.GetCsvHeader())
+	assert.Equal(t, "1, 1, 1", histogram.GetCsvLine())
+	assert.Equal(t, 2, histogram.CountAbove(10*time.Millisecond))
+	assert.Equal(t, 1, histogram.CountAbove(100*time.Millisecond))
+	assert.Equal(t, 50*time.Millisecond, histogram.Quantile(50))
+	assert.Equal(t, time.Second, histogram.Quantile(100))
+	assert.Equal(t, 1060*time.Millisecond/3, histogram.Mean())
+}
+
+func Test_Histogram_streamingQuantile(t *testing.T) {
+	histogram := NewHistogram(GetLinearBounds(10*time.Millisecond, 10*time.Millisecond, 10), false)
+	for i := 1; i <= 100; i++ {
+		histogram.Add(time.Duration(i) * time.Millisecond)
+	}
+
+	assert.Equal(t, 50*time.Millisecond, histogram.Quantile(50))
+	assert.Equal(t, 99*time.Millisecond, histogram.Quantile(99))
+	assert.Equal(t, time.Millisecond+900*time.Microsecond, histogram.Quantile(1))
+	assert.Equal(t, time.Duration(0), NewHistogram(nil, false).Quantile(50))
+}
+
+func Test_Histogram_Merge(t *testing.T) {
+	bounds := []time.Duration{10 * time.Millisecond}
+	histogram := NewHistogram(bounds, true)
+	histogram.Add(5 * time.Millisecond)
+	other := NewHistogram(bounds, true)
+	other.Add(20 * time.Millisecond)
+	other.Add(30 * time.Millisecond)
+
+	assert.Nil(t, histogram.Merge(other))
+	assert.Equal(t, "1, 2", histogram.GetCsvLine())
+	assert.Equal(t, 20*time.Millisecond, histogram.Quantile(50))
+	assert.Equal(t, 30*time.Millisecond, histogram.Max())
+
+	streaming := NewHistogram(bounds, false)
+	streaming.Add(time.Millisecond)
+	assert.Nil(t, histogram.Merge(streaming))
+	assert.Equal(t, 4, histogram.Count())
+	assert.Equal(t, time.Millisecond, histogram.min)
+	assert.False(t, histogram.exact)
+
+	assert.NotNil(t, histogram.Merge(NewHistogram([]time.Duration{20 * time.Millisecond}, false)))
+}
+
+func Test_Histogram_MarshalJSON(t *testing.T) {
+	histogram := NewHistogram([]time.Duration{time.Second}, true)
+	histogram.Add(500 * time.Millisecond)
+
+	bytes, err := json.Marshal(histogram)
+	assert.Nil(t, err)
+	output := histogramJson{}
+	assert.Nil(t, json.Unmarshal(bytes, &output))
+	assert.Equal(t, histogramJson{Count: 1, MinMs: 500, MeanMs: 500, MaxMs: 500, P50Ms: 500, P90Ms: 500, P99Ms: 500, Exact: true,
+		Buckets: []histogramBucketJson{{Bucket: "<=1s", Count: 1}, {Bucket: ">1s", Count: 0}}}, output)
+}
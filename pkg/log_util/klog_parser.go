@@ -0,0 +1,215 @@
+package log_util
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// One klog line in legacy, structured text or JSON format.
+// Message is the unquoted message for structured logs and the whole text after header for legacy logs.
+type KlogLine struct {
+	Severity string // I, W, E or F
+	Date     string // MMDD
+	Time     string // 15:04:05.000000
+	ThreadId string
+	Source   string // file.go:line
+	Message  string
+	Values   map[string]string
+}
+
+// I1012 10:00:00.000000       1 scheduler.go:600] "Successfully bound pod to node" pod="ns/name" node="n1" evaluatedNodes=500 feasibleNodes=500
+var regexKlogHeader = regexp.MustCompile(`^([IWEF])(\d{4}) (\d{2}:\d{2}:\d{2}\.\d{6})\s+(\d+) ([^\]]+)\] (.*)$`)
+
+// Parse klog line. Legacy lines have no values.
+//
+// Legacy:
+// I0709 01:24:22.406258       1 scheduler.go:594] pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h is bound successfully on node hollow-node-n8jw4, 230 nodes evaluated, 230 nodes were found feasible
+// Structured text:
+// I1012 10:00:00.000000       1 scheduler.go:600] "Successfully bound pod to node" pod="ns/name" node="n1" evaluatedNodes=500 feasibleNodes=500
+// JSON:
+// {"ts":1634032800.000123,"caller":"scheduler/scheduler.go:600","msg":"Successfully bound pod to node","v":2,"pod":{"name":"name","namespace":"ns"},"node":"n1","evaluatedNodes":500,"feasibleNodes":500}
+func ParseKlogLine(line string) (*KlogLine, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		return parseKlogJsonLine(line)
+	}
+
+	matches := regexKlogHeader.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, false
+	}
+
+	klogLine := &KlogLine{
+		Severity: matches[1],
+		Date:     matches[2],
+		Time:     matches[3],
+		ThreadId: matches[4],
+		Source:   matches[5],
+		Message:  matches[6],
+		Values:   make(map[string]string),
+	}
+
+	message, rest, isQuoted := readQuotedString(matches[6])
+	if isQuoted {
+		klogLine.Message = message
+		parseKlogKeyValues(rest, klogLine.Values)
+	}
+	return klogLine, true
+}
+
+// kube-controller-manager.log:I0409 23:36:27.556371       1 event.go:259] ...
+var regexLogSource = regexp.MustCompile(`^(.*?):?([IWEF]\d{4} \d{2}:\d{2}:\d{2}\.\d{6}\s)`)
+
+// Split log file prefixed by grep from the klog line. Source is empty and line is unchanged if there is no prefix.
+func SplitLogSource(line string) (string, string) {
+	matches := regexLogSource.FindStringSubmatchIndex(line)
+	if matches == nil {
+		return "", line
+	}
+	return line[matches[2]:matches[3]], line[matches[4]:]
+}
+
+// Returns true if the line is a structured log with the message, legacy lines are not checked.
+func IsStructuredKlogMessage(klogLine *KlogLine, message string) bool {
+	return len(klogLine.Values) > 0 && klogLine.Message == message
+}
+
+// Read go quoted string at the beginning of s. Returns unquoted string and the rest of s.
+func readQuotedString(s string) (string, string, bool) {
+	if !strings.HasPrefix(s, "\"") {
+		return "", s, false
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			unquoted, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", s, false
+			}
+			return unquoted, s[i+1:], true
+		}
+	}
+	return "", s, false
+}
+
+// pod="ns/name" node="n1" evaluatedNodes=500
+func parseKlogKeyValues(s string, values map[string]string) {
+	s = strings.TrimSpace(s)
+	for len(s) > 0 {
+		index := strings.Index(s, "=")
+		if index <= 0 || strings.Contains(s[:index], " ") {
+			return
+		}
+		key := s[:index]
+		s = s[index+1:]
+
+		value := ""
+		if strings.HasPrefix(s, "\"") {
+			unquoted, rest, isQuoted := readQuotedString(s)
+			if !isQuoted {
+				return
+			}
+			value = unquoted
+			s = rest
+		} else {
+			index = getKlogValueEnd(s)
+			value = s[:index]
+			s = s[index:]
+		}
+		values[key] = value
+		s = strings.TrimSpace(s)
+	}
+}
+
+// Unquoted value ends at space outside brackets, e.g. pods=[ns/p1 ns/p2] or event=&{ID:uid Type:ContainerStarted}
+func getKlogValueEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		case ' ':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+func parseKlogJsonLine(line string) (*KlogLine, bool) {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	entries := make(map[string]interface{})
+	if err := decoder.Decode(&entries); err != nil {
+		return nil, false
+	}
+	message, isOK := entries["msg"].(string)
+	if !isOK {
+		return nil, false
+	}
+
+	klogLine := &KlogLine{
+		Severity: "I",
+		Message:  message,
+		Values:   make(map[string]string),
+	}
+	for key, value := range entries {
+		switch key {
+		case "msg", "v":
+		case "ts":
+			ts, err := strconv.ParseFloat(getJsonValueString(value), 64)
+			if err != nil {
+				return nil, false
+			}
+			// seconds since epoch, in micro second precision
+			logTime := time.Unix(0, int64(math.Round(ts*1e6))*int64(time.Microsecond)).UTC()
+			klogLine.Date = logTime.Format("0102")
+			klogLine.Time = logTime.Format(logTimeLayout)
+		case "caller":
+			klogLine.Source = getJsonValueString(value)
+		default:
+			klogLine.Values[key] = getJsonValueString(value)
+		}
+	}
+	if _, isOK := klogLine.Values["err"]; isOK {
+		klogLine.Severity = "E"
+	}
+	return klogLine, true
+}
+
+// Object reference {"name":"name","namespace":"ns"} is returned as ns/name, same as structured text
+func getJsonValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case map[string]interface{}:
+		name, hasName := v["name"].(string)
+		if hasName {
+			namespace, _ := v["namespace"].(string)
+			if namespace == "" {
+				return name
+			}
+			return namespace + "/" + name
+		}
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(bytes)
+}
@@ -0,0 +1,72 @@
+package log_util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_ParseKlogLine(t *testing.T) {
+	inputLine := "I0709 01:24:22.406258       1 scheduler.go:594] pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h is bound successfully on node hollow-node-n8jw4, 230 nodes evaluated, 230 nodes were found feasible"
+	klogLine, isOK := ParseKlogLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "I", klogLine.Severity)
+	assert.Equal(t, "0709", klogLine.Date)
+	assert.Equal(t, "01:24:22.406258", klogLine.Time)
+	assert.Equal(t, "1", klogLine.ThreadId)
+	assert.Equal(t, "scheduler.go:594", klogLine.Source)
+	assert.Equal(t, "pod system/4m3obq-testns/saturation-deployment-0-5c568bc7fc-dwr6h is bound successfully on node hollow-node-n8jw4, 230 nodes evaluated, 230 nodes were found feasible", klogLine.Message)
+	assert.Equal(t, 0, len(klogLine.Values))
+
+	inputLine = "I1012 10:00:00.000000       1 scheduler.go:600] \"Successfully bound pod to node\" pod=\"ns/name\" node=\"n1\" evaluatedNodes=500 feasibleNodes=500"
+	klogLine, isOK = ParseKlogLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "10:00:00.000000", klogLine.Time)
+	assert.Equal(t, "Successfully bound pod to node", klogLine.Message)
+	assert.Equal(t, map[string]string{"pod": "ns/name", "node": "n1", "evaluatedNodes": "500", "feasibleNodes": "500"}, klogLine.Values)
+	assert.True(t, IsStructuredKlogMessage(klogLine, "Successfully bound pod to node"))
+
+	inputLine = "E1012 10:00:00.000000       1 factory.go:338] \"Error scheduling pod; retrying\" err=\"0/3 nodes are available: 3 Insufficient cpu.\" pod=\"ns/name\""
+	klogLine, isOK = ParseKlogLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "E", klogLine.Severity)
+	assert.Equal(t, "Error scheduling pod; retrying", klogLine.Message)
+	assert.Equal(t, "0/3 nodes are available: 3 Insufficient cpu.", klogLine.Values["err"])
+	assert.Equal(t, "ns/name", klogLine.Values["pod"])
+
+	inputLine = "{\"ts\":1634032800.000123,\"caller\":\"scheduler/scheduler.go:600\",\"msg\":\"Successfully bound pod to node\",\"v\":2,\"pod\":{\"name\":\"name\",\"namespace\":\"ns\"},\"node\":\"n1\",\"evaluatedNodes\":500,\"feasibleNodes\":500}"
+	klogLine, isOK = ParseKlogLine(inputLine)
+	assert.True(t, isOK)
+	assert.Equal(t, "I", klogLine.Severity)
+	assert.Equal(t, "1012", klogLine.Date)
+	assert.Equal(t, "10:00:00.000123", klogLine.Time)
+	assert.Equal(t, "scheduler/scheduler.go:600", klogLine.Source)
+	assert.Equal(t, "Successfully bound pod to node", klogLine.Message)
+	assert.Equal(t, map[string]string{"pod": "ns/name", "node": "n1", "evaluatedNodes": "500", "feasibleNodes": "500"}, klogLine.Values)
+
+	_, isOK = ParseKlogLine("not a klog line")
+	assert.False(t, isOK)
+}
+
+func Test_SplitLogSource(t *testing.T) {
+	source, line := SplitLogSource("kube-controller-manager.log:I0409 23:36:27.556371       1 event.go:259] Event")
+	assert.Equal(t, "kube-controller-manager.log", source)
+	assert.Equal(t, "I0409 23:36:27.556371       1 event.go:259] Event", line)
+
+	source, line = SplitLogSource("I0409 23:36:27.556371       1 event.go:259] Event")
+	assert.Equal(t, "", source)
+	assert.Equal(t, "I0409 23:36:27.556371       1 event.go:259] Event", line)
+
+	source, line = SplitLogSource("{\"ts\":1634032800.000123,\"msg\":\"Event occurred\"}")
+	assert.Equal(t, "", source)
+	assert.Equal(t, "{\"ts\":1634032800.000123,\"msg\":\"Event occurred\"}", line)
+}
+
+func Test_parseKlogKeyValues(t *testing.T) {
+	values := make(map[string]string)
+	parseKlogKeyValues(" pod=\"ns/name with \\\"quote\\\"\" node=n1 latency=\"1.2s\"", values)
+	assert.Equal(t, map[string]string{"pod": "ns/name with \"quote\"", "node": "n1", "latency": "1.2s"}, values)
+
+	values = make(map[string]string)
+	parseKlogKeyValues("source=\"api\" pods=[ns/p1 ns/p2] event=&{ID:uid Type:ContainerStarted Data:0d5f4c1b} node=n1", values)
+	assert.Equal(t, map[string]string{"source": "api", "pods": "[ns/p1 ns/p2]", "event": "&{ID:uid Type:ContainerStarted Data:0d5f4c1b}", "node": "n1"}, values)
+}
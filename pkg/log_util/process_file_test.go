@@ -20,6 +20,11 @@ func Test_GetTimeFromLog(t *testing.T) {
 	input3 := ""
 	resultTime, err = GetTimeFromLog(input3)
 	assert.NotNil(t, err)
+
+	input4 := "{\"ts\":1626036262.406258,\"caller\":\"scheduler/scheduler.go:600\",\"msg\":\"Successfully bound pod to node\",\"pod\":\"ns/name\"}"
+	resultTime, err = GetTimeFromLog(input4)
+	assert.Nil(t, err)
+	assert.Equal(t, "20:44:22.406258", resultTime)
 }
 
 func Test_GetTimeDiff(t *testing.T) {
@@ -33,11 +38,22 @@ func Test_GetTimeDiff(t *testing.T) {
 
 	timeDiff1, err = GetTimeDiff("01:24:21.904119", "01:24:21.904120")
 	assert.Nil(t, err)
-	assert.Equal(t, time.Duration(1*time.Nanosecond), timeDiff1)
+	assert.Equal(t, time.Duration(1*time.Microsecond), timeDiff1)
+
+	timeDiff1, err = GetTimeDiff("22:32:35.827433", "22:32:36.248275")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(420842*time.Microsecond), timeDiff1)
 
 	timeDiff1, err = GetTimeDiff("23:59:21.904119", "00:01:21.904119")
 	assert.Nil(t, err)
 	assert.Equal(t, time.Duration(2*time.Minute), timeDiff1)
+
+	timeDiff1, err = GetTimeDiff("01:24:21.904119", "01:24:21.903119")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(-1*time.Millisecond), timeDiff1)
+
+	_, err = GetTimeDiff("", "01:24:21.904120")
+	assert.NotNil(t, err)
 }
 
 func Test_parseTime(t *testing.T) {
@@ -50,6 +50,15 @@ func ExtractMatchingLines(inputFile, outputFile string, searchingRegex []string)
 }
 
 func GetTimeFromLog(line string) (string, error) {
+	// JSON klog line has time in "ts"
+	if strings.HasPrefix(line, "{") {
+		klogLine, isOK := ParseKlogLine(line)
+		if !isOK || klogLine.Time == "" {
+			return "", fmt.Errorf("Cannot get time from log [%s]", line)
+		}
+		return klogLine.Time, nil
+	}
+
 	strsByEmptySpace := strings.Split(line, " ")
 	if len(strsByEmptySpace) < 2 {
 		return "", fmt.Errorf("Cannot get time from log [%s]", line)
@@ -58,31 +67,42 @@ func GetTimeFromLog(line string) (string, error) {
 	return strsByEmptySpace[1], nil
 }
 
-// 01:24:22.406258
+// Negative diff larger than this is taken as crossing midnight, smaller ones are kept as clock skew or out of order lines
+const dayWrapThreshold = 12 * time.Hour
+
+// 01:24:22.406258. Fraction of second is in micro seconds. Log time has no date, time2 more than 12 hours earlier
+// than time1 is taken as the next day.
 func GetTimeDiff(time1, time2 string) (time.Duration, error) {
-	h1, min1, sec1, ms1, err1 := parseTime(time1)
+	h1, min1, sec1, us1, err1 := parseTime(time1)
 	if err1 != nil {
 		return 0, err1
 	}
 
-	h2, min2, sec2, ms2, err2 := parseTime(time2)
+	h2, min2, sec2, us2, err2 := parseTime(time2)
 	if err2 != nil {
 		return 0, err2
 	}
 
 	loc, _ := time.LoadLocation("Local")
 
-	resultTime1 := time.Date(2020, 1, 1, h1, min1, sec1, ms1, loc)
-	resultTime2 := time.Date(2020, 1, 1, h2, min2, sec2, ms2, loc)
-	/*if resultTime2.Before(resultTime1) {
-		resultTime2 = time.Date(2020, 1, 2, h2, min2, sec2, ms2, loc)
-	}*/
+	resultTime1 := time.Date(2020, 1, 1, h1, min1, sec1, us1*int(time.Microsecond), loc)
+	resultTime2 := time.Date(2020, 1, 1, h2, min2, sec2, us2*int(time.Microsecond), loc)
+	if resultTime2.Sub(resultTime1) < -dayWrapThreshold {
+		resultTime2 = resultTime2.AddDate(0, 0, 1)
+	}
 
 	timeDiff := resultTime2.Sub(resultTime1)
 	//fmt.Printf("Start time %s, completed time %s, diff %v\n", time1, time2, timeDiff)
 	return timeDiff, nil
 }
 
+const logTimeLayout = "15:04:05.000000"
+
+// Parse klog time of day, e.g. 01:24:22.406258. Fraction is in micro seconds.
+func ParseLogTime(logTime string) (time.Time, error) {
+	return time.Parse(logTimeLayout, logTime)
+}
+
 func parseTime(time1 string) (int, int, int, int, error) {
 	if time1 == "" {
 		return 0, 0, 0, 0, fmt.Errorf("Missing time. [%s]", time1)
@@ -0,0 +1,295 @@
+package log_util
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Relative accuracy and bin limit of quantile sketches of the processors.
+// With 1% accuracy, 2048 bins cover durations from 1ns to over 10 years without collapsing.
+const (
+	DefaultSketchRelativeAccuracy = 0.01
+	DefaultSketchMaxBins          = 2048
+)
+
+// Mergeable quantile sketch of durations in the style of DDSketch, in bounded memory.
+//
+// Durations are counted in logarithmic bins, bin i holds durations in (gamma^(i-1), gamma^i] nanoseconds where
+// gamma = (1+a)/(1-a) for relative accuracy a. Quantile q is within a*v of the exact nearest rank value v, as long as
+// v is not in the collapsed bins. When the bins exceed the limit, the lowest bins are collapsed into one, so that only
+// the lowest quantiles lose accuracy. Durations not above 1ns are counted as zero. Count, sum, min and max are exact.
+type QuantileSketch struct {
+	relativeAccuracy float64
+	logGamma         float64
+	maxBins          int
+	bins             map[int]int
+	zeroCount        int
+	count            int
+	sum              time.Duration
+	min              time.Duration
+	max              time.Duration
+}
+
+func NewQuantileSketch(relativeAccuracy float64, maxBins int) *QuantileSketch {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 || maxBins < 1 {
+		panic(fmt.Sprintf("Invalid quantile sketch. relative accuracy %v, max bins %d", relativeAccuracy, maxBins))
+	}
+	return &QuantileSketch{
+		relativeAccuracy: relativeAccuracy,
+		logGamma:         math.Log((1 + relativeAccuracy) / (1 - relativeAccuracy)),
+		maxBins:          maxBins,
+		bins:             make(map[int]int),
+	}
+}
+
+func NewDefaultQuantileSketch() *QuantileSketch {
+	return NewQuantileSketch(DefaultSketchRelativeAccuracy, DefaultSketchMaxBins)
+}
+
+func (s *QuantileSketch) Add(duration time.Duration) {
+	if s.count == 0 || duration < s.min {
+		s.min = duration
+	}
+	if s.count == 0 || duration > s.max {
+		s.max = duration
+	}
+	s.count++
+	s.sum += duration
+
+	if duration <= 1 {
+		s.zeroCount++
+		return
+	}
+	s.bins[s.getBinIndex(duration)]++
+	s.collapse()
+}
+
+// Merge sketch of the same relative accuracy, e.g. the sketches of multiple log files
+func (s *QuantileSketch) Merge(other *QuantileSketch) error {
+	if s.relativeAccuracy != other.relativeAccuracy {
+		return fmt.Errorf("Quantile sketch relative accuracy does not match. %v, %v", s.relativeAccuracy, other.relativeAccuracy)
+	}
+	if other.count == 0 {
+		return nil
+	}
+
+	if s.count == 0 || other.min < s.min {
+		s.min = other.min
+	}
+	if s.count == 0 || other.max > s.max {
+		s.max = other.max
+	}
+	s.count += other.count
+	s.sum += other.sum
+	s.zeroCount += other.zeroCount
+	for index, count := range other.bins {
+		s.bins[index] += count
+	}
+	s.collapse()
+	return nil
+}
+
+func (s *QuantileSketch) Count() int {
+	return s.count
+}
+
+func (s *QuantileSketch) Sum() time.Duration {
+	return s.sum
+}
+
+func (s *QuantileSketch) Max() time.Duration {
+	return s.max
+}
+
+// Percentile (0-100) by nearest rank, same rank as GetDurationPercentile
+func (s *QuantileSketch) Quantile(percentile float64) time.Duration {
+	if s.count == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(percentile / 100 * float64(s.count)))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank <= s.zeroCount {
+		return s.clamp(0)
+	}
+
+	cumulative := s.zeroCount
+	for _, index := range s.getSortedBinIndexes() {
+		cumulative += s.bins[index]
+		if cumulative >= rank {
+			return s.clamp(s.getBinValue(index))
+		}
+	}
+	return s.max
+}
+
+// count, p50_ms, p90_ms, p99_ms, max_ms
+func GetSketchStatsOutput(sketch *QuantileSketch) string {
+	return fmt.Sprintf("%d, %.3f, %.3f, %.3f, %.3f", sketch.Count(),
+		GetDurationInMilliSecond(sketch.Quantile(50)),
+		GetDurationInMilliSecond(sketch.Quantile(90)),
+		GetDurationInMilliSecond(sketch.Quantile(99)),
+		GetDurationInMilliSecond(sketch.Max()))
+}
+
+type quantileSketchJson struct {
+	RelativeAccuracy float64     `json:"relative_accuracy"`
+	MaxBins          int         `json:"max_bins"`
+	Count            int         `json:"count"`
+	SumNs            int64       `json:"sum_ns"`
+	MinNs            int64       `json:"min_ns"`
+	MaxNs            int64       `json:"max_ns"`
+	ZeroCount        int         `json:"zero_count"`
+	Bins             map[int]int `json:"bins"`
+}
+
+func (s *QuantileSketch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(quantileSketchJson{
+		RelativeAccuracy: s.relativeAccuracy,
+		MaxBins:          s.maxBins,
+		Count:            s.count,
+		SumNs:            int64(s.sum),
+		MinNs:            int64(s.min),
+		MaxNs:            int64(s.max),
+		ZeroCount:        s.zeroCount,
+		Bins:             s.bins,
+	})
+}
+
+func (s *QuantileSketch) UnmarshalJSON(data []byte) error {
+	input := quantileSketchJson{}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+	if input.RelativeAccuracy <= 0 || input.RelativeAccuracy >= 1 || input.MaxBins < 1 {
+		return fmt.Errorf("Invalid quantile sketch. relative accuracy %v, max bins %d", input.RelativeAccuracy, input.MaxBins)
+	}
+
+	*s = *NewQuantileSketch(input.RelativeAccuracy, input.MaxBins)
+	s.count = input.Count
+	s.sum = time.Duration(input.SumNs)
+	s.min = time.Duration(input.MinNs)
+	s.max = time.Duration(input.MaxNs)
+	s.zeroCount = input.ZeroCount
+	for index, count := range input.Bins {
+		s.bins[index] = count
+	}
+	return nil
+}
+
+// Sketches of an output are written next to it with the suffix, e.g. latency-kube-apiserver-audit.log.sketch, so that
+// sketches of multiple files or runs can be merged
+const QuantileSketchFileSuffix = ".sketch"
+
+// One sketch per line, name and JSON sketch separated by ", "
+func WriteQuantileSketches(outputFilename string, sketches map[string]*QuantileSketch) {
+	outputFileHandler, err := os.Create(outputFilename)
+	if err != nil {
+		fmt.Printf("Error create sketch file [%s]: %v\n", outputFilename, err)
+		panic(err)
+	}
+	defer outputFileHandler.Close()
+
+	names := make([]string, 0, len(sketches))
+	for name := range sketches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		bytes, err := json.Marshal(sketches[name])
+		if err != nil {
+			fmt.Printf("Error marshal sketch [%s]: %v\n", name, err)
+			continue
+		}
+		outputFileHandler.WriteString(name + ", " + string(bytes) + "\n")
+	}
+}
+
+// Sketches written by WriteQuantileSketches are merged into sketches by name
+func MergeQuantileSketchFile(inputFilename string, sketches map[string]*QuantileSketch) error {
+	inputFileHandler, err := os.Open(inputFilename)
+	if err != nil {
+		return err
+	}
+	defer inputFileHandler.Close()
+
+	lineReader := bufio.NewReader(inputFileHandler)
+	for {
+		line, err := lineReader.ReadString('\n')
+		if err != nil {
+			break
+		}
+
+		// name can have ", {", e.g. trace step, sketch in json has no space
+		index := strings.LastIndex(line, ", {")
+		if index == -1 {
+			return fmt.Errorf("Invalid sketch line [%s] in file [%s]", strings.TrimSpace(line), inputFilename)
+		}
+		sketch := &QuantileSketch{}
+		if err := json.Unmarshal([]byte(line[index+2:]), sketch); err != nil {
+			return err
+		}
+		name := line[:index]
+		if existing, isOK := sketches[name]; isOK {
+			if err := existing.Merge(sketch); err != nil {
+				return err
+			}
+		} else {
+			sketches[name] = sketch
+		}
+	}
+	return nil
+}
+
+// Bin of duration in (gamma^(i-1), gamma^i] nanoseconds
+func (s *QuantileSketch) getBinIndex(duration time.Duration) int {
+	return int(math.Ceil(math.Log(float64(duration)) / s.logGamma))
+}
+
+// Value with relative error of at most relative accuracy to any duration in the bin
+func (s *QuantileSketch) getBinValue(index int) time.Duration {
+	gamma := math.Exp(s.logGamma)
+	return time.Duration(math.Round(2 * math.Exp(float64(index)*s.logGamma) / (gamma + 1)))
+}
+
+func (s *QuantileSketch) getSortedBinIndexes() []int {
+	indexes := make([]int, 0, len(s.bins))
+	for index := range s.bins {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// Collapse the lowest bins into the next one when there are more bins than the limit
+func (s *QuantileSketch) collapse() {
+	if len(s.bins) <= s.maxBins {
+		return
+	}
+
+	indexes := s.getSortedBinIndexes()
+	collapsedCount := len(indexes) - s.maxBins
+	target := indexes[collapsedCount]
+	for _, index := range indexes[:collapsedCount] {
+		s.bins[target] += s.bins[index]
+		delete(s.bins, index)
+	}
+}
+
+func (s *QuantileSketch) clamp(duration time.Duration) time.Duration {
+	if duration < s.min {
+		return s.min
+	}
+	if duration > s.max {
+		return s.max
+	}
+	return duration
+}
@@ -0,0 +1,106 @@
+package log_util
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func Test_QuantileSketch_relativeAccuracy(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	sketch := NewDefaultQuantileSketch()
+	durations := make([]time.Duration, 0)
+	for i := 0; i < 10000; i++ {
+		// 10µs to 10s
+		duration := time.Duration(10000 * (1 + random.Float64()*1e6))
+		durations = append(durations, duration)
+		sketch.Add(duration)
+	}
+	SortDurations(durations)
+
+	for _, percentile := range []float64{1, 50, 90, 99, 99.9} {
+		exact := float64(GetDurationPercentile(durations, percentile))
+		assert.InEpsilon(t, exact, float64(sketch.Quantile(percentile)), DefaultSketchRelativeAccuracy, "p%v", percentile)
+	}
+	assert.Equal(t, durations[0], sketch.Quantile(0))
+	assert.Equal(t, durations[len(durations)-1], sketch.Quantile(100))
+	assert.Equal(t, 10000, sketch.Count())
+	assert.True(t, len(sketch.bins) < 1000)
+}
+
+func Test_QuantileSketch_zero(t *testing.T) {
+	sketch := NewDefaultQuantileSketch()
+	assert.Equal(t, time.Duration(0), sketch.Quantile(50))
+
+	sketch.Add(0)
+	sketch.Add(-time.Millisecond)
+	sketch.Add(time.Second)
+	assert.Equal(t, time.Duration(0), sketch.Quantile(50))
+	assert.Equal(t, time.Duration(0), sketch.Quantile(0))
+	assert.Equal(t, time.Second, sketch.Quantile(99))
+	assert.Equal(t, "3, 0.000, 1000.000, 1000.000, 1000.000", GetSketchStatsOutput(sketch))
+}
+
+func Test_QuantileSketch_Merge(t *testing.T) {
+	sketch := NewDefaultQuantileSketch()
+	other := NewDefaultQuantileSketch()
+	for i := 1; i <= 100; i++ {
+		if i%2 == 0 {
+			sketch.Add(time.Duration(i) * time.Millisecond)
+		} else {
+			other.Add(time.Duration(i) * time.Millisecond)
+		}
+	}
+
+	assert.Nil(t, sketch.Merge(other))
+	assert.Equal(t, 100, sketch.Count())
+	assert.Equal(t, 5050*time.Millisecond, sketch.Sum())
+	assert.InEpsilon(t, float64(50*time.Millisecond), float64(sketch.Quantile(50)), DefaultSketchRelativeAccuracy)
+	assert.Equal(t, time.Millisecond, sketch.Quantile(1))
+	assert.NotNil(t, sketch.Merge(NewQuantileSketch(0.05, DefaultSketchMaxBins)))
+}
+
+func Test_QuantileSketch_collapse(t *testing.T) {
+	sketch := NewQuantileSketch(DefaultSketchRelativeAccuracy, 10)
+	for i := 0; i < 20; i++ {
+		sketch.Add(time.Duration(1<<uint(i)) * time.Microsecond)
+	}
+
+	assert.Equal(t, 10, len(sketch.bins))
+	assert.Equal(t, 20, sketch.Count())
+	assert.InEpsilon(t, float64(time.Duration(1<<19)*time.Microsecond), float64(sketch.Quantile(100)), DefaultSketchRelativeAccuracy)
+	assert.InEpsilon(t, float64(time.Duration(1<<18)*time.Microsecond), float64(sketch.Quantile(95)), DefaultSketchRelativeAccuracy)
+}
+
+func Test_QuantileSketch_file(t *testing.T) {
+	sketch := NewDefaultQuantileSketch()
+	sketch.Add(time.Millisecond)
+	sketch.Add(time.Second)
+	bytes, err := json.Marshal(sketch)
+	assert.Nil(t, err)
+	decoded := &QuantileSketch{}
+	assert.Nil(t, json.Unmarshal(bytes, decoded))
+	assert.Equal(t, sketch, decoded)
+
+	dir, err := os.MkdirTemp("", "sketch")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := path.Join(dir, "latency.sketch")
+	WriteQuantileSketches(filename, map[string]*QuantileSketch{"get": sketch})
+
+	sketches := make(map[string]*QuantileSketch)
+	assert.Nil(t, MergeQuantileSketchFile(filename, sketches))
+	assert.Nil(t, MergeQuantileSketchFile(filename, sketches))
+	assert.Equal(t, 4, sketches["get"].Count())
+	assert.Equal(t, time.Second, sketches["get"].Max())
+
+	stepName := "\"Update\", {\"rv\": 1}"
+	WriteQuantileSketches(filename, map[string]*QuantileSketch{stepName: sketch})
+	sketches = make(map[string]*QuantileSketch)
+	assert.Nil(t, MergeQuantileSketchFile(filename, sketches))
+	assert.Equal(t, 2, sketches[stepName].Count())
+}